@@ -0,0 +1,40 @@
+// Command fakegateway runs a local stand-in for the VNPay and MoMo sandboxes
+// so the online payment flow can be exercised without network access.
+//
+// Every payment is approved. Point the server at it with:
+//
+//	VNPAY_TMN_CODE=FAKETMN VNPAY_HASH_SECRET=fake-secret \
+//	VNPAY_PAY_URL=http://localhost:9090/vnpay/pay VNPAY_API_URL=http://localhost:9090/vnpay/api \
+//	MOMO_PARTNER_CODE=FAKEMOMO MOMO_ACCESS_KEY=fake-access MOMO_SECRET_KEY=fake-secret \
+//	MOMO_ENDPOINT=http://localhost:9090/momo
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+
+	"phone-store-backend/internal/modules/payments/fakegateway"
+)
+
+var (
+	addr        = flag.String("addr", ":9090", "listen address")
+	vnpaySecret = flag.String("vnpay-secret", "fake-secret", "VNPay hash secret")
+	vnpayIPN    = flag.String("vnpay-ipn", "http://localhost:8080/api/payments/callback/VNPAY", "VNPay IPN URL")
+	momoAccess  = flag.String("momo-access-key", "fake-access", "MoMo access key")
+	momoSecret  = flag.String("momo-secret", "fake-secret", "MoMo secret key")
+)
+
+func main() {
+	flag.Parse()
+
+	gateway := fakegateway.New(fakegateway.Config{
+		VNPaySecret:   *vnpaySecret,
+		VNPayIPNURL:   *vnpayIPN,
+		MoMoAccessKey: *momoAccess,
+		MoMoSecret:    *momoSecret,
+	})
+
+	log.Printf("🧪 Fake payment gateway listening on %s", *addr)
+	log.Fatal(http.ListenAndServe(*addr, gateway))
+}
@@ -11,6 +11,7 @@ import (
 
 	"phone-store-backend/internal/config"
 	"phone-store-backend/internal/db"
+	"phone-store-backend/internal/jobs"
 	"phone-store-backend/internal/middlewares"
 	"phone-store-backend/internal/modules/auth"
 	"phone-store-backend/internal/modules/cart"
@@ -18,9 +19,13 @@ import (
 	"phone-store-backend/internal/modules/orders"
 	"phone-store-backend/internal/modules/payments"
 	"phone-store-backend/internal/modules/products"
+	"phone-store-backend/internal/modules/promotions"
+	"phone-store-backend/internal/modules/returns"
 	"phone-store-backend/internal/modules/reviews"
 	"phone-store-backend/internal/modules/shipping"
 	"phone-store-backend/internal/modules/users"
+	"phone-store-backend/internal/modules/vouchers"
+	"phone-store-backend/internal/pricing"
 
 	"github.com/gin-gonic/gin"
 )
@@ -74,13 +79,71 @@ func main() {
 	// Public product routes
 	productRepo := products.NewRepository(mongodb.Database)
 	productService := products.NewService(productRepo)
+
+	// Products saved before price ranges and search text were stored on
+	// them need both for filtering, sorting and search
+	if err := productService.SyncMissingProducts(context.Background()); err != nil {
+		log.Printf("⚠️ Failed to sync products: %v", err)
+	}
 	productHandler := products.NewHandler(productService)
 
 	api.GET("/products", productHandler.GetProducts)
+	api.GET("/products/facets", productHandler.GetFacets)
 	api.GET("/products/:slug", productHandler.GetProductBySlug)
 	api.GET("/brands", productHandler.GetBrands)
 	api.GET("/categories", productHandler.GetCategories)
 
+	// Cart preview and checkout share one pricing service
+	pricingService := pricing.NewService(pricing.NewRepository(mongodb.Database))
+
+	// Payments
+	paymentProviders := []payments.Provider{payments.NewCODProvider()}
+	if cfg.VNPayTmnCode != "" {
+		paymentProviders = append(paymentProviders, payments.NewVNPayProvider(payments.VNPayConfig{
+			TmnCode:    cfg.VNPayTmnCode,
+			HashSecret: cfg.VNPayHashSecret,
+			PayURL:     cfg.VNPayPayURL,
+			APIURL:     cfg.VNPayAPIURL,
+			ReturnURL:  cfg.VNPayReturnURL,
+		}))
+	}
+	if cfg.MoMoPartnerCode != "" {
+		paymentProviders = append(paymentProviders, payments.NewMoMoProvider(payments.MoMoConfig{
+			PartnerCode: cfg.MoMoPartnerCode,
+			AccessKey:   cfg.MoMoAccessKey,
+			SecretKey:   cfg.MoMoSecretKey,
+			Endpoint:    cfg.MoMoEndpoint,
+			RedirectURL: cfg.MoMoRedirectURL,
+			IPNURL:      cfg.MoMoIPNURL,
+		}))
+	}
+
+	if cfg.VietQRAccountNo != "" {
+		paymentProviders = append(paymentProviders, payments.NewBankTransferProvider(payments.VietQRConfig{
+			BankBIN:     cfg.VietQRBankBIN,
+			AccountNo:   cfg.VietQRAccountNo,
+			AccountName: cfg.VietQRAccountName,
+		}))
+	}
+
+	// Checkout only accepts the methods that have a provider
+	paymentMethods := make([]string, 0, len(paymentProviders))
+	for _, p := range paymentProviders {
+		paymentMethods = append(paymentMethods, p.Code())
+	}
+
+	// Orders are needed by payments to mark them paid
+	orderRepo := orders.NewRepository(mongodb.Database)
+	orderService := orders.NewService(orderRepo, pricingService, shipping.NewRepository(mongodb.Database), paymentMethods)
+
+	paymentRepo := payments.NewRepository(mongodb.Database)
+	paymentService := payments.NewService(paymentRepo, orderService, paymentProviders...)
+	paymentHandler := payments.NewHandler(paymentService)
+
+	// Retried checkout, payment and refund requests with the same
+	// Idempotency-Key get the first response back instead of running twice
+	idempotency := middlewares.Idempotency(mongodb.Database, cfg.IdempotencyTTL)
+
 	// Protected routes (require authentication)
 	protected := api.Group("")
 	protected.Use(middlewares.AuthMiddleware(cfg))
@@ -98,28 +161,39 @@ func main() {
 
 		// Cart routes
 		cartRepo := cart.NewRepository(mongodb.Database)
-		cartService := cart.NewService(cartRepo)
+		cartService := cart.NewService(cartRepo, pricingService)
 		cartHandler := cart.NewHandler(cartService)
 
 		cartGroup := protected.Group("/cart")
 		{
 			cartGroup.GET("", cartHandler.GetCart)
+			cartGroup.POST("/quote", cartHandler.Quote)
 			cartGroup.POST("/items", cartHandler.AddItem)
 			cartGroup.PUT("/items/:variantId", cartHandler.UpdateItem)
 			cartGroup.DELETE("/items/:variantId", cartHandler.RemoveItem)
 		}
 
 		// Order routes
-		orderRepo := orders.NewRepository(mongodb.Database)
-		orderService := orders.NewService(orderRepo)
 		orderHandler := orders.NewHandler(orderService)
 
 		orderGroup := protected.Group("/orders")
 		{
-			orderGroup.POST("", orderHandler.CreateOrder)
+			orderGroup.POST("", idempotency, orderHandler.CreateOrder)
 			orderGroup.GET("/me", orderHandler.GetMyOrders)
 			orderGroup.GET("/:id", orderHandler.GetOrderByID)
 			orderGroup.GET("/:id/history", orderHandler.GetOrderHistory)
+			orderGroup.POST("/:id/cancel", orderHandler.CancelOrder)
+		}
+
+		// Return routes
+		returnRepo := returns.NewRepository(mongodb.Database)
+		returnService := returns.NewService(returnRepo, paymentService, cfg.ReturnWindow)
+		returnHandler := returns.NewHandler(returnService)
+
+		returnGroup := protected.Group("/returns")
+		{
+			returnGroup.POST("", returnHandler.CreateReturn)
+			returnGroup.GET("/me", returnHandler.GetMyReturns)
 		}
 
 		// Review routes
@@ -151,11 +225,12 @@ func main() {
 	})
 
 	// Payment methods (public)
-	paymentRepo := payments.NewRepository(mongodb.Database)
-	paymentService := payments.NewService(paymentRepo)
-	paymentHandler := payments.NewHandler(paymentService)
-
 	api.GET("/payment-methods", paymentHandler.GetPaymentMethods)
+
+	// Gateway notifications (public, verified by signature). VNPay sends its
+	// IPN as a GET request.
+	api.POST("/payments/callback/:provider", paymentHandler.HandleCallback)
+	api.GET("/payments/callback/:provider", paymentHandler.HandleCallback)
 	api.GET("/shipping-methods", func(c *gin.Context) {
 		shippingRepo := shipping.NewRepository(mongodb.Database)
 		shippingService := shipping.NewService(shippingRepo)
@@ -163,8 +238,9 @@ func main() {
 		shippingHandler.GetShippingMethods(c)
 	})
 
-	protected.POST("/payments", paymentHandler.CreatePayment)
+	protected.POST("/payments", idempotency, paymentHandler.CreatePayment)
 	protected.GET("/payments/:orderId", paymentHandler.GetPaymentByOrderID)
+	protected.GET("/payments/:orderId/qr", paymentHandler.GetPaymentQR)
 
 	// Admin routes (require authentication + admin role)
 	admin := api.Group("/admin")
@@ -232,13 +308,55 @@ func main() {
 		admin.DELETE("/reviews/:id", reviewHandler.DeleteReview)
 
 		// Order management
-		orderRepo := orders.NewRepository(mongodb.Database)
-		orderService := orders.NewService(orderRepo)
 		orderHandler := orders.NewHandler(orderService)
 
 		admin.GET("/orders", orderHandler.GetAllOrders)
 		admin.PUT("/orders/:id/status", orderHandler.UpdateOrderStatus)
 
+		// Voucher management
+		voucherRepo := vouchers.NewRepository(mongodb.Database)
+		voucherService := vouchers.NewService(voucherRepo)
+		voucherHandler := vouchers.NewHandler(voucherService)
+
+		adminVouchers := admin.Group("/vouchers")
+		{
+			adminVouchers.GET("", voucherHandler.GetVouchers)
+			adminVouchers.GET("/:id", voucherHandler.GetVoucherByID)
+			adminVouchers.POST("", voucherHandler.CreateVoucher)
+			adminVouchers.PUT("/:id", voucherHandler.UpdateVoucher)
+			adminVouchers.DELETE("/:id", voucherHandler.DeactivateVoucher)
+			adminVouchers.GET("/:id/redemptions", voucherHandler.GetRedemptions)
+			adminVouchers.POST("/:id/codes", voucherHandler.GenerateCodes)
+			adminVouchers.GET("/:id/codes", voucherHandler.GetCodes)
+			adminVouchers.GET("/:id/codes/export", voucherHandler.ExportCodes)
+		}
+
+		// Promotion management
+		promotionRepo := promotions.NewRepository(mongodb.Database)
+		promotionService := promotions.NewService(promotionRepo)
+		promotionHandler := promotions.NewHandler(promotionService)
+
+		adminPromotions := admin.Group("/promotions")
+		{
+			adminPromotions.GET("", promotionHandler.GetPromotions)
+			adminPromotions.GET("/:id", promotionHandler.GetPromotionByID)
+			adminPromotions.POST("", promotionHandler.CreatePromotion)
+			adminPromotions.PUT("/:id", promotionHandler.UpdatePromotion)
+			adminPromotions.DELETE("/:id", promotionHandler.DeactivatePromotion)
+		}
+
+		// Bank transfer reconciliation
+		admin.POST("/payments/reconcile", paymentHandler.ReconcileStatement)
+
+		// Refund management
+		adminRefunds := admin.Group("/refunds")
+		{
+			adminRefunds.POST("", idempotency, paymentHandler.IssueRefund)
+			adminRefunds.GET("", paymentHandler.GetRefunds)
+			adminRefunds.PUT("/:id/status", paymentHandler.UpdateRefundStatus)
+			adminRefunds.POST("/:id/process", idempotency, paymentHandler.ProcessRefund)
+		}
+
 		// Shipment management
 		shippingRepo := shipping.NewRepository(mongodb.Database)
 		shippingService := shipping.NewService(shippingRepo)
@@ -247,6 +365,29 @@ func main() {
 		admin.POST("/shipments", shippingHandler.CreateShipment)
 	}
 
+	// Staff routes (require authentication + staff or admin role)
+	staffReturns := api.Group("/admin/returns")
+	staffReturns.Use(middlewares.AuthMiddleware(cfg))
+	staffReturns.Use(middlewares.StaffOrAdmin())
+	{
+		returnRepo := returns.NewRepository(mongodb.Database)
+		returnService := returns.NewService(returnRepo, paymentService, cfg.ReturnWindow)
+		returnHandler := returns.NewHandler(returnService)
+
+		staffReturns.GET("", returnHandler.GetReturns)
+		staffReturns.POST("/:id/approve", returnHandler.ApproveReturn)
+		staffReturns.POST("/:id/reject", returnHandler.RejectReturn)
+		staffReturns.POST("/:id/receive", returnHandler.ReceiveReturn)
+	}
+
+	// Background jobs
+	var backgroundJobs []jobs.Job
+	if cfg.UnpaidOrderTTL > 0 {
+		backgroundJobs = append(backgroundJobs, jobs.CancelUnpaidOrders(orderService, cfg.UnpaidOrderTTL))
+	}
+	jobRunner := jobs.NewRunner(mongodb.Database, backgroundJobs...)
+	jobRunner.Start()
+
 	// Start server with graceful shutdown
 	srv := &http.Server{
 		Addr:    ":" + cfg.Port,
@@ -268,6 +409,8 @@ func main() {
 
 	log.Println("🛑 Shutting down server...")
 
+	jobRunner.Stop()
+
 	// Graceful shutdown with 5 second timeout
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
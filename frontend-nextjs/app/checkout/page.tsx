@@ -6,6 +6,7 @@ import Image from 'next/image'
 import { useRouter } from 'next/navigation'
 import { cartService } from '@/services/cartService'
 import { orderService } from '@/services/orderService'
+import { ShippingMethod } from '@/types'
 import toast from 'react-hot-toast'
 
 interface CartItem {
@@ -35,11 +36,26 @@ export default function CheckoutPage() {
   const [paymentMethod, setPaymentMethod] = useState('COD')
   const [voucherCode, setVoucherCode] = useState('')
   const [discount, setDiscount] = useState(0)
+  const [shippingMethods, setShippingMethods] = useState<ShippingMethod[]>([])
+  const [shippingMethodId, setShippingMethodId] = useState('')
 
   useEffect(() => {
     fetchCart()
+    fetchShippingMethods()
   }, [])
 
+  const fetchShippingMethods = async () => {
+    try {
+      const methods = await orderService.getShippingMethods()
+      setShippingMethods(methods)
+      if (methods.length > 0) {
+        setShippingMethodId(methods[0].id)
+      }
+    } catch {
+      toast.error('Không thể tải phương thức vận chuyển')
+    }
+  }
+
   const fetchCart = async () => {
     try {
       const data = await cartService.getCart()
@@ -79,6 +95,11 @@ export default function CheckoutPage() {
       return
     }
 
+    if (!shippingMethodId) {
+      toast.error('Vui lòng chọn phương thức vận chuyển')
+      return
+    }
+
     setLoading(true)
     try {
       await orderService.createOrder({
@@ -90,6 +111,8 @@ export default function CheckoutPage() {
           district,
           ward
         },
+        shippingMethodId,
+        paymentMethodCode: paymentMethod,
         voucherCode: voucherCode || undefined
       })
       
@@ -103,7 +126,7 @@ export default function CheckoutPage() {
   }
 
   const subtotal = items.reduce((sum, item) => sum + item.price * item.quantity, 0)
-  const shippingFee = 0 // Miễn phí
+  const shippingFee = shippingMethods.find((method) => method.id === shippingMethodId)?.cost || 0
   const total = subtotal + shippingFee - discount
 
   const formatPrice = (price: number) => {
@@ -218,6 +241,21 @@ export default function CheckoutPage() {
                       className="w-full border border-gray-300 rounded px-4 py-2 focus:outline-none focus:border-blue-500"
                     />
                   </div>
+
+                  <div className="md:col-span-2">
+                    <label className="block text-sm font-medium mb-2">Phương thức vận chuyển</label>
+                    <select
+                      value={shippingMethodId}
+                      onChange={(e) => setShippingMethodId(e.target.value)}
+                      className="w-full border border-gray-300 rounded px-4 py-2 focus:outline-none focus:border-blue-500"
+                    >
+                      {shippingMethods.map((method) => (
+                        <option key={method.id} value={method.id}>
+                          {method.name} - {formatPrice(method.cost)} ({method.estDays} ngày)
+                        </option>
+                      ))}
+                    </select>
+                  </div>
                 </div>
               </div>
 
@@ -332,7 +370,11 @@ export default function CheckoutPage() {
                   </div>
                   <div className="flex justify-between">
                     <span className="text-gray-600">Phí vận chuyển</span>
-                    <span className="text-green-600 font-semibold">Miễn phí</span>
+                    {shippingFee > 0 ? (
+                      <span className="font-semibold">{formatPrice(shippingFee)}</span>
+                    ) : (
+                      <span className="text-green-600 font-semibold">Miễn phí</span>
+                    )}
                   </div>
                   {discount > 0 && (
                     <div className="flex justify-between text-green-600">
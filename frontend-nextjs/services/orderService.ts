@@ -1,8 +1,10 @@
 import api from '@/lib/api'
-import { Order, ShippingAddress } from '@/types'
+import { Order, ShippingAddress, ShippingMethod } from '@/types'
 
 export interface CreateOrderData {
   shippingAddress: ShippingAddress
+  shippingMethodId: string
+  paymentMethodCode: string
   voucherCode?: string
 }
 
@@ -21,4 +23,9 @@ export const orderService = {
     const response = await api.get<Order>(`/orders/${id}`)
     return response.data
   },
+
+  getShippingMethods: async (): Promise<ShippingMethod[]> => {
+    const response = await api.get<{ data: ShippingMethod[] }>('/shipping-methods')
+    return response.data.data || []
+  },
 }
@@ -85,6 +85,14 @@ export interface ShippingAddress {
   ward: string
 }
 
+export interface ShippingMethod {
+  id: string
+  name: string
+  description: string
+  cost: number
+  estDays: number
+}
+
 export interface OrderItem {
   productId: string
   variantId: string
@@ -3,6 +3,7 @@ package config
 import (
 	"log"
 	"os"
+	"strconv"
 	"time"
 
 	"github.com/joho/godotenv"
@@ -15,6 +16,36 @@ type Config struct {
 	JWTSecret    string
 	JWTExpiration time.Duration
 	CORSOrigin   string
+
+	// ReturnWindow is how long after delivery a customer may open a return
+	ReturnWindow time.Duration
+
+	// IdempotencyTTL is how long a response is kept for replay under its
+	// Idempotency-Key
+	IdempotencyTTL time.Duration
+
+	// UnpaidOrderTTL is how long an order paid through MoMo or VNPay may stay
+	// unpaid before it is canceled. Zero disables the cancellation.
+	UnpaidOrderTTL time.Duration
+
+	// Payment gateways. A gateway is only enabled when its credentials are set.
+	VNPayTmnCode    string
+	VNPayHashSecret string
+	VNPayPayURL     string
+	VNPayAPIURL     string
+	VNPayReturnURL  string
+
+	MoMoPartnerCode string
+	MoMoAccessKey   string
+	MoMoSecretKey   string
+	MoMoEndpoint    string
+	MoMoRedirectURL string
+	MoMoIPNURL      string
+
+	// Bank transfers via VietQR. Enabled when the account number is set.
+	VietQRBankBIN     string
+	VietQRAccountNo   string
+	VietQRAccountName string
 }
 
 func Load() *Config {
@@ -30,6 +61,24 @@ func Load() *Config {
 		duration = 24 * time.Hour
 	}
 
+	// Parse return window (in days)
+	returnWindowDays, err := strconv.Atoi(getEnv("RETURN_WINDOW_DAYS", "7"))
+	if err != nil || returnWindowDays < 0 {
+		returnWindowDays = 7
+	}
+
+	// Parse how long idempotency keys are kept
+	idempotencyTTL, err := time.ParseDuration(getEnv("IDEMPOTENCY_TTL", "24h"))
+	if err != nil || idempotencyTTL <= 0 {
+		idempotencyTTL = 24 * time.Hour
+	}
+
+	// Parse how long online orders may stay unpaid
+	unpaidOrderTTL, err := time.ParseDuration(getEnv("UNPAID_ORDER_TTL", "1h"))
+	if err != nil || unpaidOrderTTL < 0 {
+		unpaidOrderTTL = time.Hour
+	}
+
 	return &Config{
 		Port:         getEnv("PORT", "8080"),
 		MongoURI:     getEnv("MONGO_URI", "mongodb://localhost:27017"),
@@ -37,6 +86,26 @@ func Load() *Config {
 		JWTSecret:    getEnv("JWT_SECRET", "default-secret-change-in-production"),
 		JWTExpiration: duration,
 		CORSOrigin:   getEnv("CORS_ORIGIN", "http://localhost:3000"),
+		ReturnWindow: time.Duration(returnWindowDays) * 24 * time.Hour,
+		IdempotencyTTL: idempotencyTTL,
+		UnpaidOrderTTL: unpaidOrderTTL,
+
+		VNPayTmnCode:    getEnv("VNPAY_TMN_CODE", ""),
+		VNPayHashSecret: getEnv("VNPAY_HASH_SECRET", ""),
+		VNPayPayURL:     getEnv("VNPAY_PAY_URL", "https://sandbox.vnpayment.vn/paymentv2/vpcpay.html"),
+		VNPayAPIURL:     getEnv("VNPAY_API_URL", "https://sandbox.vnpayment.vn/merchant_webapi/api/transaction"),
+		VNPayReturnURL:  getEnv("VNPAY_RETURN_URL", "http://localhost:3000/payment/result"),
+
+		MoMoPartnerCode: getEnv("MOMO_PARTNER_CODE", ""),
+		MoMoAccessKey:   getEnv("MOMO_ACCESS_KEY", ""),
+		MoMoSecretKey:   getEnv("MOMO_SECRET_KEY", ""),
+		MoMoEndpoint:    getEnv("MOMO_ENDPOINT", "https://test-payment.momo.vn"),
+		MoMoRedirectURL: getEnv("MOMO_REDIRECT_URL", "http://localhost:3000/payment/result"),
+		MoMoIPNURL:      getEnv("MOMO_IPN_URL", "http://localhost:8080/api/payments/callback/MOMO"),
+
+		VietQRBankBIN:     getEnv("VIETQR_BANK_BIN", ""),
+		VietQRAccountNo:   getEnv("VIETQR_ACCOUNT_NO", ""),
+		VietQRAccountName: getEnv("VIETQR_ACCOUNT_NAME", ""),
 	}
 }
 
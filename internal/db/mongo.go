@@ -2,6 +2,7 @@ package db
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"time"
 
@@ -36,6 +37,13 @@ func Connect(uri, dbName string) (*MongoDB, error) {
 		Database: client.Database(dbName),
 	}
 
+	// Exactly-once payment callbacks and idempotent requests rely on these
+	// unique indexes, so the server does not start without them
+	if err := db.CreateCriticalIndexes(); err != nil {
+		client.Disconnect(context.Background())
+		return nil, fmt.Errorf("create critical indexes: %w", err)
+	}
+
 	// Create indexes
 	if err := db.CreateIndexes(); err != nil {
 		log.Printf("⚠️  Warning: Failed to create indexes: %v", err)
@@ -44,6 +52,50 @@ func Connect(uri, dbName string) (*MongoDB, error) {
 	return db, nil
 }
 
+// CreateCriticalIndexes creates the unique indexes that guard against
+// applying something twice. They are created before any other index, so a
+// failure elsewhere (e.g. duplicate slugs) cannot leave them missing.
+func (db *MongoDB) CreateCriticalIndexes() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// Payment transactions: each gateway transaction is applied once
+	_, err := db.Database.Collection("payment_transactions").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "provider", Value: 1}, {Key: "transactionId", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return err
+	}
+
+	// Idempotency keys: one per user and key
+	_, err = db.Database.Collection("idempotency_keys").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "userId", Value: 1}, {Key: "key", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return err
+	}
+
+	// Vouchers: the code check on create is a read, so two admins creating
+	// the same code at once are told apart by this index
+	_, err = db.Database.Collection("vouchers").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "code", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return err
+	}
+
+	// Generated voucher codes: code generation relies on this index to
+	// reject collisions, so a code is never handed out twice
+	_, err = db.Database.Collection("voucher_codes").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "code", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	return err
+}
+
 func (db *MongoDB) CreateIndexes() error {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
@@ -66,6 +118,45 @@ func (db *MongoDB) CreateIndexes() error {
 		return err
 	}
 
+	// Product listings filter and sort on the lowest variant price
+	_, err = db.Database.Collection("products").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "isActive", Value: 1}, {Key: "minPrice", Value: 1}},
+	})
+	if err != nil {
+		return err
+	}
+
+	// Product search: folded text weighted by where a word appears, with no
+	// stemming since the catalog is Vietnamese and English
+	_, err = db.Database.Collection("products").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "search.name", Value: "text"},
+			{Key: "search.brand", Value: "text"},
+			{Key: "search.attributes", Value: "text"},
+			{Key: "search.description", Value: "text"},
+		},
+		Options: options.Index().
+			SetName("product_search").
+			SetDefaultLanguage("none").
+			SetWeights(bson.D{
+				{Key: "search.name", Value: 10},
+				{Key: "search.brand", Value: 5},
+				{Key: "search.attributes", Value: 3},
+				{Key: "search.description", Value: 1},
+			}),
+	})
+	if err != nil {
+		return err
+	}
+
+	// Search words, the vocabulary typos are corrected against
+	_, err = db.Database.Collection("products").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "search.terms", Value: 1}},
+	})
+	if err != nil {
+		return err
+	}
+
 	// Brands indexes
 	_, err = db.Database.Collection("brands").Indexes().CreateOne(ctx, mongo.IndexModel{
 		Keys:    bson.D{{Key: "slug", Value: 1}},
@@ -116,6 +207,85 @@ func (db *MongoDB) CreateIndexes() error {
 		return err
 	}
 
+	// Order items are loaded by order, including many orders at once
+	_, err = db.Database.Collection("order_items").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "orderId", Value: 1}},
+	})
+	if err != nil {
+		return err
+	}
+
+	// Voucher redemptions indexes
+	_, err = db.Database.Collection("voucher_redemptions").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "voucherId", Value: 1}, {Key: "userId", Value: 1}, {Key: "status", Value: 1}},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Database.Collection("voucher_redemptions").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "orderId", Value: 1}},
+	})
+	if err != nil {
+		return err
+	}
+
+	// Generated voucher codes indexes
+	_, err = db.Database.Collection("voucher_codes").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "voucherId", Value: 1}, {Key: "status", Value: 1}},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Database.Collection("voucher_codes").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "orderId", Value: 1}},
+		Options: options.Index().SetSparse(true),
+	})
+	if err != nil {
+		return err
+	}
+
+	// Promotions indexes: checkout loads the running ones on every quote
+	_, err = db.Database.Collection("promotions").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "isActive", Value: 1}, {Key: "endsAt", Value: 1}},
+	})
+	if err != nil {
+		return err
+	}
+
+	// Return requests indexes
+	_, err = db.Database.Collection("return_requests").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "orderItemId", Value: 1}},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Database.Collection("return_requests").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "userId", Value: 1}, {Key: "createdAt", Value: -1}},
+	})
+	if err != nil {
+		return err
+	}
+
+	// Unpaid orders are looked up by status and age
+	_, err = db.Database.Collection("orders").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "status", Value: 1}, {Key: "createdAt", Value: 1}},
+	})
+	if err != nil {
+		return err
+	}
+
+	// Idempotency keys are removed once expired
+	_, err = db.Database.Collection("idempotency_keys").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	if err != nil {
+		return err
+	}
+
 	log.Println("✅ Created database indexes")
 	return nil
 }
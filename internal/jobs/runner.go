@@ -0,0 +1,155 @@
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Job is a task run periodically in the background
+type Job struct {
+	Name     string
+	Interval time.Duration
+	// Lease is how long one run may take. The job lock is held for that
+	// long, and the run is canceled when it is over.
+	Lease time.Duration
+	Run   func(ctx context.Context) error
+}
+
+// Runner runs jobs on their interval. Every run first takes the job's lock
+// in the job_locks collection, so when several server instances share a
+// database each run happens on only one of them.
+type Runner struct {
+	locks  *mongo.Collection
+	owner  string
+	jobs   []Job
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewRunner(db *mongo.Database, jobs ...Job) *Runner {
+	return &Runner{
+		locks: db.Collection("job_locks"),
+		owner: instanceID(),
+		jobs:  jobs,
+	}
+}
+
+// Start runs every job in its own goroutine until Stop is called
+func (r *Runner) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	r.cancel = cancel
+
+	for _, job := range r.jobs {
+		r.wg.Add(1)
+		go func(job Job) {
+			defer r.wg.Done()
+			r.loop(ctx, job)
+		}(job)
+	}
+}
+
+// Stop cancels running jobs and waits for them to return
+func (r *Runner) Stop() {
+	if r.cancel == nil {
+		return
+	}
+	r.cancel()
+	r.wg.Wait()
+}
+
+func (r *Runner) loop(ctx context.Context, job Job) {
+	ticker := time.NewTicker(job.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.runOnce(ctx, job)
+		}
+	}
+}
+
+// runOnce runs job if no other instance holds its lock
+func (r *Runner) runOnce(ctx context.Context, job Job) {
+	locked, err := r.acquire(ctx, job)
+	if err != nil {
+		log.Printf("Job %s: failed to take lock: %v", job.Name, err)
+		return
+	}
+	if !locked {
+		return
+	}
+	defer r.release(job)
+
+	runCtx, cancel := context.WithTimeout(ctx, job.Lease)
+	defer cancel()
+
+	if err := job.Run(runCtx); err != nil {
+		log.Printf("Job %s failed: %v", job.Name, err)
+	}
+}
+
+// acquire takes the job's lock for one lease. It returns false while
+// another instance holds an unexpired lease.
+func (r *Runner) acquire(ctx context.Context, job Job) (bool, error) {
+	now := time.Now()
+	_, err := r.locks.UpdateOne(
+		ctx,
+		bson.M{
+			"_id": job.Name,
+			"$or": bson.A{
+				bson.M{"lockedUntil": bson.M{"$lte": now}},
+				bson.M{"owner": r.owner},
+			},
+		},
+		bson.M{"$set": bson.M{
+			"owner":       r.owner,
+			"lockedUntil": now.Add(job.Lease),
+			"updatedAt":   now,
+		}},
+		options.Update().SetUpsert(true),
+	)
+	if mongo.IsDuplicateKeyError(err) {
+		// The lock exists and is held by someone else
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// release ends the lease early so the next run does not have to wait for it
+func (r *Runner) release(job Job) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := r.locks.UpdateOne(
+		ctx,
+		bson.M{"_id": job.Name, "owner": r.owner},
+		bson.M{"$set": bson.M{"lockedUntil": time.Now(), "updatedAt": time.Now()}},
+	)
+	if err != nil {
+		log.Printf("Job %s: failed to release lock: %v", job.Name, err)
+	}
+}
+
+// instanceID identifies this server process as a lock owner
+func instanceID() string {
+	host, _ := os.Hostname()
+	suffix := make([]byte, 4)
+	rand.Read(suffix)
+	return fmt.Sprintf("%s-%d-%s", host, os.Getpid(), hex.EncodeToString(suffix))
+}
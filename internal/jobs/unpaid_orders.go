@@ -0,0 +1,31 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"phone-store-backend/internal/modules/orders"
+)
+
+// unpaidOrderMethods are the online gateways whose unpaid orders expire.
+// COD orders are paid on delivery, and bank transfers are settled by
+// statement reconciliation, which can take longer.
+var unpaidOrderMethods = []string{"MOMO", "VNPAY"}
+
+// CancelUnpaidOrders cancels orders paid through an online gateway that are
+// still unpaid ttl after being placed, returning their stock
+func CancelUnpaidOrders(orderService *orders.Service, ttl time.Duration) Job {
+	return Job{
+		Name:     "cancel-unpaid-orders",
+		Interval: time.Minute,
+		Lease:    5 * time.Minute,
+		Run: func(ctx context.Context) error {
+			canceled, err := orderService.CancelUnpaidOrders(ctx, unpaidOrderMethods, ttl)
+			if canceled > 0 {
+				log.Printf("Canceled %d unpaid orders older than %s", canceled, ttl)
+			}
+			return err
+		},
+	}
+}
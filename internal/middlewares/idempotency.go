@@ -0,0 +1,219 @@
+package middlewares
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"phone-store-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// IdempotencyKeyHeader is the request header clients set to make a request
+// safe to retry
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// maxIdempotencyKeyLength bounds the keys clients can make us store
+const maxIdempotencyKeyLength = 255
+
+// idempotencyLease is how long a key stays in progress. A request that dies
+// without releasing its key (the process crashed or was killed) only blocks
+// retries until the lease runs out, not for the whole TTL.
+const idempotencyLease = 2 * time.Minute
+
+// idempotencyRecorder copies the response as it is written so it can be
+// stored for replay
+type idempotencyRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *idempotencyRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *idempotencyRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Idempotency makes a handler safe to retry. The first request with a given
+// Idempotency-Key runs normally and its response is kept for ttl; a retry
+// with the same key and body gets that response back without running the
+// handler again, and the same key with another body is refused with 409.
+// While the first request runs, the key is held for idempotencyLease only.
+// Keys are scoped to the authenticated user, so it must run after
+// AuthMiddleware. Requests without the header are not affected.
+func Idempotency(db *mongo.Database, ttl time.Duration) gin.HandlerFunc {
+	collection := db.Collection("idempotency_keys")
+
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"message": "Idempotency-Key is too long",
+				"code":    "BAD_REQUEST",
+				"details": nil,
+			})
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"message": "Failed to read request body",
+				"code":    "BAD_REQUEST",
+				"details": nil,
+			})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		ctx := c.Request.Context()
+		now := time.Now()
+		record := &models.IdempotencyKey{
+			ID:          primitive.NewObjectID(),
+			Key:         key,
+			UserID:      c.GetString("userID"),
+			Fingerprint: requestFingerprint(c.Request.Method, c.Request.URL.Path, body),
+			Status:      models.IdempotencyStatusInProgress,
+			CreatedAt:   now,
+			ExpiresAt:   now.Add(idempotencyLease),
+		}
+
+		existing, err := claimIdempotencyKey(ctx, collection, record)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+				"message": "Internal server error",
+				"code":    "INTERNAL_ERROR",
+				"details": nil,
+			})
+			return
+		}
+
+		if existing != nil {
+			switch {
+			case existing.Fingerprint != record.Fingerprint:
+				c.AbortWithStatusJSON(http.StatusConflict, gin.H{
+					"message": "Idempotency-Key was already used for a different request",
+					"code":    "IDEMPOTENCY_KEY_REUSED",
+					"details": nil,
+				})
+			case existing.Status != models.IdempotencyStatusCompleted:
+				c.AbortWithStatusJSON(http.StatusConflict, gin.H{
+					"message": "A request with this Idempotency-Key is still being processed",
+					"code":    "IDEMPOTENCY_KEY_IN_PROGRESS",
+					"details": nil,
+				})
+			default:
+				c.Header("Idempotent-Replayed", "true")
+				c.Data(existing.StatusCode, existing.ContentType, existing.ResponseBody)
+				c.Abort()
+			}
+			return
+		}
+
+		recorder := &idempotencyRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+
+		// Only touch our own claim: after the lease a retry may have taken
+		// the key over
+		filter := bson.M{"_id": record.ID}
+		release := func() {
+			if _, err := collection.DeleteOne(context.Background(), filter); err != nil {
+				log.Printf("Failed to release idempotency key %s: %v", key, err)
+			}
+		}
+
+		// A panicking handler never finished the request, so the key is
+		// released for a retry before the panic goes on to the recovery
+		// middleware
+		defer func() {
+			if r := recover(); r != nil {
+				release()
+				panic(r)
+			}
+		}()
+
+		c.Next()
+
+		// Server errors are not kept: the request may not have happened, so
+		// the client is free to retry it with the same key
+		if c.Writer.Status() >= http.StatusInternalServerError {
+			release()
+			return
+		}
+
+		_, err = collection.UpdateOne(context.Background(), filter, bson.M{"$set": bson.M{
+			"status":       models.IdempotencyStatusCompleted,
+			"statusCode":   c.Writer.Status(),
+			"contentType":  c.Writer.Header().Get("Content-Type"),
+			"responseBody": recorder.body.Bytes(),
+			"expiresAt":    time.Now().Add(ttl),
+		}})
+		if err != nil {
+			log.Printf("Failed to store response for idempotency key %s: %v", key, err)
+		}
+	}
+}
+
+// claimIdempotencyKey stores record as in progress. When the key is already
+// taken it returns the stored record instead; a key past its expiry or its
+// lease that the TTL monitor has not removed yet is taken over.
+func claimIdempotencyKey(ctx context.Context, collection *mongo.Collection, record *models.IdempotencyKey) (*models.IdempotencyKey, error) {
+	filter := bson.M{"userId": record.UserID, "key": record.Key}
+
+	for attempt := 0; attempt < 2; attempt++ {
+		_, err := collection.InsertOne(ctx, record)
+		if err == nil {
+			return nil, nil
+		}
+		if !mongo.IsDuplicateKeyError(err) {
+			return nil, err
+		}
+
+		var existing models.IdempotencyKey
+		err = collection.FindOne(ctx, filter).Decode(&existing)
+		if err == mongo.ErrNoDocuments {
+			continue // Removed in the meantime
+		} else if err != nil {
+			return nil, err
+		}
+		if existing.ExpiresAt.After(record.CreatedAt) {
+			return &existing, nil
+		}
+
+		if _, err := collection.DeleteOne(ctx, bson.M{"_id": existing.ID}); err != nil {
+			return nil, err
+		}
+	}
+
+	var existing models.IdempotencyKey
+	if err := collection.FindOne(ctx, filter).Decode(&existing); err != nil {
+		return nil, err
+	}
+	return &existing, nil
+}
+
+// requestFingerprint identifies what a request asks for, so a key reused for
+// another request can be told apart from a retry
+func requestFingerprint(method, path string, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(method + " " + path + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
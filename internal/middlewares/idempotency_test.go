@@ -0,0 +1,109 @@
+package middlewares
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"phone-store-backend/internal/models"
+	"phone-store-backend/internal/testdb"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const idempotencyTestUser = "user-1"
+
+// idempotentRouter serves handler behind the idempotency middleware as
+// idempotencyTestUser
+func idempotentRouter(database *mongo.Database, handler gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(gin.CustomRecovery(func(c *gin.Context, _ interface{}) {
+		c.AbortWithStatus(http.StatusInternalServerError)
+	}))
+	router.Use(func(c *gin.Context) { c.Set("userID", idempotencyTestUser) })
+	router.POST("/api/orders", Idempotency(database, time.Hour), handler)
+	return router
+}
+
+func sendWithKey(router *gin.Engine, key string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("POST", "/api/orders", strings.NewReader(`{"note":"x"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(IdempotencyKeyHeader, key)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestIdempotencyReleasesKeyWhenHandlerPanics(t *testing.T) {
+	database := testdb.Open(t)
+	calls := 0
+	router := idempotentRouter(database, func(c *gin.Context) {
+		calls++
+		if calls == 1 {
+			panic("lost the connection")
+		}
+		c.JSON(http.StatusCreated, gin.H{"call": calls})
+	})
+
+	if w := sendWithKey(router, "checkout-1"); w.Code != http.StatusInternalServerError {
+		t.Fatalf("panicking request got %d, want %d", w.Code, http.StatusInternalServerError)
+	}
+	if w := sendWithKey(router, "checkout-1"); w.Code != http.StatusCreated {
+		t.Fatalf("retry got %d, want %d: %s", w.Code, http.StatusCreated, w.Body)
+	}
+	if calls != 2 {
+		t.Errorf("handler ran %d times, want 2", calls)
+	}
+}
+
+func TestIdempotencyTakesOverKeyPastItsLease(t *testing.T) {
+	database := testdb.Open(t)
+	calls := 0
+	router := idempotentRouter(database, func(c *gin.Context) {
+		calls++
+		c.JSON(http.StatusCreated, gin.H{"call": calls})
+	})
+
+	// Left behind by a request whose process died mid-way
+	fingerprint := requestFingerprint("POST", "/api/orders", []byte(`{"note":"x"}`))
+	for key, claimedAt := range map[string]time.Time{
+		"crashed": time.Now().Add(-idempotencyLease - time.Second),
+		"running": time.Now(),
+	} {
+		_, err := database.Collection("idempotency_keys").InsertOne(context.Background(), &models.IdempotencyKey{
+			ID:          primitive.NewObjectID(),
+			Key:         key,
+			UserID:      idempotencyTestUser,
+			Fingerprint: fingerprint,
+			Status:      models.IdempotencyStatusInProgress,
+			CreatedAt:   claimedAt,
+			ExpiresAt:   claimedAt.Add(idempotencyLease),
+		})
+		if err != nil {
+			t.Fatalf("seed key: %v", err)
+		}
+	}
+
+	if w := sendWithKey(router, "running"); w.Code != http.StatusConflict {
+		t.Errorf("key within its lease got %d, want %d", w.Code, http.StatusConflict)
+	}
+	if w := sendWithKey(router, "crashed"); w.Code != http.StatusCreated {
+		t.Errorf("key past its lease got %d, want %d: %s", w.Code, http.StatusCreated, w.Body)
+	}
+
+	// The completed response is kept for the full TTL, not the lease
+	var stored models.IdempotencyKey
+	if err := database.Collection("idempotency_keys").FindOne(context.Background(), bson.M{"userId": idempotencyTestUser, "key": "crashed"}).Decode(&stored); err != nil {
+		t.Fatalf("load key: %v", err)
+	}
+	if stored.Status != models.IdempotencyStatusCompleted || time.Until(stored.ExpiresAt) < 30*time.Minute {
+		t.Errorf("stored key is %s until %s, want %s for the TTL", stored.Status, stored.ExpiresAt, models.IdempotencyStatusCompleted)
+	}
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type IdempotencyStatus string
+
+const (
+	IdempotencyStatusInProgress IdempotencyStatus = "IN_PROGRESS"
+	IdempotencyStatusCompleted  IdempotencyStatus = "COMPLETED"
+)
+
+// IdempotencyKey remembers the response to a request sent with an
+// Idempotency-Key header, so a retry gets the same response instead of
+// running the request again. Expired keys are removed by a TTL index.
+type IdempotencyKey struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Key          string             `bson:"key" json:"key"`
+	UserID       string             `bson:"userId" json:"userId"`
+	Fingerprint  string             `bson:"fingerprint" json:"fingerprint"` // Hash of method, path and body
+	Status       IdempotencyStatus  `bson:"status" json:"status"`
+	StatusCode   int                `bson:"statusCode,omitempty" json:"statusCode,omitempty"`
+	ContentType  string             `bson:"contentType,omitempty" json:"contentType,omitempty"`
+	ResponseBody []byte             `bson:"responseBody,omitempty" json:"-"`
+	CreatedAt    time.Time          `bson:"createdAt" json:"createdAt"`
+	ExpiresAt    time.Time          `bson:"expiresAt" json:"expiresAt"`
+}
@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// JobLock is the lease a server instance holds while it runs a background
+// job, so that only one instance runs each job at a time. The ID is the job
+// name.
+type JobLock struct {
+	ID          string    `bson:"_id" json:"id"`
+	Owner       string    `bson:"owner" json:"owner"`
+	LockedUntil time.Time `bson:"lockedUntil" json:"lockedUntil"`
+	UpdatedAt   time.Time `bson:"updatedAt" json:"updatedAt"`
+}
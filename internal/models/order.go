@@ -25,16 +25,33 @@ type OrderShippingAddress struct {
 	Ward     string `bson:"ward" json:"ward"`
 }
 
+// OrderShippingMethod is the shipping method chosen at checkout, as it was
+// at the time
+type OrderShippingMethod struct {
+	ID                primitive.ObjectID `bson:"id" json:"id"`
+	Name              string             `bson:"name" json:"name"`
+	Cost              float64            `bson:"cost" json:"cost"`
+	EstDays           int                `bson:"estDays" json:"estDays"`
+	EstimatedDelivery time.Time          `bson:"estimatedDelivery" json:"estimatedDelivery"`
+}
+
 type Order struct {
-	ID              primitive.ObjectID   `bson:"_id,omitempty" json:"id"`
-	OrderNumber     string               `bson:"orderNumber" json:"orderNumber"`
-	UserID          primitive.ObjectID   `bson:"userId" json:"userId"`
-	ShippingAddress OrderShippingAddress `bson:"shippingAddress" json:"shippingAddress"`
-	VoucherCode     string               `bson:"voucherCode,omitempty" json:"voucherCode,omitempty"`
-	SubTotal        float64              `bson:"subTotal" json:"subTotal"`
-	Discount        float64              `bson:"discount" json:"discount"`
-	Total           float64              `bson:"total" json:"total"`
-	Status          OrderStatus          `bson:"status" json:"status"`
-	CreatedAt       time.Time            `bson:"createdAt" json:"createdAt"`
-	UpdatedAt       time.Time            `bson:"updatedAt" json:"updatedAt"`
+	ID               primitive.ObjectID   `bson:"_id,omitempty" json:"id"`
+	OrderNumber      string               `bson:"orderNumber" json:"orderNumber"`
+	UserID           primitive.ObjectID   `bson:"userId" json:"userId"`
+	ShippingAddress  OrderShippingAddress `bson:"shippingAddress" json:"shippingAddress"`
+	ShippingMethod   *OrderShippingMethod `bson:"shippingMethod,omitempty" json:"shippingMethod,omitempty"`
+	PaymentMethod    string               `bson:"paymentMethod,omitempty" json:"paymentMethod,omitempty"` // Payment method code chosen at checkout
+	VoucherCode      string               `bson:"voucherCode,omitempty" json:"voucherCode,omitempty"`
+	Promotions       []AppliedPromotion   `bson:"appliedPromotions,omitempty" json:"appliedPromotions,omitempty"`
+	SubTotal         float64              `bson:"subTotal" json:"subTotal"`
+	ShippingFee      float64              `bson:"shippingFee" json:"shippingFee"`
+	Discount         float64              `bson:"discount" json:"discount"`                 // Item and shipping discounts
+	ShippingDiscount float64              `bson:"shippingDiscount" json:"shippingDiscount"` // Part of Discount taken off shipping
+	Total            float64              `bson:"total" json:"total"`
+	RefundedTotal    float64              `bson:"refundedTotal" json:"refundedTotal"` // Completed refunds
+	Status           OrderStatus          `bson:"status" json:"status"`
+	DeliveredAt      *time.Time           `bson:"deliveredAt,omitempty" json:"deliveredAt,omitempty"` // Set when the order is completed
+	CreatedAt        time.Time            `bson:"createdAt" json:"createdAt"`
+	UpdatedAt        time.Time            `bson:"updatedAt" json:"updatedAt"`
 }
@@ -17,6 +17,8 @@ type OrderItem struct {
 	Storage   string             `bson:"storage" json:"storage"`
 	Price     float64            `bson:"price" json:"price"` // Snapshot price at order time
 	Quantity  int                `bson:"quantity" json:"quantity"`
+	Discount  float64            `bson:"discount" json:"discount"`                 // Share of the order discount for the whole line
+	Returned  int                `bson:"returnedQuantity" json:"returnedQuantity"` // Units covered by open or accepted returns
 	CreatedAt time.Time          `bson:"createdAt" json:"createdAt"`
 	UpdatedAt time.Time          `bson:"updatedAt" json:"updatedAt"`
 }
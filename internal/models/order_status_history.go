@@ -7,10 +7,12 @@ import (
 )
 
 type OrderStatusHistory struct {
-	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	OrderID   primitive.ObjectID `bson:"orderId" json:"orderId"`
-	Status    string             `bson:"status" json:"status"`
-	Note      string             `bson:"note" json:"note"`
-	UpdatedBy primitive.ObjectID `bson:"updatedBy" json:"updatedBy"` // User ID who made the change
-	CreatedAt time.Time          `bson:"createdAt" json:"createdAt"`
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	OrderID    primitive.ObjectID `bson:"orderId" json:"orderId"`
+	FromStatus string             `bson:"fromStatus,omitempty" json:"fromStatus,omitempty"`
+	Status     string             `bson:"status" json:"status"`
+	Note       string             `bson:"note" json:"note"`
+	Actor      string             `bson:"actor,omitempty" json:"actor,omitempty"` // CUSTOMER, STAFF, SYSTEM
+	UpdatedBy  primitive.ObjectID `bson:"updatedBy" json:"updatedBy"`             // User ID who made the change, zero for SYSTEM
+	CreatedAt  time.Time          `bson:"createdAt" json:"createdAt"`
 }
@@ -12,6 +12,7 @@ const (
 	PaymentStatusPending   PaymentStatus = "PENDING"
 	PaymentStatusCompleted PaymentStatus = "COMPLETED"
 	PaymentStatusFailed    PaymentStatus = "FAILED"
+	PaymentStatusVoided    PaymentStatus = "VOIDED" // Order canceled before the payment was completed
 )
 
 type Payment struct {
@@ -19,6 +20,7 @@ type Payment struct {
 	OrderID       primitive.ObjectID `bson:"orderId" json:"orderId"`
 	Method        string             `bson:"method" json:"method"` // COD, CARD, MOMO, etc.
 	Amount        float64            `bson:"amount" json:"amount"`
+	Refunded      float64            `bson:"refunded" json:"refunded"` // Pending + completed refunds
 	Status        PaymentStatus      `bson:"status" json:"status"`
 	TransactionID string             `bson:"transactionId,omitempty" json:"transactionId,omitempty"`
 	PaidAt        *time.Time         `bson:"paidAt,omitempty" json:"paidAt,omitempty"`
@@ -6,12 +6,19 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// PaymentMethodCOD is the only method paid after the order ships; every
+// other method is paid online before it
+const PaymentMethodCOD = "COD"
+
 type PaymentMethod struct {
 	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
 	Name        string             `bson:"name" json:"name"`
 	Code        string             `bson:"code" json:"code"` // COD, BANK_TRANSFER, MOMO, VNPAY
 	Description string             `bson:"description" json:"description"`
 	IsActive    bool               `bson:"isActive" json:"isActive"`
-	CreatedAt   time.Time          `bson:"createdAt" json:"createdAt"`
-	UpdatedAt   time.Time          `bson:"updatedAt" json:"updatedAt"`
+	// Checkout rules; zero values mean no restriction
+	MaxOrderValue     float64   `bson:"maxOrderValue,omitempty" json:"maxOrderValue,omitempty"`
+	DisabledProvinces []string  `bson:"disabledProvinces,omitempty" json:"disabledProvinces,omitempty"` // Matched against the shipping address city
+	CreatedAt         time.Time `bson:"createdAt" json:"createdAt"`
+	UpdatedAt         time.Time `bson:"updatedAt" json:"updatedAt"`
 }
@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// PaymentTransaction is the ledger of gateway notifications. The unique
+// (provider, transactionId) index makes each gateway transaction apply once.
+type PaymentTransaction struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Provider      string             `bson:"provider" json:"provider"`
+	TransactionID string             `bson:"transactionId" json:"transactionId"`
+	PaymentID     primitive.ObjectID `bson:"paymentId" json:"paymentId"`
+	OrderID       primitive.ObjectID `bson:"orderId" json:"orderId"`
+	Amount        float64            `bson:"amount" json:"amount"`
+	Success       bool               `bson:"success" json:"success"`
+	Message       string             `bson:"message,omitempty" json:"message,omitempty"`
+	CreatedAt     time.Time          `bson:"createdAt" json:"createdAt"`
+}
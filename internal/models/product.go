@@ -14,8 +14,25 @@ type Product struct {
 	BrandID     primitive.ObjectID `bson:"brandId" json:"brandId"`
 	CategoryID  primitive.ObjectID `bson:"categoryId" json:"categoryId"`
 	Images      []string           `bson:"images" json:"images"`
+	MinPrice    float64            `bson:"minPrice" json:"minPrice"` // Lowest active variant price, kept in sync by the products service
+	MaxPrice    float64            `bson:"maxPrice" json:"maxPrice"` // Highest active variant price
+	Colors      []string           `bson:"colors" json:"colors"`     // Distinct colors of active variants
+	Storages    []string           `bson:"storages" json:"storages"` // Distinct storage sizes of active variants
 	IsActive    bool               `bson:"isActive" json:"isActive"`
 	IsFeatured  bool               `bson:"isFeatured" json:"isFeatured"`
+	IsPreOrder  bool               `bson:"isPreOrder" json:"isPreOrder"` // Not released yet; must be paid online
+	Search      *ProductSearch     `bson:"search,omitempty" json:"-"`
 	CreatedAt   time.Time          `bson:"createdAt" json:"createdAt"`
 	UpdatedAt   time.Time          `bson:"updatedAt" json:"updatedAt"`
 }
+
+// ProductSearch is the text a product is found by, without diacritics and
+// lowercased. It is rebuilt whenever the product, its brand or its variants
+// change.
+type ProductSearch struct {
+	Name        string   `bson:"name"`
+	Brand       string   `bson:"brand"`
+	Attributes  string   `bson:"attributes"` // SKUs, colors and storage sizes of active variants
+	Description string   `bson:"description"`
+	Terms       []string `bson:"terms"` // Distinct words of all the above, for typo tolerance
+}
@@ -0,0 +1,54 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Promotion is an automatic discount: it applies to every cart that meets
+// its conditions, no code required
+type Promotion struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Name        string             `bson:"name" json:"name"`
+	Description string             `bson:"description" json:"description"`
+	// Stacking. Promotions run from the highest priority down, each on what
+	// is left to pay after the ones before it. Vouchers run last.
+	Priority              int  `bson:"priority" json:"priority"`
+	Exclusive             bool `bson:"exclusive" json:"exclusive"`                         // Skip lower-priority promotions once applied
+	CombinableWithVoucher bool `bson:"combinableWithVoucher" json:"combinableWithVoucher"` // false: no voucher on the same order
+	// Conditions. Empty scope lists mean every line is eligible; otherwise a
+	// line is eligible when it matches any of them.
+	ProductIDs          []primitive.ObjectID `bson:"productIds,omitempty" json:"productIds,omitempty"`
+	BrandIDs            []primitive.ObjectID `bson:"brandIds,omitempty" json:"brandIds,omitempty"`
+	CategoryIDs         []primitive.ObjectID `bson:"categoryIds,omitempty" json:"categoryIds,omitempty"`
+	RequiredProductIDs  []primitive.ObjectID `bson:"requiredProductIds,omitempty" json:"requiredProductIds,omitempty"`   // Cart must hold each, for bundles
+	RequiredCategoryIDs []primitive.ObjectID `bson:"requiredCategoryIds,omitempty" json:"requiredCategoryIds,omitempty"` // Cart must hold one of each, for bundles
+	MinQuantity         int                  `bson:"minQuantity" json:"minQuantity"`                                     // Eligible units
+	MinSubTotal         float64              `bson:"minSubTotal" json:"minSubTotal"`                                     // Eligible lines before discounts
+	StartsAt            time.Time            `bson:"startsAt" json:"startsAt"`
+	EndsAt              time.Time            `bson:"endsAt" json:"endsAt"`
+	// Reward, taken off the eligible lines
+	DiscountType    DiscountType    `bson:"discountType" json:"discountType"` // PERCENT or FIXED
+	DiscountPercent float64         `bson:"discountPercent" json:"discountPercent"`
+	DiscountAmount  float64         `bson:"discountAmount" json:"discountAmount"`
+	MaxDiscount     float64         `bson:"maxDiscount" json:"maxDiscount"`         // 0 = no cap
+	Tiers           []PromotionTier `bson:"tiers,omitempty" json:"tiers,omitempty"` // Spend tiers; the highest one reached sets the discount
+	IsActive        bool            `bson:"isActive" json:"isActive"`
+	CreatedAt       time.Time       `bson:"createdAt" json:"createdAt"`
+	UpdatedAt       time.Time       `bson:"updatedAt" json:"updatedAt"`
+}
+
+// PromotionTier is one step of a tiered spend discount
+type PromotionTier struct {
+	MinSubTotal     float64 `bson:"minSubTotal" json:"minSubTotal"`
+	DiscountPercent float64 `bson:"discountPercent" json:"discountPercent"`
+	DiscountAmount  float64 `bson:"discountAmount" json:"discountAmount"`
+}
+
+// AppliedPromotion records what a promotion took off an order
+type AppliedPromotion struct {
+	PromotionID primitive.ObjectID `bson:"promotionId" json:"promotionId"`
+	Name        string             `bson:"name" json:"name"`
+	Discount    float64            `bson:"discount" json:"discount"`
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type RefundStatus string
+
+const (
+	RefundStatusPending    RefundStatus = "PENDING"
+	RefundStatusProcessing RefundStatus = "PROCESSING" // Sent to the gateway, waiting for its answer
+	RefundStatusCompleted  RefundStatus = "COMPLETED"
+	RefundStatusFailed     RefundStatus = "FAILED"
+)
+
+type Refund struct {
+	ID              primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	OrderID         primitive.ObjectID  `bson:"orderId" json:"orderId"`
+	PaymentID       primitive.ObjectID  `bson:"paymentId" json:"paymentId"`
+	ReturnRequestID *primitive.ObjectID `bson:"returnRequestId,omitempty" json:"returnRequestId,omitempty"`
+	Amount          float64             `bson:"amount" json:"amount"`
+	Status          RefundStatus        `bson:"status" json:"status"`
+	Reason          string              `bson:"reason" json:"reason"`
+	CreatedBy       primitive.ObjectID  `bson:"createdBy" json:"createdBy"` // Operator who issued the refund
+	CreatedAt       time.Time           `bson:"createdAt" json:"createdAt"`
+	UpdatedAt       time.Time           `bson:"updatedAt" json:"updatedAt"`
+}
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type ReturnStatus string
+
+const (
+	ReturnStatusRequested ReturnStatus = "REQUESTED"
+	ReturnStatusApproved  ReturnStatus = "APPROVED"
+	ReturnStatusRejected  ReturnStatus = "REJECTED"
+	ReturnStatusReceived  ReturnStatus = "RECEIVED"
+)
+
+type ReturnDisposition string
+
+const (
+	ReturnDispositionRestock  ReturnDisposition = "RESTOCK"
+	ReturnDispositionWriteOff ReturnDisposition = "WRITE_OFF"
+)
+
+type ReturnRequest struct {
+	ID           primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	OrderID      primitive.ObjectID  `bson:"orderId" json:"orderId"`
+	OrderItemID  primitive.ObjectID  `bson:"orderItemId" json:"orderItemId"`
+	VariantID    primitive.ObjectID  `bson:"variantId" json:"variantId"`
+	UserID       primitive.ObjectID  `bson:"userId" json:"userId"`
+	Quantity     int                 `bson:"quantity" json:"quantity"`
+	Reason       string              `bson:"reason" json:"reason"`
+	Photos       []string            `bson:"photos" json:"photos"`
+	Status       ReturnStatus        `bson:"status" json:"status"`
+	Disposition  ReturnDisposition   `bson:"disposition,omitempty" json:"disposition,omitempty"`
+	RefundAmount float64             `bson:"refundAmount" json:"refundAmount"`
+	RefundID     *primitive.ObjectID `bson:"refundId,omitempty" json:"refundId,omitempty"`
+	StaffNote    string              `bson:"staffNote,omitempty" json:"staffNote,omitempty"`
+	ReviewedBy   *primitive.ObjectID `bson:"reviewedBy,omitempty" json:"reviewedBy,omitempty"`
+	ReviewedAt   *time.Time          `bson:"reviewedAt,omitempty" json:"reviewedAt,omitempty"`
+	ReceivedAt   *time.Time          `bson:"receivedAt,omitempty" json:"receivedAt,omitempty"`
+	CreatedAt    time.Time           `bson:"createdAt" json:"createdAt"`
+	UpdatedAt    time.Time           `bson:"updatedAt" json:"updatedAt"`
+}
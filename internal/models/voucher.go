@@ -6,15 +6,41 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+type DiscountType string
+
+const (
+	DiscountTypePercent      DiscountType = "PERCENT" // Also used when the type is empty
+	DiscountTypeFixed        DiscountType = "FIXED"
+	DiscountTypeFreeShipping DiscountType = "FREE_SHIPPING"
+)
+
 type Voucher struct {
 	ID              primitive.ObjectID `bson:"_id,omitempty" json:"id"`
 	Code            string             `bson:"code" json:"code"`
 	Description     string             `bson:"description" json:"description"`
-	DiscountPercent float64            `bson:"discountPercent" json:"discountPercent"` // 0-100
-	MaxDiscount     float64            `bson:"maxDiscount" json:"maxDiscount"`
-	MinOrderValue   float64            `bson:"minOrderValue" json:"minOrderValue"`
-	ExpiredAt       time.Time          `bson:"expiredAt" json:"expiredAt"`
-	IsActive        bool               `bson:"isActive" json:"isActive"`
-	CreatedAt       time.Time          `bson:"createdAt" json:"createdAt"`
-	UpdatedAt       time.Time          `bson:"updatedAt" json:"updatedAt"`
+	DiscountType    DiscountType       `bson:"discountType,omitempty" json:"discountType"`
+	DiscountPercent float64            `bson:"discountPercent" json:"discountPercent"` // 0-100, PERCENT only
+	DiscountAmount  float64            `bson:"discountAmount" json:"discountAmount"`   // FIXED only
+	MaxDiscount     float64            `bson:"maxDiscount" json:"maxDiscount"`         // 0 = no cap
+	// Scope. Empty lists everywhere mean the whole order; otherwise a line is
+	// eligible when it matches any of the lists.
+	ProductIDs     []primitive.ObjectID `bson:"productIds,omitempty" json:"productIds,omitempty"`
+	VariantIDs     []primitive.ObjectID `bson:"variantIds,omitempty" json:"variantIds,omitempty"`
+	BrandIDs       []primitive.ObjectID `bson:"brandIds,omitempty" json:"brandIds,omitempty"`
+	CategoryIDs    []primitive.ObjectID `bson:"categoryIds,omitempty" json:"categoryIds,omitempty"`
+	MinOrderValue  float64              `bson:"minOrderValue" json:"minOrderValue"`
+	StartsAt       *time.Time           `bson:"startsAt,omitempty" json:"startsAt,omitempty"`
+	ExpiredAt      time.Time            `bson:"expiredAt" json:"expiredAt"`
+	UsageLimit     int                  `bson:"usageLimit" json:"usageLimit"`     // Total redemptions, 0 = unlimited
+	PerUserLimit   int                  `bson:"perUserLimit" json:"perUserLimit"` // Redemptions per customer, 0 = unlimited
+	FirstOrderOnly bool                 `bson:"firstOrderOnly" json:"firstOrderOnly"`
+	CodesOnly      bool                 `bson:"codesOnly" json:"codesOnly"` // Redeemed only through generated single-use codes
+	UsedCount      int                  `bson:"usedCount" json:"usedCount"` // Active redemptions
+	IsActive       bool                 `bson:"isActive" json:"isActive"`
+	CreatedAt      time.Time            `bson:"createdAt" json:"createdAt"`
+	UpdatedAt      time.Time            `bson:"updatedAt" json:"updatedAt"`
+
+	// Set while codes are being generated, so runs for the same voucher
+	// cannot both fit in what is left of the usage limit
+	GeneratingUntil *time.Time `bson:"generatingUntil,omitempty" json:"-"`
 }
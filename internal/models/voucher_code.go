@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type VoucherCodeStatus string
+
+const (
+	VoucherCodeStatusAvailable VoucherCodeStatus = "AVAILABLE"
+	VoucherCodeStatusRedeemed  VoucherCodeStatus = "REDEEMED"
+)
+
+// VoucherCode is a generated single-use code for a voucher. The voucher is
+// the template: discount, scope, dates and limits all come from it.
+type VoucherCode struct {
+	ID         primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	VoucherID  primitive.ObjectID  `bson:"voucherId" json:"voucherId"`
+	Code       string              `bson:"code" json:"code"`
+	Status     VoucherCodeStatus   `bson:"status" json:"status"`
+	UserID     *primitive.ObjectID `bson:"userId,omitempty" json:"userId,omitempty"`
+	OrderID    *primitive.ObjectID `bson:"orderId,omitempty" json:"orderId,omitempty"`
+	RedeemedAt *time.Time          `bson:"redeemedAt,omitempty" json:"redeemedAt,omitempty"`
+	CreatedAt  time.Time           `bson:"createdAt" json:"createdAt"`
+}
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type RedemptionStatus string
+
+const (
+	RedemptionStatusActive   RedemptionStatus = "ACTIVE"
+	RedemptionStatusReleased RedemptionStatus = "RELEASED" // Order canceled, the use is given back
+)
+
+// VoucherRedemption records one use of a voucher by an order. It is written
+// in the same transaction as the order.
+type VoucherRedemption struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	VoucherID  primitive.ObjectID `bson:"voucherId" json:"voucherId"`
+	Code       string             `bson:"code" json:"code"`
+	UserID     primitive.ObjectID `bson:"userId" json:"userId"`
+	OrderID    primitive.ObjectID `bson:"orderId" json:"orderId"`
+	Discount   float64            `bson:"discount" json:"discount"`
+	Status     RedemptionStatus   `bson:"status" json:"status"`
+	ReleasedAt *time.Time         `bson:"releasedAt,omitempty" json:"releasedAt,omitempty"`
+	CreatedAt  time.Time          `bson:"createdAt" json:"createdAt"`
+}
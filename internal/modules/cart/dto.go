@@ -10,8 +10,19 @@ type UpdateItemRequest struct {
 }
 
 type CartResponse struct {
-	ID    string     `json:"id"`
-	Items []CartItem `json:"items"`
+	ID         string          `json:"id"`
+	Items      []CartItem      `json:"items"`
+	SubTotal   float64         `json:"subTotal"`
+	Discount   float64         `json:"discount"` // Automatic promotions
+	Total      float64         `json:"total"`    // Before shipping and vouchers
+	Promotions []CartPromotion `json:"promotions"`
+}
+
+// CartPromotion is an automatic promotion applied to the cart
+type CartPromotion struct {
+	ID       string  `json:"id"`
+	Name     string  `json:"name"`
+	Discount float64 `json:"discount"`
 }
 
 type CartItem struct {
@@ -26,3 +37,49 @@ type CartItem struct {
 	Quantity    int     `json:"quantity"`
 	Image       string  `json:"image"`
 }
+
+type QuoteRequest struct {
+	ShippingMethodID string `json:"shippingMethodId"`
+	VoucherCode      string `json:"voucherCode"`
+}
+
+type QuoteResponse struct {
+	Items            []QuoteItem          `json:"items"`
+	SubTotal         float64              `json:"subTotal"`
+	ShippingMethod   *QuoteShippingMethod `json:"shippingMethod,omitempty"`
+	ShippingFee      float64              `json:"shippingFee"`
+	Discount         float64              `json:"discount"`
+	ShippingDiscount float64              `json:"shippingDiscount"`
+	Total            float64              `json:"total"`
+	Promotions       []CartPromotion      `json:"promotions"`
+	Voucher          *QuoteVoucher        `json:"voucher,omitempty"`
+}
+
+type QuoteItem struct {
+	VariantID   string  `json:"variantId"`
+	ProductID   string  `json:"productId"`
+	ProductName string  `json:"productName"`
+	SKU         string  `json:"sku"`
+	Price       float64 `json:"price"`
+	Quantity    int     `json:"quantity"`
+	SubTotal    float64 `json:"subTotal"`
+	Discount    float64 `json:"discount"`
+	Total       float64 `json:"total"`
+}
+
+type QuoteShippingMethod struct {
+	ID      string  `json:"id"`
+	Name    string  `json:"name"`
+	Cost    float64 `json:"cost"`
+	EstDays int     `json:"estDays"`
+}
+
+// QuoteVoucher tells whether the requested voucher was applied and, if
+// not, why
+type QuoteVoucher struct {
+	Code     string  `json:"code"`
+	Applied  bool    `json:"applied"`
+	Discount float64 `json:"discount"`
+	Reason   string  `json:"reason,omitempty"`
+	Message  string  `json:"message,omitempty"`
+}
@@ -1,6 +1,8 @@
 package cart
 
 import (
+	"errors"
+	"io"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -102,3 +104,30 @@ func (h *Handler) RemoveItem(c *gin.Context) {
 		"message": "Item removed from cart",
 	})
 }
+
+// Quote previews the cart total for a shipping method and voucher
+func (h *Handler) Quote(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var req QuoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Invalid request body",
+			"code":    "BAD_REQUEST",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	quote, err := h.service.Quote(c.Request.Context(), userID.(string), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": err.Error(),
+			"code":    "QUOTE_FAILED",
+			"details": nil,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, quote)
+}
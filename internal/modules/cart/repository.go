@@ -53,10 +53,44 @@ func (r *Repository) FindVariantByID(ctx context.Context, id primitive.ObjectID)
 	return &variant, err
 }
 
-func (r *Repository) FindProductByID(ctx context.Context, id primitive.ObjectID) (*models.Product, error) {
-	var product models.Product
-	err := r.db.Collection("products").FindOne(ctx, bson.M{"_id": id, "isActive": true}).Decode(&product)
-	return &product, err
+// FindVariantsByIDs loads the active variants among ids, keyed by ID
+func (r *Repository) FindVariantsByIDs(ctx context.Context, ids []primitive.ObjectID) (map[primitive.ObjectID]*models.ProductVariant, error) {
+	cursor, err := r.db.Collection("product_variants").Find(ctx, bson.M{"_id": bson.M{"$in": ids}, "isActive": true})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var variants []*models.ProductVariant
+	if err := cursor.All(ctx, &variants); err != nil {
+		return nil, err
+	}
+
+	byID := make(map[primitive.ObjectID]*models.ProductVariant, len(variants))
+	for _, variant := range variants {
+		byID[variant.ID] = variant
+	}
+	return byID, nil
+}
+
+// FindProductsByIDs loads the active products among ids, keyed by ID
+func (r *Repository) FindProductsByIDs(ctx context.Context, ids []primitive.ObjectID) (map[primitive.ObjectID]*models.Product, error) {
+	cursor, err := r.db.Collection("products").Find(ctx, bson.M{"_id": bson.M{"$in": ids}, "isActive": true})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var products []*models.Product
+	if err := cursor.All(ctx, &products); err != nil {
+		return nil, err
+	}
+
+	byID := make(map[primitive.ObjectID]*models.Product, len(products))
+	for _, product := range products {
+		byID[product.ID] = product
+	}
+	return byID, nil
 }
 
 func (r *Repository) ClearCart(ctx context.Context, userID primitive.ObjectID) error {
@@ -6,17 +6,19 @@ import (
 	"time"
 
 	"phone-store-backend/internal/models"
+	"phone-store-backend/internal/pricing"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type Service struct {
-	repo *Repository
+	repo    *Repository
+	pricing *pricing.Service
 }
 
-func NewService(repo *Repository) *Service {
-	return &Service{repo: repo}
+func NewService(repo *Repository, pricing *pricing.Service) *Service {
+	return &Service{repo: repo, pricing: pricing}
 }
 
 func (s *Service) GetCart(ctx context.Context, userID string) (*CartResponse, error) {
@@ -48,9 +50,29 @@ func (s *Service) GetCart(ctx context.Context, userID string) (*CartResponse, er
 		return nil, err
 	}
 
+	// Total what is still on sale, with the promotions checkout will apply
+	var available []models.CartItem
+	for _, item := range cart.Items {
+		for _, shown := range items {
+			if shown.VariantID == item.VariantID.Hex() {
+				available = append(available, item)
+				break
+			}
+		}
+	}
+
+	priced, err := s.pricing.Price(ctx, &pricing.Request{UserID: uid, Items: available})
+	if err != nil {
+		return nil, err
+	}
+
 	return &CartResponse{
-		ID:    cart.ID.Hex(),
-		Items: items,
+		ID:         cart.ID.Hex(),
+		Items:      items,
+		SubTotal:   priced.SubTotal,
+		Discount:   priced.Discount(),
+		Total:      priced.Total(),
+		Promotions: transformPromotions(priced.Promotions),
 	}, nil
 }
 
@@ -182,17 +204,114 @@ func (s *Service) RemoveItem(ctx context.Context, userID, variantID string) erro
 	return s.repo.UpdateCart(ctx, cart.ID, newItems)
 }
 
+// Quote prices the cart the same way checkout will. A rejected voucher is
+// reported in the response rather than failing the quote.
+func (s *Service) Quote(ctx context.Context, userID string, req *QuoteRequest) (*QuoteResponse, error) {
+	uid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.New("invalid user ID")
+	}
+
+	var items []models.CartItem
+	cart, err := s.repo.FindCartByUserID(ctx, uid)
+	if err == nil {
+		items = cart.Items
+	} else if err != mongo.ErrNoDocuments {
+		return nil, err
+	}
+
+	priced, err := s.pricing.Price(ctx, &pricing.Request{
+		UserID:           uid,
+		Items:            items,
+		ShippingMethodID: req.ShippingMethodID,
+		VoucherCode:      req.VoucherCode,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	response := &QuoteResponse{
+		Items:            []QuoteItem{},
+		SubTotal:         priced.SubTotal,
+		ShippingFee:      priced.ShippingFee,
+		Discount:         priced.Discount(),
+		ShippingDiscount: priced.ShippingDiscount,
+		Total:            priced.Total(),
+		Promotions:       transformPromotions(priced.Promotions),
+	}
+
+	for _, item := range priced.Items {
+		response.Items = append(response.Items, QuoteItem{
+			VariantID:   item.Variant.ID.Hex(),
+			ProductID:   item.Product.ID.Hex(),
+			ProductName: item.Product.Name,
+			SKU:         item.Variant.SKU,
+			Price:       item.Line.UnitPrice,
+			Quantity:    item.Line.Quantity,
+			SubTotal:    item.Line.Subtotal(),
+			Discount:    item.Line.Discount,
+			Total:       item.Line.Subtotal() - item.Line.Discount,
+		})
+	}
+
+	if method := priced.ShippingMethod; method != nil {
+		response.ShippingMethod = &QuoteShippingMethod{
+			ID:      method.ID.Hex(),
+			Name:    method.Name,
+			Cost:    method.Cost,
+			EstDays: method.EstDays,
+		}
+	}
+
+	if priced.VoucherCode != "" {
+		voucher := &QuoteVoucher{Code: priced.VoucherCode}
+		var voucherErr *pricing.VoucherError
+		if errors.As(priced.VoucherErr, &voucherErr) {
+			voucher.Reason = string(voucherErr.Reason)
+			voucher.Message = voucherErr.Message
+		} else {
+			voucher.Applied = true
+			voucher.Discount = priced.VoucherDiscount
+		}
+		response.Voucher = voucher
+	}
+
+	return response, nil
+}
+
 func (s *Service) transformCartItems(ctx context.Context, items []models.CartItem) ([]CartItem, error) {
 	var result []CartItem
+	if len(items) == 0 {
+		return result, nil
+	}
+
+	// Load all variants, then all their products, in two queries
+	variantIDs := make([]primitive.ObjectID, len(items))
+	for i, item := range items {
+		variantIDs[i] = item.VariantID
+	}
+	variants, err := s.repo.FindVariantsByIDs(ctx, variantIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	productIDs := make([]primitive.ObjectID, 0, len(variants))
+	for _, variant := range variants {
+		productIDs = append(productIDs, variant.ProductID)
+	}
+	products, err := s.repo.FindProductsByIDs(ctx, productIDs)
+	if err != nil {
+		return nil, err
+	}
 
 	for _, item := range items {
-		variant, err := s.repo.FindVariantByID(ctx, item.VariantID)
-		if err != nil {
+		variant, ok := variants[item.VariantID]
+		if !ok {
 			continue // Skip if variant not found
 		}
 
-		product, err := s.repo.FindProductByID(ctx, variant.ProductID)
-		if err != nil {
+		product, ok := products[variant.ProductID]
+		if !ok {
 			continue
 		}
 
@@ -217,3 +336,15 @@ func (s *Service) transformCartItems(ctx context.Context, items []models.CartIte
 
 	return result, nil
 }
+
+func transformPromotions(applied []models.AppliedPromotion) []CartPromotion {
+	promotions := []CartPromotion{}
+	for _, promotion := range applied {
+		promotions = append(promotions, CartPromotion{
+			ID:       promotion.PromotionID.Hex(),
+			Name:     promotion.Name,
+			Discount: promotion.Discount,
+		})
+	}
+	return promotions
+}
@@ -2,9 +2,15 @@ package orders
 
 import "phone-store-backend/internal/models"
 
+// CreateOrderRequest DTO for checkout. The address is taken from
+// shippingAddressId, else the inline shippingAddress, else the customer's
+// default address.
 type CreateOrderRequest struct {
-	ShippingAddress ShippingAddressRequest `json:"shippingAddress" binding:"required"`
-	VoucherCode     string                 `json:"voucherCode"`
+	ShippingAddressID string                  `json:"shippingAddressId"`
+	ShippingAddress   *ShippingAddressRequest `json:"shippingAddress"`
+	ShippingMethodID  string                  `json:"shippingMethodId" binding:"required"`
+	PaymentMethodCode string                  `json:"paymentMethodCode" binding:"required"`
+	VoucherCode       string                  `json:"voucherCode"`
 }
 
 type ShippingAddressRequest struct {
@@ -20,11 +26,17 @@ type OrderResponse struct {
 	ID              string                      `json:"id"`
 	OrderNumber     string                      `json:"orderNumber"`
 	ShippingAddress models.OrderShippingAddress `json:"shippingAddress"`
+	ShippingMethod  *models.OrderShippingMethod `json:"shippingMethod,omitempty"`
+	PaymentMethod   string                      `json:"paymentMethod,omitempty"`
 	Items           []OrderItemResponse         `json:"items"`
 	SubTotal        float64                     `json:"subTotal"`
+	ShippingFee     float64                     `json:"shippingFee"`
 	Discount        float64                     `json:"discount"`
+	Promotions      []models.AppliedPromotion   `json:"appliedPromotions,omitempty"`
 	Total           float64                     `json:"total"`
+	RefundedTotal   float64                     `json:"refundedTotal"`
 	Status          string                      `json:"status"`
+	DeliveredAt     string                      `json:"deliveredAt,omitempty"`
 	CreatedAt       string                      `json:"createdAt"`
 }
 
@@ -37,6 +49,7 @@ type OrderItemResponse struct {
 	Storage    string  `json:"storage"`
 	Price      float64 `json:"price"`
 	Quantity   int     `json:"quantity"`
+	Discount   float64 `json:"discount"`
 	TotalPrice float64 `json:"totalPrice"`
 }
 
@@ -46,12 +59,19 @@ type UpdateOrderStatusRequest struct {
 	Note   string `json:"note"`
 }
 
+// CancelOrderRequest DTO for a customer canceling their own order
+type CancelOrderRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
 // StatusHistoryResponse DTO for order status history
 type StatusHistoryResponse struct {
-	Status    string `json:"status"`
-	Note      string `json:"note"`
-	UpdatedBy string `json:"updatedBy"`
-	CreatedAt string `json:"createdAt"`
+	FromStatus string `json:"fromStatus,omitempty"`
+	Status     string `json:"status"`
+	Note       string `json:"note"`
+	Actor      string `json:"actor,omitempty"`
+	UpdatedBy  string `json:"updatedBy"`
+	CreatedAt  string `json:"createdAt"`
 }
 
 // OrdersListResponse DTO for paginated orders list
@@ -1,9 +1,12 @@
 package orders
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
+	"phone-store-backend/internal/pricing"
+
 	"github.com/gin-gonic/gin"
 )
 
@@ -30,6 +33,24 @@ func (h *Handler) CreateOrder(c *gin.Context) {
 
 	order, err := h.service.CreateOrder(c.Request.Context(), userID.(string), &req)
 	if err != nil {
+		var voucherErr *pricing.VoucherError
+		if errors.As(err, &voucherErr) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": err.Error(),
+				"code":    "VOUCHER_REJECTED",
+				"details": gin.H{"reason": voucherErr.Reason},
+			})
+			return
+		}
+		var methodErr *PaymentMethodError
+		if errors.As(err, &methodErr) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": err.Error(),
+				"code":    "PAYMENT_METHOD_REJECTED",
+				"details": gin.H{"reason": methodErr.Reason},
+			})
+			return
+		}
 		c.JSON(http.StatusBadRequest, gin.H{
 			"message": err.Error(),
 			"code":    "CREATE_ORDER_FAILED",
@@ -77,6 +98,50 @@ func (h *Handler) GetOrderByID(c *gin.Context) {
 	c.JSON(http.StatusOK, order)
 }
 
+// CancelOrder godoc
+// @Summary Cancel my order while it is still pending and unpaid
+// @Tags Orders
+// @Security BearerAuth
+// @Param id path string true "Order ID"
+// @Param request body CancelOrderRequest true "Cancellation reason"
+// @Success 200
+// @Failure 409 "Order is no longer cancelable"
+// @Router /api/orders/{id}/cancel [post]
+func (h *Handler) CancelOrder(c *gin.Context) {
+	userID := c.GetString("userID")
+	orderID := c.Param("id")
+
+	var req CancelOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Invalid request body",
+			"code":    "BAD_REQUEST",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if err := h.service.CancelOrder(c.Request.Context(), userID, orderID, req.Reason); err != nil {
+		status := http.StatusBadRequest
+		code := "CANCEL_ORDER_FAILED"
+		var transitionErr *TransitionError
+		if errors.As(err, &transitionErr) || errors.Is(err, ErrOrderAlreadyPaid) {
+			status = http.StatusConflict
+			code = "ORDER_NOT_CANCELABLE"
+		}
+		c.JSON(status, gin.H{
+			"message": err.Error(),
+			"code":    code,
+			"details": nil,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Order canceled",
+	})
+}
+
 // UpdateOrderStatus godoc
 // @Summary Update order status (admin only)
 // @Tags Orders
@@ -84,6 +149,7 @@ func (h *Handler) GetOrderByID(c *gin.Context) {
 // @Param id path string true "Order ID"
 // @Param request body UpdateOrderStatusRequest true "Status data"
 // @Success 200
+// @Failure 409 "Transition not allowed from the current status"
 // @Router /api/orders/{id}/status [put]
 func (h *Handler) UpdateOrderStatus(c *gin.Context) {
 	userID := c.GetString("userID")
@@ -100,7 +166,12 @@ func (h *Handler) UpdateOrderStatus(c *gin.Context) {
 	}
 
 	if err := h.service.UpdateOrderStatus(c.Request.Context(), orderID, req.Status, req.Note, userID); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
+		status := http.StatusBadRequest
+		var transitionErr *TransitionError
+		if errors.As(err, &transitionErr) {
+			status = http.StatusConflict
+		}
+		c.JSON(status, gin.H{
 			"success": false,
 			"message": err.Error(),
 			"data":    nil,
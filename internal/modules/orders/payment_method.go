@@ -0,0 +1,69 @@
+package orders
+
+import (
+	"strings"
+
+	"phone-store-backend/internal/models"
+	"phone-store-backend/internal/pricing"
+)
+
+// PaymentMethodReason is a machine-readable reason a payment method was
+// refused at checkout
+type PaymentMethodReason string
+
+const (
+	ReasonPaymentMethodNotFound PaymentMethodReason = "PAYMENT_METHOD_NOT_FOUND"
+	ReasonOrderValueTooHigh     PaymentMethodReason = "ORDER_VALUE_TOO_HIGH"
+	ReasonProvinceNotSupported  PaymentMethodReason = "PROVINCE_NOT_SUPPORTED"
+	ReasonPreOrderOnlineOnly    PaymentMethodReason = "PRE_ORDER_REQUIRES_ONLINE_PAYMENT"
+)
+
+// PaymentMethodError explains why a payment method cannot be used for an
+// order
+type PaymentMethodError struct {
+	Reason  PaymentMethodReason
+	Message string
+}
+
+func (e *PaymentMethodError) Error() string {
+	return e.Message
+}
+
+// Is matches any payment method error with the same reason
+func (e *PaymentMethodError) Is(target error) bool {
+	t, ok := target.(*PaymentMethodError)
+	return ok && t.Reason == e.Reason
+}
+
+// Payment method rejection errors
+var (
+	ErrPaymentMethodNotFound = &PaymentMethodError{ReasonPaymentMethodNotFound, "payment method not available"}
+	ErrOrderValueTooHigh     = &PaymentMethodError{ReasonOrderValueTooHigh, "order total exceeds the limit of this payment method"}
+	ErrProvinceNotSupported  = &PaymentMethodError{ReasonProvinceNotSupported, "payment method is not available for this shipping address"}
+	ErrPreOrderOnlineOnly    = &PaymentMethodError{ReasonPreOrderOnlineOnly, "orders with pre-order items must be paid online"}
+)
+
+// checkPaymentMethod reports why method cannot pay for an order of total
+// shipped to address, or nil when it can
+func checkPaymentMethod(method *models.PaymentMethod, total float64, address *models.OrderShippingAddress, items []*pricing.Item) error {
+	if method.MaxOrderValue > 0 && total > method.MaxOrderValue {
+		return ErrOrderValueTooHigh
+	}
+
+	city := strings.TrimSpace(address.City)
+	for _, province := range method.DisabledProvinces {
+		if strings.EqualFold(strings.TrimSpace(province), city) {
+			return ErrProvinceNotSupported
+		}
+	}
+
+	if method.Code == models.PaymentMethodCOD {
+		for _, item := range items {
+			if item.Product.IsPreOrder {
+				return ErrPreOrderOnlineOnly
+			}
+		}
+	}
+
+	return nil
+}
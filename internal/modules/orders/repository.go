@@ -2,6 +2,7 @@ package orders
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"phone-store-backend/internal/models"
@@ -12,6 +13,10 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// ErrInsufficientStock is returned when a variant no longer has enough stock
+// to cover the requested quantity.
+var ErrInsufficientStock = errors.New("insufficient stock")
+
 type Repository struct {
 	db *mongo.Database
 }
@@ -20,6 +25,22 @@ func NewRepository(db *mongo.Database) *Repository {
 	return &Repository{db: db}
 }
 
+// WithTransaction runs fn inside a multi-document transaction. Transient
+// errors (e.g. write conflicts between concurrent checkouts) are retried by
+// the driver; any other error aborts the transaction.
+func (r *Repository) WithTransaction(ctx context.Context, fn func(sessCtx mongo.SessionContext) error) error {
+	session, err := r.db.Client().StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sessCtx)
+	})
+	return err
+}
+
 func (r *Repository) CreateOrder(ctx context.Context, order *models.Order) error {
 	_, err := r.db.Collection("orders").InsertOne(ctx, order)
 	return err
@@ -40,31 +61,124 @@ func (r *Repository) FindCartByUserID(ctx context.Context, userID primitive.Obje
 	return &cart, err
 }
 
-func (r *Repository) FindVariantByID(ctx context.Context, id primitive.ObjectID) (*models.ProductVariant, error) {
-	var variant models.ProductVariant
-	err := r.db.Collection("product_variants").FindOne(ctx, bson.M{"_id": id, "isActive": true}).Decode(&variant)
-	return &variant, err
+// ClaimVoucherUse counts one more use of a voucher unless its usage limit
+// is reached. Every redemption writes the voucher document, so concurrent
+// checkouts with the same voucher conflict and are retried one at a time.
+func (r *Repository) ClaimVoucherUse(ctx context.Context, voucherID primitive.ObjectID) (bool, error) {
+	result, err := r.db.Collection("vouchers").UpdateOne(
+		ctx,
+		bson.M{
+			"_id":      voucherID,
+			"isActive": true,
+			"$expr": bson.M{"$or": bson.A{
+				bson.M{"$lte": bson.A{bson.M{"$ifNull": bson.A{"$usageLimit", 0}}, 0}},
+				bson.M{"$lt": bson.A{bson.M{"$ifNull": bson.A{"$usedCount", 0}}, "$usageLimit"}},
+			}},
+		},
+		bson.M{
+			"$inc": bson.M{"usedCount": 1},
+			"$set": bson.M{"updatedAt": time.Now()},
+		},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
+// ClaimVoucherCode marks a generated single-use code as redeemed by an
+// order unless it already was
+func (r *Repository) ClaimVoucherCode(ctx context.Context, codeID, userID, orderID primitive.ObjectID) (bool, error) {
+	result, err := r.db.Collection("voucher_codes").UpdateOne(
+		ctx,
+		bson.M{"_id": codeID, "status": models.VoucherCodeStatusAvailable},
+		bson.M{"$set": bson.M{
+			"status":     models.VoucherCodeStatusRedeemed,
+			"userId":     userID,
+			"orderId":    orderID,
+			"redeemedAt": time.Now(),
+		}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount > 0, nil
 }
 
-func (r *Repository) FindProductByID(ctx context.Context, id primitive.ObjectID) (*models.Product, error) {
-	var product models.Product
-	err := r.db.Collection("products").FindOne(ctx, bson.M{"_id": id, "isActive": true}).Decode(&product)
-	return &product, err
+func (r *Repository) CreateRedemption(ctx context.Context, redemption *models.VoucherRedemption) error {
+	_, err := r.db.Collection("voucher_redemptions").InsertOne(ctx, redemption)
+	return err
 }
 
-func (r *Repository) FindVoucherByCode(ctx context.Context, code string) (*models.Voucher, error) {
-	var voucher models.Voucher
-	err := r.db.Collection("vouchers").FindOne(ctx, bson.M{"code": code, "isActive": true}).Decode(&voucher)
-	return &voucher, err
+// ReleaseRedemption gives back the voucher use of a canceled order
+func (r *Repository) ReleaseRedemption(ctx context.Context, orderID primitive.ObjectID) error {
+	var redemption models.VoucherRedemption
+	err := r.db.Collection("voucher_redemptions").FindOneAndUpdate(
+		ctx,
+		bson.M{"orderId": orderID, "status": models.RedemptionStatusActive},
+		bson.M{"$set": bson.M{
+			"status":     models.RedemptionStatusReleased,
+			"releasedAt": time.Now(),
+		}},
+	).Decode(&redemption)
+	if err == mongo.ErrNoDocuments {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Collection("vouchers").UpdateOne(
+		ctx,
+		bson.M{"_id": redemption.VoucherID, "usedCount": bson.M{"$gt": 0}},
+		bson.M{
+			"$inc": bson.M{"usedCount": -1},
+			"$set": bson.M{"updatedAt": time.Now()},
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	// A generated single-use code can be used again
+	_, err = r.db.Collection("voucher_codes").UpdateOne(
+		ctx,
+		bson.M{"orderId": orderID, "status": models.VoucherCodeStatusRedeemed},
+		bson.M{
+			"$set":   bson.M{"status": models.VoucherCodeStatusAvailable},
+			"$unset": bson.M{"userId": "", "orderId": "", "redeemedAt": ""},
+		},
+	)
+	return err
 }
 
-// Decrease stock when order is created
+// DecreaseStock atomically decrements stock only if enough is left, so
+// concurrent checkouts can never push a variant below zero.
 func (r *Repository) DecreaseStock(ctx context.Context, variantID primitive.ObjectID, quantity int) error {
+	result, err := r.db.Collection("product_variants").UpdateOne(
+		ctx,
+		bson.M{"_id": variantID, "isActive": true, "stock": bson.M{"$gte": quantity}},
+		bson.M{
+			"$inc": bson.M{"stock": -quantity},
+			"$set": bson.M{"updatedAt": time.Now()},
+		},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrInsufficientStock
+	}
+	return nil
+}
+
+// IncreaseStock returns quantity to a variant, e.g. when an order is canceled
+func (r *Repository) IncreaseStock(ctx context.Context, variantID primitive.ObjectID, quantity int) error {
 	_, err := r.db.Collection("product_variants").UpdateOne(
 		ctx,
 		bson.M{"_id": variantID},
 		bson.M{
-			"$inc": bson.M{"stock": -quantity},
+			"$inc": bson.M{"stock": quantity},
 			"$set": bson.M{"updatedAt": time.Now()},
 		},
 	)
@@ -120,6 +234,27 @@ func (r *Repository) FindOrderItemsByOrderID(ctx context.Context, orderID primit
 	return items, nil
 }
 
+// FindOrderItemsByOrderIDs loads the items of several orders in one query,
+// grouped by order ID
+func (r *Repository) FindOrderItemsByOrderIDs(ctx context.Context, orderIDs []primitive.ObjectID) (map[primitive.ObjectID][]*models.OrderItem, error) {
+	cursor, err := r.db.Collection("order_items").Find(ctx, bson.M{"orderId": bson.M{"$in": orderIDs}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var items []*models.OrderItem
+	if err := cursor.All(ctx, &items); err != nil {
+		return nil, err
+	}
+
+	byOrder := make(map[primitive.ObjectID][]*models.OrderItem, len(orderIDs))
+	for _, item := range items {
+		byOrder[item.OrderID] = append(byOrder[item.OrderID], item)
+	}
+	return byOrder, nil
+}
+
 // Order Status History methods
 func (r *Repository) CreateStatusHistory(ctx context.Context, history *models.OrderStatusHistory) error {
 	_, err := r.db.Collection("order_status_history").InsertOne(ctx, history)
@@ -141,13 +276,150 @@ func (r *Repository) FindStatusHistoryByOrderID(ctx context.Context, orderID pri
 	return history, nil
 }
 
-func (r *Repository) UpdateOrderStatus(ctx context.Context, id primitive.ObjectID, status string) error {
+// UpdateOrderStatus moves an order from one status to another. It only
+// matches while the order is still in the from status, so two concurrent
+// transitions cannot both succeed; false is returned when nothing matched.
+func (r *Repository) UpdateOrderStatus(ctx context.Context, id primitive.ObjectID, from, to models.OrderStatus) (bool, error) {
+	result, err := r.db.Collection("orders").UpdateOne(
+		ctx,
+		bson.M{"_id": id, "status": from},
+		bson.M{
+			"$set": bson.M{
+				"status":    to,
+				"updatedAt": time.Now(),
+			},
+		},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
+// SetDeliveredAt records when an order was delivered
+func (r *Repository) SetDeliveredAt(ctx context.Context, id primitive.ObjectID, at time.Time) error {
 	_, err := r.db.Collection("orders").UpdateOne(
 		ctx,
 		bson.M{"_id": id},
+		bson.M{"$set": bson.M{"deliveredAt": at, "updatedAt": time.Now()}},
+	)
+	return err
+}
+
+// Payment methods
+func (r *Repository) FindPaymentMethodByCode(ctx context.Context, code string) (*models.PaymentMethod, error) {
+	var method models.PaymentMethod
+	err := r.db.Collection("payment_methods").FindOne(ctx, bson.M{"code": code, "isActive": true}).Decode(&method)
+	if err != nil {
+		return nil, err
+	}
+	return &method, nil
+}
+
+// CreatePayment records the pending payment opened at checkout
+func (r *Repository) CreatePayment(ctx context.Context, payment *models.Payment) error {
+	_, err := r.db.Collection("payments").InsertOne(ctx, payment)
+	return err
+}
+
+func (r *Repository) HasCompletedPayment(ctx context.Context, orderID primitive.ObjectID) (bool, error) {
+	count, err := r.db.Collection("payments").CountDocuments(ctx, bson.M{
+		"orderId": orderID,
+		"status":  models.PaymentStatusCompleted,
+	})
+	return count > 0, err
+}
+
+// CaptureCODPayment completes the pending cash on delivery payment of an
+// order, if it has one
+func (r *Repository) CaptureCODPayment(ctx context.Context, orderID primitive.ObjectID) error {
+	now := time.Now()
+	_, err := r.db.Collection("payments").UpdateOne(
+		ctx,
+		bson.M{
+			"orderId": orderID,
+			"method":  models.PaymentMethodCOD,
+			"status":  models.PaymentStatusPending,
+		},
 		bson.M{
 			"$set": bson.M{
-				"status":    status,
+				"status":    models.PaymentStatusCompleted,
+				"paidAt":    now,
+				"updatedAt": now,
+			},
+		},
+	)
+	return err
+}
+
+// QueueRefunds records a pending refund of what is left of every captured
+// payment of an order, reserving it on the payment the way the refunds
+// subsystem does, so staff find the refunds in the refunds list
+func (r *Repository) QueueRefunds(ctx context.Context, orderID primitive.ObjectID, reason string) error {
+	cursor, err := r.db.Collection("payments").Find(ctx, bson.M{
+		"orderId": orderID,
+		"status":  models.PaymentStatusCompleted,
+	})
+	if err != nil {
+		return err
+	}
+	var payments []*models.Payment
+	if err := cursor.All(ctx, &payments); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, payment := range payments {
+		amount := payment.Amount - payment.Refunded
+		if amount <= 0 {
+			continue
+		}
+
+		result, err := r.db.Collection("payments").UpdateOne(
+			ctx,
+			bson.M{
+				"_id": payment.ID,
+				"$expr": bson.M{"$lte": bson.A{
+					bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$refunded", 0}}, amount}},
+					"$amount",
+				}},
+			},
+			bson.M{
+				"$inc": bson.M{"refunded": amount},
+				"$set": bson.M{"updatedAt": now},
+			},
+		)
+		if err != nil {
+			return err
+		}
+		if result.MatchedCount == 0 {
+			continue // Refunded in the meantime
+		}
+
+		_, err = r.db.Collection("refunds").InsertOne(ctx, &models.Refund{
+			ID:        primitive.NewObjectID(),
+			OrderID:   orderID,
+			PaymentID: payment.ID,
+			Amount:    amount,
+			Status:    models.RefundStatusPending,
+			Reason:    reason,
+			CreatedAt: now,
+			UpdatedAt: now,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Repository) VoidPendingPayments(ctx context.Context, orderID primitive.ObjectID) error {
+	_, err := r.db.Collection("payments").UpdateMany(
+		ctx,
+		bson.M{"orderId": orderID, "status": models.PaymentStatusPending},
+		bson.M{
+			"$set": bson.M{
+				"status":    models.PaymentStatusVoided,
 				"updatedAt": time.Now(),
 			},
 		},
@@ -155,6 +427,29 @@ func (r *Repository) UpdateOrderStatus(ctx context.Context, id primitive.ObjectI
 	return err
 }
 
+// FindUnpaidOrders returns up to limit pending orders placed before the
+// given time with one of the given payment methods, oldest first
+func (r *Repository) FindUnpaidOrders(ctx context.Context, methods []string, placedBefore time.Time, limit int64) ([]*models.Order, error) {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "createdAt", Value: 1}}).
+		SetLimit(limit)
+	cursor, err := r.db.Collection("orders").Find(ctx, bson.M{
+		"status":        models.OrderStatusPending,
+		"paymentMethod": bson.M{"$in": methods},
+		"createdAt":     bson.M{"$lt": placedBefore},
+	}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var orders []*models.Order
+	if err := cursor.All(ctx, &orders); err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
+
 // Admin methods
 func (r *Repository) FindAllOrders(ctx context.Context, filter bson.M, opts *options.FindOptions) ([]*models.Order, error) {
 	cursor, err := r.db.Collection("orders").Find(ctx, filter, opts)
@@ -4,20 +4,44 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log"
 	"time"
 
 	"phone-store-backend/internal/models"
+	"phone-store-backend/internal/pricing"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// ErrOrderAlreadyPaid is returned when a customer tries to cancel a paid order
+var ErrOrderAlreadyPaid = errors.New("order has already been paid")
+
+// AddressBook looks up a customer's saved shipping addresses. Implemented
+// by shipping.Repository.
+type AddressBook interface {
+	FindAddressByID(ctx context.Context, id primitive.ObjectID) (*models.ShippingAddress, error)
+	FindDefaultAddress(ctx context.Context, userID primitive.ObjectID) (*models.ShippingAddress, error)
+}
+
 type Service struct {
-	repo *Repository
+	repo      *Repository
+	pricing   *pricing.Service
+	addresses AddressBook
+	// payable holds the payment method codes that have a gateway set up
+	payable map[string]bool
 }
 
-func NewService(repo *Repository) *Service {
-	return &Service{repo: repo}
+// NewService creates the order service. paymentMethods are the codes of the
+// payment methods the server can take payments with; any other method is
+// refused at checkout.
+func NewService(repo *Repository, pricing *pricing.Service, addresses AddressBook, paymentMethods []string) *Service {
+	payable := make(map[string]bool, len(paymentMethods))
+	for _, code := range paymentMethods {
+		payable[code] = true
+	}
+	return &Service{repo: repo, pricing: pricing, addresses: addresses, payable: payable}
 }
 
 func (s *Service) CreateOrder(ctx context.Context, userID string, req *CreateOrderRequest) (*OrderResponse, error) {
@@ -36,116 +60,252 @@ func (s *Service) CreateOrder(ctx context.Context, userID string, req *CreateOrd
 		return nil, errors.New("cart is empty")
 	}
 
-	// Validate stock and calculate total
-	var orderItems []*models.OrderItem
-	var subTotal float64
-
-	for _, cartItem := range cart.Items {
-		variant, err := s.repo.FindVariantByID(ctx, cartItem.VariantID)
-		if err != nil {
-			return nil, fmt.Errorf("variant %s not found", cartItem.VariantID.Hex())
-		}
+	shippingAddress, err := s.resolveShippingAddress(ctx, uid, req)
+	if err != nil {
+		return nil, err
+	}
 
-		// Check stock availability
-		if variant.Stock < cartItem.Quantity {
-			return nil, fmt.Errorf("insufficient stock for %s", variant.SKU)
-		}
+	// Price the cart exactly as the cart preview does
+	priced, err := s.pricing.Price(ctx, &pricing.Request{
+		UserID:           uid,
+		Items:            cart.Items,
+		ShippingMethodID: req.ShippingMethodID,
+		VoucherCode:      req.VoucherCode,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if priced.VoucherErr != nil {
+		return nil, priced.VoucherErr
+	}
+	quote := priced.Quote
 
-		product, err := s.repo.FindProductByID(ctx, variant.ProductID)
-		if err != nil {
-			return nil, fmt.Errorf("product not found for variant %s", variant.SKU)
+	// Validate stock and snapshot prices
+	var orderItems []*models.OrderItem
+	for _, item := range priced.Items {
+		if item.Variant.Stock < item.Line.Quantity {
+			return nil, fmt.Errorf("%w for %s", ErrInsufficientStock, item.Variant.SKU)
 		}
 
-		// Create order item with snapshot price
-		orderItem := &models.OrderItem{
+		orderItems = append(orderItems, &models.OrderItem{
 			ID:        primitive.NewObjectID(),
-			VariantID: variant.ID,
-			ProductID: product.ID,
-			Name:      product.Name,
-			SKU:       variant.SKU,
-			Color:     variant.Color,
-			Storage:   variant.Storage,
-			Price:     variant.Price,
-			Quantity:  cartItem.Quantity,
+			VariantID: item.Variant.ID,
+			ProductID: item.Product.ID,
+			Name:      item.Product.Name,
+			SKU:       item.Variant.SKU,
+			Color:     item.Variant.Color,
+			Storage:   item.Variant.Storage,
+			Price:     item.Line.UnitPrice,
+			Quantity:  item.Line.Quantity,
+			Discount:  item.Line.Discount, // Share of item discounts, for pro-rated refunds
 			CreatedAt: time.Now(),
 			UpdatedAt: time.Now(),
-		}
+		})
+	}
+
+	paymentMethod, err := s.repo.FindPaymentMethodByCode(ctx, req.PaymentMethodCode)
+	if err == mongo.ErrNoDocuments || (err == nil && !s.payable[paymentMethod.Code]) {
+		return nil, ErrPaymentMethodNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	if err := checkPaymentMethod(paymentMethod, quote.Total(), shippingAddress, priced.Items); err != nil {
+		return nil, err
+	}
+
+	voucherCode := ""
+	if priced.Voucher != nil {
+		voucherCode = priced.VoucherCode
+	}
+
+	method := priced.ShippingMethod
+	placedAt := time.Now()
+
+	// Create order
+	order := &models.Order{
+		ID:              primitive.NewObjectID(),
+		OrderNumber:     s.generateOrderNumber(),
+		UserID:          uid,
+		ShippingAddress: *shippingAddress,
+		ShippingMethod: &models.OrderShippingMethod{
+			ID:                method.ID,
+			Name:              method.Name,
+			Cost:              method.Cost,
+			EstDays:           method.EstDays,
+			EstimatedDelivery: placedAt.AddDate(0, 0, method.EstDays),
+		},
+		PaymentMethod:    paymentMethod.Code,
+		VoucherCode:      voucherCode,
+		Promotions:       quote.Promotions,
+		SubTotal:         quote.SubTotal,
+		ShippingFee:      quote.ShippingFee,
+		Discount:         quote.Discount(),
+		ShippingDiscount: quote.ShippingDiscount,
+		Total:            quote.Total(),
+		Status:           models.OrderStatusPending,
+		CreatedAt:        placedAt,
+		UpdatedAt:        placedAt,
+	}
 
-		orderItems = append(orderItems, orderItem)
-		subTotal += variant.Price * float64(cartItem.Quantity)
+	// Set order ID for items
+	for _, item := range orderItems {
+		item.OrderID = order.ID
 	}
 
-	// Apply voucher if provided
-	discount := 0.0
-	if req.VoucherCode != "" {
-		voucher, err := s.repo.FindVoucherByCode(ctx, req.VoucherCode)
-		if err == nil && voucher.IsActive && time.Now().Before(voucher.ExpiredAt) {
-			// Calculate discount
-			if subTotal >= voucher.MinOrderValue {
-				discount = subTotal * (voucher.DiscountPercent / 100)
-				if discount > voucher.MaxDiscount {
-					discount = voucher.MaxDiscount
+	// The payment is opened with the order; POST /api/payments later starts
+	// the gateway checkout for it
+	payment := &models.Payment{
+		ID:        primitive.NewObjectID(),
+		OrderID:   order.ID,
+		Method:    paymentMethod.Code,
+		Amount:    order.Total,
+		Status:    models.PaymentStatusPending,
+		CreatedAt: placedAt,
+		UpdatedAt: placedAt,
+	}
+
+	// Reserve stock, save the order and clear the cart atomically. If any
+	// line can no longer be covered the whole checkout is rolled back.
+	err = s.repo.WithTransaction(ctx, func(sessCtx mongo.SessionContext) error {
+		for _, item := range orderItems {
+			if err := s.repo.DecreaseStock(sessCtx, item.VariantID, item.Quantity); err != nil {
+				if errors.Is(err, ErrInsufficientStock) {
+					return fmt.Errorf("%w for %s", ErrInsufficientStock, item.SKU)
 				}
+				return err
 			}
 		}
+
+		if priced.Voucher != nil {
+			if err := s.redeemVoucher(sessCtx, priced, order); err != nil {
+				return err
+			}
+		}
+
+		if err := s.repo.CreateOrder(sessCtx, order); err != nil {
+			return err
+		}
+
+		if err := s.repo.CreateOrderItems(sessCtx, orderItems); err != nil {
+			return err
+		}
+
+		if err := s.repo.CreatePayment(sessCtx, payment); err != nil {
+			return err
+		}
+
+		if err := s.repo.CreateStatusHistory(sessCtx, &models.OrderStatusHistory{
+			ID:        primitive.NewObjectID(),
+			OrderID:   order.ID,
+			Status:    string(order.Status),
+			Note:      "Order placed",
+			Actor:     string(ActorCustomer),
+			UpdatedBy: uid,
+			CreatedAt: time.Now(),
+		}); err != nil {
+			return err
+		}
+
+		return s.repo.ClearCart(sessCtx, uid)
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	total := subTotal - discount
+	// Return order response
+	return s.transformOrder(order, orderItems), nil
+}
+
+// resolveShippingAddress picks the address to snapshot onto the order: the
+// saved address the customer chose, an address typed at checkout, or their
+// default address
+func (s *Service) resolveShippingAddress(ctx context.Context, userID primitive.ObjectID, req *CreateOrderRequest) (*models.OrderShippingAddress, error) {
+	var saved *models.ShippingAddress
+	switch {
+	case req.ShippingAddressID != "":
+		addressID, err := primitive.ObjectIDFromHex(req.ShippingAddressID)
+		if err != nil {
+			return nil, errors.New("invalid shipping address ID")
+		}
+		address, err := s.addresses.FindAddressByID(ctx, addressID)
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New("shipping address not found")
+		} else if err != nil {
+			return nil, err
+		}
+		// Someone else's address is reported as missing
+		if address.UserID != userID {
+			return nil, errors.New("shipping address not found")
+		}
+		saved = address
 
-	// Create order
-	order := &models.Order{
-		ID:          primitive.NewObjectID(),
-		OrderNumber: s.generateOrderNumber(),
-		UserID:      uid,
-		ShippingAddress: models.OrderShippingAddress{
+	case req.ShippingAddress != nil:
+		return &models.OrderShippingAddress{
 			FullName: req.ShippingAddress.FullName,
 			Phone:    req.ShippingAddress.Phone,
 			Address:  req.ShippingAddress.Address,
 			City:     req.ShippingAddress.City,
 			District: req.ShippingAddress.District,
 			Ward:     req.ShippingAddress.Ward,
-		},
-		VoucherCode: req.VoucherCode,
-		SubTotal:    subTotal,
-		Discount:    discount,
-		Total:       total,
-		Status:      models.OrderStatusPending,
-		CreatedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
+		}, nil
+
+	default:
+		address, err := s.addresses.FindDefaultAddress(ctx, userID)
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New("shipping address is required: pass shippingAddressId or shippingAddress, or set a default address")
+		} else if err != nil {
+			return nil, err
+		}
+		saved = address
 	}
 
-	// Save order
-	if err := s.repo.CreateOrder(ctx, order); err != nil {
-		return nil, err
-	}
+	return &models.OrderShippingAddress{
+		FullName: saved.FullName,
+		Phone:    saved.Phone,
+		Address:  saved.Address,
+		City:     saved.City,
+		District: saved.District,
+		Ward:     saved.Ward,
+	}, nil
+}
 
-	// Set order ID for items
-	for _, item := range orderItems {
-		item.OrderID = order.ID
+// redeemVoucher enforces the voucher's usage rules and records its use by
+// order. It must run inside the checkout transaction, before the order is
+// saved.
+func (s *Service) redeemVoucher(sessCtx mongo.SessionContext, priced *pricing.Result, order *models.Order) error {
+	voucher := priced.Voucher
+	if err := s.pricing.CheckCustomer(sessCtx, voucher, order.UserID); err != nil {
+		return err
 	}
 
-	// Save order items
-	if err := s.repo.CreateOrderItems(ctx, orderItems); err != nil {
-		return nil, err
+	claimed, err := s.repo.ClaimVoucherUse(sessCtx, voucher.ID)
+	if err != nil {
+		return err
 	}
-
-	// Decrease stock for each variant
-	for _, item := range orderItems {
-		if err := s.repo.DecreaseStock(ctx, item.VariantID, item.Quantity); err != nil {
-			// Log error but don't fail the order
-			// In production, you might want to use transactions
-			fmt.Printf("Warning: Failed to decrease stock for variant %s: %v\n", item.VariantID.Hex(), err)
-		}
+	if !claimed {
+		return pricing.ErrVoucherUsageExhausted
 	}
 
-	// Clear cart
-	if err := s.repo.ClearCart(ctx, uid); err != nil {
-		// Log error but don't fail the order
-		fmt.Printf("Warning: Failed to clear cart: %v\n", err)
+	if priced.SingleUseCode != nil {
+		claimed, err := s.repo.ClaimVoucherCode(sessCtx, priced.SingleUseCode.ID, order.UserID, order.ID)
+		if err != nil {
+			return err
+		}
+		if !claimed {
+			return pricing.ErrVoucherCodeUsed
+		}
 	}
 
-	// Return order response
-	return s.transformOrder(order, orderItems), nil
+	return s.repo.CreateRedemption(sessCtx, &models.VoucherRedemption{
+		ID:        primitive.NewObjectID(),
+		VoucherID: voucher.ID,
+		Code:      priced.VoucherCode,
+		UserID:    order.UserID,
+		OrderID:   order.ID,
+		Discount:  priced.VoucherDiscount,
+		Status:    models.RedemptionStatusActive,
+		CreatedAt: time.Now(),
+	})
 }
 
 func (s *Service) GetMyOrders(ctx context.Context, userID string) ([]*OrderResponse, error) {
@@ -159,16 +319,7 @@ func (s *Service) GetMyOrders(ctx context.Context, userID string) ([]*OrderRespo
 		return nil, err
 	}
 
-	var response []*OrderResponse
-	for _, order := range orders {
-		items, err := s.repo.FindOrderItemsByOrderID(ctx, order.ID)
-		if err != nil {
-			continue
-		}
-		response = append(response, s.transformOrder(order, items))
-	}
-
-	return response, nil
+	return s.transformOrders(ctx, orders)
 }
 
 func (s *Service) GetOrderByID(ctx context.Context, userID, orderID string, isAdmin bool) (*OrderResponse, error) {
@@ -199,6 +350,30 @@ func (s *Service) generateOrderNumber() string {
 	return fmt.Sprintf("ORD-%d", time.Now().UnixNano()/1000000)
 }
 
+// transformOrders builds the responses for a list of orders, loading all
+// their items at once
+func (s *Service) transformOrders(ctx context.Context, orders []*models.Order) ([]*OrderResponse, error) {
+	if len(orders) == 0 {
+		return nil, nil
+	}
+
+	orderIDs := make([]primitive.ObjectID, len(orders))
+	for i, order := range orders {
+		orderIDs[i] = order.ID
+	}
+
+	items, err := s.repo.FindOrderItemsByOrderIDs(ctx, orderIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	response := make([]*OrderResponse, 0, len(orders))
+	for _, order := range orders {
+		response = append(response, s.transformOrder(order, items[order.ID]))
+	}
+	return response, nil
+}
+
 func (s *Service) transformOrder(order *models.Order, items []*models.OrderItem) *OrderResponse {
 	var itemResponses []OrderItemResponse
 	for _, item := range items {
@@ -211,24 +386,34 @@ func (s *Service) transformOrder(order *models.Order, items []*models.OrderItem)
 			Storage:    item.Storage,
 			Price:      item.Price,
 			Quantity:   item.Quantity,
+			Discount:   item.Discount,
 			TotalPrice: item.Price * float64(item.Quantity),
 		})
 	}
 
-	return &OrderResponse{
+	resp := &OrderResponse{
 		ID:              order.ID.Hex(),
 		OrderNumber:     order.OrderNumber,
 		ShippingAddress: order.ShippingAddress,
+		ShippingMethod:  order.ShippingMethod,
+		PaymentMethod:   order.PaymentMethod,
 		Items:           itemResponses,
 		SubTotal:        order.SubTotal,
+		ShippingFee:     order.ShippingFee,
 		Discount:        order.Discount,
+		Promotions:      order.Promotions,
 		Total:           order.Total,
+		RefundedTotal:   order.RefundedTotal,
 		Status:          string(order.Status),
 		CreatedAt:       order.CreatedAt.Format(time.RFC3339),
 	}
+	if order.DeliveredAt != nil {
+		resp.DeliveredAt = order.DeliveredAt.Format(time.RFC3339)
+	}
+	return resp
 }
 
-// UpdateOrderStatus updates order status and logs history
+// UpdateOrderStatus changes order status on behalf of staff
 func (s *Service) UpdateOrderStatus(ctx context.Context, orderID, status, note, updatedBy string) error {
 	oid, err := primitive.ObjectIDFromHex(orderID)
 	if err != nil {
@@ -240,35 +425,164 @@ func (s *Service) UpdateOrderStatus(ctx context.Context, orderID, status, note,
 		return errors.New("invalid user ID")
 	}
 
-	// Validate status
-	validStatuses := []string{"PENDING", "CONFIRMED", "PROCESSING", "SHIPPING", "DELIVERED", "CANCELLED"}
-	isValid := false
-	for _, s := range validStatuses {
-		if s == status {
-			isValid = true
-			break
+	return s.TransitionOrder(ctx, oid, models.OrderStatus(status), ActorStaff, userID, note)
+}
+
+// CancelOrder lets the owner cancel an order that is still pending and unpaid.
+// Stock is restored and pending payments are voided by the cancel transition.
+func (s *Service) CancelOrder(ctx context.Context, userID, orderID, reason string) error {
+	uid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return errors.New("invalid user ID")
+	}
+
+	oid, err := primitive.ObjectIDFromHex(orderID)
+	if err != nil {
+		return errors.New("invalid order ID")
+	}
+
+	order, err := s.repo.FindOrderByID(ctx, oid)
+	if err != nil || order.UserID != uid {
+		return errors.New("order not found")
+	}
+
+	paid, err := s.repo.HasCompletedPayment(ctx, oid)
+	if err != nil {
+		return err
+	}
+	if paid {
+		return ErrOrderAlreadyPaid
+	}
+
+	return s.TransitionOrder(ctx, oid, models.OrderStatusCanceled, ActorCustomer, uid, reason)
+}
+
+// unpaidOrderBatch is how many expired orders CancelUnpaidOrders handles
+// per call
+const unpaidOrderBatch = 100
+
+// CancelUnpaidOrders cancels pending orders paid with one of methods that
+// are still unpaid ttl after being placed. Stock, vouchers and pending
+// payments are released by the cancel transition. It returns how many orders
+// were canceled.
+func (s *Service) CancelUnpaidOrders(ctx context.Context, methods []string, ttl time.Duration) (int, error) {
+	orders, err := s.repo.FindUnpaidOrders(ctx, methods, time.Now().Add(-ttl), unpaidOrderBatch)
+	if err != nil {
+		return 0, err
+	}
+
+	note := fmt.Sprintf("Canceled automatically: not paid within %s", ttl)
+	canceled := 0
+	for _, order := range orders {
+		paid, err := s.repo.HasCompletedPayment(ctx, order.ID)
+		if err != nil {
+			return canceled, err
 		}
+		if paid {
+			continue
+		}
+
+		err = s.TransitionOrder(ctx, order.ID, models.OrderStatusCanceled, ActorSystem, primitive.NilObjectID, note)
+		var transitionErr *TransitionError
+		if errors.As(err, &transitionErr) {
+			continue // Paid or canceled in the meantime
+		} else if err != nil {
+			return canceled, err
+		}
+		canceled++
 	}
-	if !isValid {
+
+	return canceled, nil
+}
+
+// TransitionOrder moves an order to a new status if the state machine allows
+// it for the given actor. The status change, its side effects and the
+// history entry are written in a single transaction.
+func (s *Service) TransitionOrder(ctx context.Context, orderID primitive.ObjectID, to models.OrderStatus, actor Actor, actorID primitive.ObjectID, note string) error {
+	if !IsValidStatus(to) {
 		return errors.New("invalid order status")
 	}
 
-	// Update order status
-	if err := s.repo.UpdateOrderStatus(ctx, oid, status); err != nil {
+	order, err := s.repo.FindOrderByID(ctx, orderID)
+	if err != nil {
+		return errors.New("order not found")
+	}
+
+	transition, err := findTransition(order.Status, to, actor)
+	if err != nil {
 		return err
 	}
 
-	// Log status history
-	history := &models.OrderStatusHistory{
-		ID:        primitive.NewObjectID(),
-		OrderID:   oid,
-		Status:    status,
-		Note:      note,
-		UpdatedBy: userID,
-		CreatedAt: time.Now(),
+	return s.repo.WithTransaction(ctx, func(sessCtx mongo.SessionContext) error {
+		return s.applyTransition(sessCtx, order, transition, actor, actorID, note)
+	})
+}
+
+// applyTransition moves the order, runs the transition effects and records
+// history. It must run inside a transaction.
+func (s *Service) applyTransition(sessCtx mongo.SessionContext, order *models.Order, transition *Transition, actor Actor, actorID primitive.ObjectID, note string) error {
+	updated, err := s.repo.UpdateOrderStatus(sessCtx, order.ID, transition.From, transition.To)
+	if err != nil {
+		return err
+	}
+	if !updated {
+		// Someone else moved the order first
+		current, err := s.repo.FindOrderByID(sessCtx, order.ID)
+		if err != nil {
+			return err
+		}
+		return &TransitionError{From: current.Status, To: transition.To, Actor: actor}
+	}
+
+	for _, effect := range transition.Effects {
+		if err := effect(sessCtx, s.repo, order); err != nil {
+			return err
+		}
 	}
 
-	return s.repo.CreateStatusHistory(ctx, history)
+	return s.repo.CreateStatusHistory(sessCtx, &models.OrderStatusHistory{
+		ID:         primitive.NewObjectID(),
+		OrderID:    order.ID,
+		FromStatus: string(transition.From),
+		Status:     string(transition.To),
+		Note:       note,
+		Actor:      string(actor),
+		UpdatedBy:  actorID,
+		CreatedAt:  time.Now(),
+	})
+}
+
+// MarkOrderPaid moves a pending order to PAID inside the caller's
+// transaction once its payment has been captured. Orders that are already
+// paid or further along are left alone. For a canceled order it notes the
+// capture in the history and returns true: the money has to go back.
+func (s *Service) MarkOrderPaid(sessCtx mongo.SessionContext, orderID primitive.ObjectID, note string) (bool, error) {
+	order, err := s.repo.FindOrderByID(sessCtx, orderID)
+	if err != nil {
+		return false, errors.New("order not found")
+	}
+
+	if order.Status == models.OrderStatusCanceled {
+		log.Printf("Warning: payment captured for canceled order %s, refund required", order.OrderNumber)
+		return true, s.repo.CreateStatusHistory(sessCtx, &models.OrderStatusHistory{
+			ID:         primitive.NewObjectID(),
+			OrderID:    order.ID,
+			FromStatus: string(order.Status),
+			Status:     string(order.Status),
+			Note:       note + " after the order was canceled; refund pending",
+			Actor:      string(ActorSystem),
+			CreatedAt:  time.Now(),
+		})
+	}
+	if order.Status != models.OrderStatusPending {
+		return false, nil
+	}
+
+	transition, err := findTransition(order.Status, models.OrderStatusPaid, ActorSystem)
+	if err != nil {
+		return false, err
+	}
+	return false, s.applyTransition(sessCtx, order, transition, ActorSystem, primitive.NilObjectID, note)
 }
 
 // GetOrderStatusHistory retrieves order status history
@@ -286,10 +600,12 @@ func (s *Service) GetOrderStatusHistory(ctx context.Context, orderID string) ([]
 	var response []StatusHistoryResponse
 	for _, h := range history {
 		response = append(response, StatusHistoryResponse{
-			Status:    h.Status,
-			Note:      h.Note,
-			UpdatedBy: h.UpdatedBy.Hex(),
-			CreatedAt: h.CreatedAt.Format(time.RFC3339),
+			FromStatus: h.FromStatus,
+			Status:     h.Status,
+			Note:       h.Note,
+			Actor:      h.Actor,
+			UpdatedBy:  h.UpdatedBy.Hex(),
+			CreatedAt:  h.CreatedAt.Format(time.RFC3339),
 		})
 	}
 
@@ -325,13 +641,9 @@ func (s *Service) GetAllOrders(ctx context.Context, page, limit int, status stri
 		return nil, err
 	}
 
-	var orderResponses []*OrderResponse
-	for _, order := range orders {
-		items, err := s.repo.FindOrderItemsByOrderID(ctx, order.ID)
-		if err != nil {
-			continue
-		}
-		orderResponses = append(orderResponses, s.transformOrder(order, items))
+	orderResponses, err := s.transformOrders(ctx, orders)
+	if err != nil {
+		return nil, err
 	}
 
 	totalPages := int((total + int64(limit) - 1) / int64(limit))
@@ -0,0 +1,278 @@
+package orders
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"phone-store-backend/internal/models"
+	"phone-store-backend/internal/modules/shipping"
+	"phone-store-backend/internal/pricing"
+	"phone-store-backend/internal/testdb"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// checkoutFixture is a catalog with one variant, a shipping method and COD
+type checkoutFixture struct {
+	db             *mongo.Database
+	service        *Service
+	variantID      primitive.ObjectID
+	shippingMethod primitive.ObjectID
+}
+
+func newCheckoutFixture(t *testing.T, stock int) *checkoutFixture {
+	t.Helper()
+	database := testdb.Open(t)
+	ctx := context.Background()
+	now := time.Now()
+
+	product := &models.Product{ID: primitive.NewObjectID(), Name: "iPhone 15", Slug: "iphone-15", IsActive: true, CreatedAt: now, UpdatedAt: now}
+	variant := &models.ProductVariant{ID: primitive.NewObjectID(), ProductID: product.ID, SKU: "IP15-128-BLK", Price: 20000000, Stock: stock, IsActive: true, CreatedAt: now, UpdatedAt: now}
+	method := &models.ShippingMethod{ID: primitive.NewObjectID(), Name: "Standard", Cost: 30000, EstDays: 3, IsActive: true, CreatedAt: now, UpdatedAt: now}
+	cod := &models.PaymentMethod{ID: primitive.NewObjectID(), Name: "Cash on delivery", Code: models.PaymentMethodCOD, IsActive: true, CreatedAt: now, UpdatedAt: now}
+
+	for collection, doc := range map[string]interface{}{
+		"products":         product,
+		"product_variants": variant,
+		"shipping_methods": method,
+		"payment_methods":  cod,
+	} {
+		if _, err := database.Collection(collection).InsertOne(ctx, doc); err != nil {
+			t.Fatalf("seed %s: %v", collection, err)
+		}
+	}
+
+	service := NewService(
+		NewRepository(database),
+		pricing.NewService(pricing.NewRepository(database)),
+		shipping.NewRepository(database),
+		[]string{models.PaymentMethodCOD},
+	)
+	return &checkoutFixture{db: database, service: service, variantID: variant.ID, shippingMethod: method.ID}
+}
+
+// addCustomer creates a customer whose cart holds quantity of the variant
+func (f *checkoutFixture) addCustomer(t *testing.T, quantity int) primitive.ObjectID {
+	t.Helper()
+	userID := primitive.NewObjectID()
+	_, err := f.db.Collection("carts").InsertOne(context.Background(), &models.Cart{
+		ID:        primitive.NewObjectID(),
+		UserID:    userID,
+		Items:     []models.CartItem{{VariantID: f.variantID, Quantity: quantity}},
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	})
+	if err != nil {
+		t.Fatalf("seed cart: %v", err)
+	}
+	return userID
+}
+
+func (f *checkoutFixture) checkout(userID primitive.ObjectID) (*OrderResponse, error) {
+	return f.service.CreateOrder(context.Background(), userID.Hex(), &CreateOrderRequest{
+		ShippingAddress: &ShippingAddressRequest{
+			FullName: "Nguyen Van A",
+			Phone:    "0901234567",
+			Address:  "1 Le Loi",
+			City:     "Ho Chi Minh",
+			District: "District 1",
+			Ward:     "Ben Nghe",
+		},
+		ShippingMethodID:  f.shippingMethod.Hex(),
+		PaymentMethodCode: models.PaymentMethodCOD,
+	})
+}
+
+func TestCreateOrderConcurrentCheckoutsNeverOversell(t *testing.T) {
+	const stock, customers = 3, 20
+	f := newCheckoutFixture(t, stock)
+	ctx := context.Background()
+
+	userIDs := make([]primitive.ObjectID, customers)
+	for i := range userIDs {
+		userIDs[i] = f.addCustomer(t, 1)
+	}
+
+	var (
+		wg         sync.WaitGroup
+		mu         sync.Mutex
+		placed     int
+		soldOut    int
+		unexpected []error
+	)
+	start := make(chan struct{})
+	for _, userID := range userIDs {
+		wg.Add(1)
+		go func(userID primitive.ObjectID) {
+			defer wg.Done()
+			<-start
+			_, err := f.checkout(userID)
+
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				placed++
+			case errors.Is(err, ErrInsufficientStock):
+				soldOut++
+			default:
+				unexpected = append(unexpected, err)
+			}
+		}(userID)
+	}
+	close(start)
+	wg.Wait()
+
+	for _, err := range unexpected {
+		t.Errorf("unexpected checkout error: %v", err)
+	}
+	if placed != stock {
+		t.Errorf("placed %d orders, want %d", placed, stock)
+	}
+	if placed+soldOut != customers {
+		t.Errorf("%d checkouts placed and %d sold out, want %d in total", placed, soldOut, customers)
+	}
+
+	var variant models.ProductVariant
+	if err := f.db.Collection("product_variants").FindOne(ctx, bson.M{"_id": f.variantID}).Decode(&variant); err != nil {
+		t.Fatalf("load variant: %v", err)
+	}
+	if variant.Stock != 0 {
+		t.Errorf("stock = %d, want 0", variant.Stock)
+	}
+
+	// Rolled back checkouts leave nothing behind
+	orders, err := f.db.Collection("orders").CountDocuments(ctx, bson.M{})
+	if err != nil {
+		t.Fatalf("count orders: %v", err)
+	}
+	if orders != int64(placed) {
+		t.Errorf("%d orders saved, want %d", orders, placed)
+	}
+	items, err := f.db.Collection("order_items").CountDocuments(ctx, bson.M{})
+	if err != nil {
+		t.Fatalf("count order items: %v", err)
+	}
+	if items != int64(placed) {
+		t.Errorf("%d order items saved, want %d", items, placed)
+	}
+}
+
+func TestCreateOrderRejectsPaymentMethodsWithoutProvider(t *testing.T) {
+	f := newCheckoutFixture(t, 1)
+	ctx := context.Background()
+	userID := f.addCustomer(t, 1)
+
+	// Seeded and active, but the server has no MoMo credentials
+	momo := &models.PaymentMethod{ID: primitive.NewObjectID(), Name: "MoMo", Code: "MOMO", IsActive: true}
+	if _, err := f.db.Collection("payment_methods").InsertOne(ctx, momo); err != nil {
+		t.Fatalf("seed payment method: %v", err)
+	}
+
+	_, err := f.service.CreateOrder(ctx, userID.Hex(), &CreateOrderRequest{
+		ShippingAddress: &ShippingAddressRequest{
+			FullName: "Nguyen Van A",
+			Phone:    "0901234567",
+			Address:  "1 Le Loi",
+			City:     "Ho Chi Minh",
+			District: "District 1",
+			Ward:     "Ben Nghe",
+		},
+		ShippingMethodID:  f.shippingMethod.Hex(),
+		PaymentMethodCode: "MOMO",
+	})
+	if !errors.Is(err, ErrPaymentMethodNotFound) {
+		t.Errorf("err = %v, want ErrPaymentMethodNotFound", err)
+	}
+}
+
+func TestCompletingCODOrderRecordsDeliveryAndCapturesPayment(t *testing.T) {
+	f := newCheckoutFixture(t, 1)
+	ctx := context.Background()
+	userID := f.addCustomer(t, 1)
+
+	placed, err := f.checkout(userID)
+	if err != nil {
+		t.Fatalf("checkout: %v", err)
+	}
+	orderID, _ := primitive.ObjectIDFromHex(placed.ID)
+	staffID := primitive.NewObjectID()
+
+	for _, status := range []models.OrderStatus{models.OrderStatusShipping, models.OrderStatusCompleted} {
+		if err := f.service.TransitionOrder(ctx, orderID, status, ActorStaff, staffID, ""); err != nil {
+			t.Fatalf("move order to %s: %v", status, err)
+		}
+	}
+
+	var order models.Order
+	if err := f.db.Collection("orders").FindOne(ctx, bson.M{"_id": orderID}).Decode(&order); err != nil {
+		t.Fatalf("load order: %v", err)
+	}
+	if order.DeliveredAt == nil {
+		t.Error("deliveredAt not set on the completed order")
+	}
+
+	var payment models.Payment
+	if err := f.db.Collection("payments").FindOne(ctx, bson.M{"orderId": orderID}).Decode(&payment); err != nil {
+		t.Fatalf("load payment: %v", err)
+	}
+	if payment.Status != models.PaymentStatusCompleted || payment.PaidAt == nil {
+		t.Errorf("COD payment is %s, want %s with paidAt", payment.Status, models.PaymentStatusCompleted)
+	}
+}
+
+func TestCancelingPaidOrderQueuesRefundOfCapturedPayment(t *testing.T) {
+	f := newCheckoutFixture(t, 1)
+	ctx := context.Background()
+	userID := f.addCustomer(t, 1)
+
+	placed, err := f.checkout(userID)
+	if err != nil {
+		t.Fatalf("checkout: %v", err)
+	}
+	orderID, _ := primitive.ObjectIDFromHex(placed.ID)
+	staffID := primitive.NewObjectID()
+
+	// The payment opened at checkout is captured, then staff cancel
+	if _, err := f.db.Collection("payments").UpdateOne(ctx, bson.M{"orderId": orderID}, bson.M{"$set": bson.M{"status": models.PaymentStatusCompleted}}); err != nil {
+		t.Fatalf("capture payment: %v", err)
+	}
+	for _, status := range []models.OrderStatus{models.OrderStatusPaid, models.OrderStatusCanceled} {
+		if err := f.service.TransitionOrder(ctx, orderID, status, ActorStaff, staffID, ""); err != nil {
+			t.Fatalf("move order to %s: %v", status, err)
+		}
+	}
+
+	var payment models.Payment
+	if err := f.db.Collection("payments").FindOne(ctx, bson.M{"orderId": orderID}).Decode(&payment); err != nil {
+		t.Fatalf("load payment: %v", err)
+	}
+	cursor, err := f.db.Collection("refunds").Find(ctx, bson.M{"orderId": orderID})
+	if err != nil {
+		t.Fatalf("load refunds: %v", err)
+	}
+	var refunds []models.Refund
+	if err := cursor.All(ctx, &refunds); err != nil {
+		t.Fatalf("load refunds: %v", err)
+	}
+
+	if len(refunds) != 1 || refunds[0].Status != models.RefundStatusPending || refunds[0].Amount != payment.Amount || refunds[0].PaymentID != payment.ID {
+		t.Errorf("refunds = %+v, want one pending refund of %.0f for the payment", refunds, payment.Amount)
+	}
+	if payment.Refunded != payment.Amount {
+		t.Errorf("payment has %.0f reserved for refunds, want %.0f", payment.Refunded, payment.Amount)
+	}
+
+	var variant models.ProductVariant
+	if err := f.db.Collection("product_variants").FindOne(ctx, bson.M{"_id": f.variantID}).Decode(&variant); err != nil {
+		t.Fatalf("load variant: %v", err)
+	}
+	if variant.Stock != 1 {
+		t.Errorf("stock = %d after the cancellation, want 1", variant.Stock)
+	}
+}
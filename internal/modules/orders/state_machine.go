@@ -0,0 +1,156 @@
+package orders
+
+import (
+	"fmt"
+	"time"
+
+	"phone-store-backend/internal/models"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Actor identifies who triggers an order status transition
+type Actor string
+
+const (
+	ActorCustomer Actor = "CUSTOMER"
+	ActorStaff    Actor = "STAFF"
+	ActorSystem   Actor = "SYSTEM"
+)
+
+// Effect is a side effect run inside the same transaction as the status change
+type Effect func(sessCtx mongo.SessionContext, repo *Repository, order *models.Order) error
+
+// Transition describes one allowed move between two order statuses
+type Transition struct {
+	From    models.OrderStatus
+	To      models.OrderStatus
+	Actors  []Actor
+	Effects []Effect
+}
+
+// transitions is the single source of truth for the order lifecycle.
+// Any move not listed here is rejected.
+var transitions = []Transition{
+	{
+		From:   models.OrderStatusPending,
+		To:     models.OrderStatusPaid,
+		Actors: []Actor{ActorSystem, ActorStaff},
+	},
+	{
+		// COD orders ship before they are paid
+		From:   models.OrderStatusPending,
+		To:     models.OrderStatusShipping,
+		Actors: []Actor{ActorStaff},
+	},
+	{
+		From:   models.OrderStatusPaid,
+		To:     models.OrderStatusShipping,
+		Actors: []Actor{ActorStaff},
+	},
+	{
+		// Completed means delivered: returns open from here, and COD
+		// money was collected by the courier
+		From:    models.OrderStatusShipping,
+		To:      models.OrderStatusCompleted,
+		Actors:  []Actor{ActorStaff, ActorSystem},
+		Effects: []Effect{markDelivered, captureCODPayment},
+	},
+	{
+		From:    models.OrderStatusPending,
+		To:      models.OrderStatusCanceled,
+		Actors:  []Actor{ActorCustomer, ActorStaff, ActorSystem},
+		Effects: []Effect{restockItems, voidPendingPayments, releaseVoucher},
+	},
+	{
+		From:    models.OrderStatusPaid,
+		To:      models.OrderStatusCanceled,
+		Actors:  []Actor{ActorStaff},
+		Effects: []Effect{restockItems, releaseVoucher, refundPayments},
+	},
+}
+
+// TransitionError is returned when a status change is not allowed by the
+// state machine, either because the move does not exist or because the
+// actor may not trigger it
+type TransitionError struct {
+	From  models.OrderStatus
+	To    models.OrderStatus
+	Actor Actor
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("cannot change order status from %s to %s as %s", e.From, e.To, e.Actor)
+}
+
+// IsValidStatus reports whether status is a known order status
+func IsValidStatus(status models.OrderStatus) bool {
+	switch status {
+	case models.OrderStatusPending,
+		models.OrderStatusPaid,
+		models.OrderStatusShipping,
+		models.OrderStatusCompleted,
+		models.OrderStatusCanceled:
+		return true
+	}
+	return false
+}
+
+// findTransition returns the transition from -> to that actor may trigger
+func findTransition(from, to models.OrderStatus, actor Actor) (*Transition, error) {
+	for i := range transitions {
+		t := &transitions[i]
+		if t.From != from || t.To != to {
+			continue
+		}
+		for _, a := range t.Actors {
+			if a == actor {
+				return t, nil
+			}
+		}
+		break
+	}
+	return nil, &TransitionError{From: from, To: to, Actor: actor}
+}
+
+// restockItems returns every ordered quantity to its variant
+func restockItems(sessCtx mongo.SessionContext, repo *Repository, order *models.Order) error {
+	items, err := repo.FindOrderItemsByOrderID(sessCtx, order.ID)
+	if err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		if err := repo.IncreaseStock(sessCtx, item.VariantID, item.Quantity); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// voidPendingPayments voids payments that were started but never completed
+func voidPendingPayments(sessCtx mongo.SessionContext, repo *Repository, order *models.Order) error {
+	return repo.VoidPendingPayments(sessCtx, order.ID)
+}
+
+// markDelivered records when the order was delivered, which starts the
+// return window
+func markDelivered(sessCtx mongo.SessionContext, repo *Repository, order *models.Order) error {
+	return repo.SetDeliveredAt(sessCtx, order.ID, time.Now())
+}
+
+// captureCODPayment completes the cash on delivery payment, so that it can
+// be refunded like any other captured payment
+func captureCODPayment(sessCtx mongo.SessionContext, repo *Repository, order *models.Order) error {
+	return repo.CaptureCODPayment(sessCtx, order.ID)
+}
+
+// refundPayments queues the money captured for a canceled order for refund
+func refundPayments(sessCtx mongo.SessionContext, repo *Repository, order *models.Order) error {
+	return repo.QueueRefunds(sessCtx, order.ID, "Order canceled after payment")
+}
+
+// releaseVoucher gives the voucher use back so the code can be used again
+func releaseVoucher(sessCtx mongo.SessionContext, repo *Repository, order *models.Order) error {
+	return repo.ReleaseRedemption(sessCtx, order.ID)
+}
@@ -0,0 +1,111 @@
+package orders
+
+import (
+	"errors"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+
+	"phone-store-backend/internal/models"
+)
+
+func TestFindTransition(t *testing.T) {
+	const (
+		pending   = models.OrderStatusPending
+		paid      = models.OrderStatusPaid
+		shipping  = models.OrderStatusShipping
+		completed = models.OrderStatusCompleted
+		canceled  = models.OrderStatusCanceled
+	)
+	type move struct {
+		from, to models.OrderStatus
+		actor    Actor
+	}
+
+	// Every move the lifecycle allows; anything else must be rejected
+	allowed := map[move]bool{
+		{pending, paid, ActorSystem}:       true,
+		{pending, paid, ActorStaff}:        true,
+		{pending, shipping, ActorStaff}:    true,
+		{paid, shipping, ActorStaff}:       true,
+		{shipping, completed, ActorStaff}:  true,
+		{shipping, completed, ActorSystem}: true,
+		{pending, canceled, ActorCustomer}: true,
+		{pending, canceled, ActorStaff}:    true,
+		{pending, canceled, ActorSystem}:   true,
+		{paid, canceled, ActorStaff}:       true,
+	}
+
+	statuses := []models.OrderStatus{pending, paid, shipping, completed, canceled}
+	for _, from := range statuses {
+		for _, to := range statuses {
+			for _, actor := range []Actor{ActorCustomer, ActorStaff, ActorSystem} {
+				tr, err := findTransition(from, to, actor)
+				if allowed[move{from, to, actor}] {
+					if err != nil || tr.From != from || tr.To != to {
+						t.Errorf("%s -> %s as %s: got %v, %v; want allowed", from, to, actor, tr, err)
+					}
+					continue
+				}
+
+				var transitionErr *TransitionError
+				if !errors.As(err, &transitionErr) {
+					t.Errorf("%s -> %s as %s: got %v, want a TransitionError", from, to, actor, err)
+					continue
+				}
+				if *transitionErr != (TransitionError{From: from, To: to, Actor: actor}) {
+					t.Errorf("%s -> %s as %s: error describes %+v", from, to, actor, *transitionErr)
+				}
+			}
+		}
+	}
+}
+
+// Each move must be listed once, or findTransition would only ever see the
+// first entry's actors and effects
+func TestTransitionsListEachMoveOnce(t *testing.T) {
+	seen := map[[2]models.OrderStatus]bool{}
+	for _, tr := range transitions {
+		key := [2]models.OrderStatus{tr.From, tr.To}
+		if seen[key] {
+			t.Errorf("%s -> %s is listed twice", tr.From, tr.To)
+		}
+		seen[key] = true
+		if !IsValidStatus(tr.From) || !IsValidStatus(tr.To) {
+			t.Errorf("%s -> %s uses an unknown status", tr.From, tr.To)
+		}
+	}
+}
+
+func TestTransitionEffects(t *testing.T) {
+	name := func(effect Effect) string {
+		return runtime.FuncForPC(reflect.ValueOf(effect).Pointer()).Name()
+	}
+	tests := []struct {
+		from, to models.OrderStatus
+		effects  []Effect
+	}{
+		{models.OrderStatusPending, models.OrderStatusPaid, nil},
+		{models.OrderStatusShipping, models.OrderStatusCompleted, []Effect{markDelivered, captureCODPayment}},
+		{models.OrderStatusPending, models.OrderStatusCanceled, []Effect{restockItems, voidPendingPayments, releaseVoucher}},
+		{models.OrderStatusPaid, models.OrderStatusCanceled, []Effect{restockItems, releaseVoucher, refundPayments}},
+	}
+	for _, tt := range tests {
+		tr, err := findTransition(tt.from, tt.to, ActorStaff)
+		if err != nil {
+			t.Fatalf("%s -> %s: %v", tt.from, tt.to, err)
+		}
+
+		var got, want []string
+		for _, effect := range tr.Effects {
+			got = append(got, name(effect))
+		}
+		for _, effect := range tt.effects {
+			want = append(want, name(effect))
+		}
+		if strings.Join(got, ",") != strings.Join(want, ",") {
+			t.Errorf("%s -> %s runs %v, want %v", tt.from, tt.to, got, want)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package payments_test
+
+import (
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+
+	"phone-store-backend/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// callback delivers a successful stub gateway notification for payment
+func (f *paymentFixture) callback(t *testing.T, payment *models.Payment, transactionID string) {
+	t.Helper()
+	r := httptest.NewRequest("GET", fmt.Sprintf("/api/payments/callback/%s?ref=%s&txn=%s&amount=%.0f",
+		stubMethod, payment.ID.Hex(), transactionID, payment.Amount), nil)
+	if err := f.service.HandleCallback(context.Background(), stubMethod, r); err != nil {
+		t.Fatalf("callback %s: %v", transactionID, err)
+	}
+}
+
+func (f *paymentFixture) orderStatus(t *testing.T, orderID primitive.ObjectID) models.OrderStatus {
+	t.Helper()
+	var order models.Order
+	if err := f.db.Collection("orders").FindOne(context.Background(), bson.M{"_id": orderID}).Decode(&order); err != nil {
+		t.Fatalf("load order: %v", err)
+	}
+	return order.Status
+}
+
+func (f *paymentFixture) refunds(t *testing.T, orderID primitive.ObjectID) []models.Refund {
+	t.Helper()
+	cursor, err := f.db.Collection("refunds").Find(context.Background(), bson.M{"orderId": orderID})
+	if err != nil {
+		t.Fatalf("load refunds: %v", err)
+	}
+	var refunds []models.Refund
+	if err := cursor.All(context.Background(), &refunds); err != nil {
+		t.Fatalf("load refunds: %v", err)
+	}
+	return refunds
+}
+
+func TestCallbackForBalanceAfterPartialCaptureMarksOrderPaid(t *testing.T) {
+	f := newPaymentFixture(t, &stubProvider{})
+	const captured = 10000000
+	first := &models.Payment{Method: stubMethod, Amount: captured, Status: models.PaymentStatusPending}
+	order := f.addOrder(t, models.OrderStatusPending, first)
+
+	f.callback(t, first, "T-1")
+	if status := f.orderStatus(t, order.ID); status != models.OrderStatusPending {
+		t.Fatalf("order is %s after a partial capture, want %s", status, models.OrderStatusPending)
+	}
+
+	// The balance is paid with a second payment
+	balance := &models.Payment{ID: primitive.NewObjectID(), OrderID: order.ID, Method: stubMethod, Amount: orderTotal - captured, Status: models.PaymentStatusPending}
+	if _, err := f.db.Collection("payments").InsertOne(context.Background(), balance); err != nil {
+		t.Fatalf("seed payment: %v", err)
+	}
+	f.callback(t, balance, "T-2")
+
+	if status := f.orderStatus(t, order.ID); status != models.OrderStatusPaid {
+		t.Errorf("order is %s after the balance was paid, want %s", status, models.OrderStatusPaid)
+	}
+	if refunds := f.refunds(t, order.ID); len(refunds) != 0 {
+		t.Errorf("%d refunds queued for an order paid exactly once, want 0", len(refunds))
+	}
+}
+
+func TestCallbackForFullyPaidOrderQueuesRefund(t *testing.T) {
+	f := newPaymentFixture(t, &stubProvider{})
+	paid := &models.Payment{Method: stubMethod, Amount: orderTotal, Status: models.PaymentStatusPending}
+	late := &models.Payment{Method: stubMethod, Amount: orderTotal, Status: models.PaymentStatusVoided}
+	order := f.addOrder(t, models.OrderStatusPending, paid, late)
+
+	f.callback(t, paid, "T-1")
+	f.callback(t, late, "T-2")
+
+	if status := f.orderStatus(t, order.ID); status != models.OrderStatusPaid {
+		t.Errorf("order is %s, want %s", status, models.OrderStatusPaid)
+	}
+	refunds := f.refunds(t, order.ID)
+	if len(refunds) != 1 || refunds[0].PaymentID != late.ID || refunds[0].Amount != orderTotal {
+		t.Errorf("refunds = %+v, want one of %d for the late payment", refunds, orderTotal)
+	}
+}
@@ -0,0 +1,46 @@
+package payments
+
+import "testing"
+
+func TestClassifyCapture(t *testing.T) {
+	const total = 30000000
+	tests := []struct {
+		name                   string
+		capturedBefore, amount float64
+		want                   captureOutcome
+	}{
+		{"whole total at once", 0, total, capturePaidInFull},
+		{"first part", 0, 10000000, capturePartial},
+		{"balance after a part", 10000000, 20000000, capturePaidInFull},
+		{"another part", 10000000, 10000000, capturePartial},
+		{"more than the balance", 10000000, total, capturePaidInFull},
+		{"already paid in full", total, total, captureDuplicate},
+		{"already overpaid", total + 1, 1, captureDuplicate},
+		{"rounding to the total", 0, total - 0.4, capturePaidInFull},
+	}
+	for _, tt := range tests {
+		if got := classifyCapture(total, tt.capturedBefore, tt.amount); got != tt.want {
+			t.Errorf("%s: classifyCapture(%d, %.1f, %.1f) = %d, want %d", tt.name, total, tt.capturedBefore, tt.amount, got, tt.want)
+		}
+	}
+}
+
+func TestAmountDue(t *testing.T) {
+	tests := []struct {
+		name            string
+		total, captured float64
+		want            float64
+	}{
+		{"nothing captured", 30000000, 0, 30000000},
+		{"part captured", 30000000, 10000000, 20000000},
+		{"paid in full", 30000000, 30000000, 0},
+		{"overpaid", 30000000, 30000001, -1},
+		{"float leftover", 0.1 + 0.2, 0.3, 0},
+		{"odd total", 29999999.6, 9999999.6, 20000000},
+	}
+	for _, tt := range tests {
+		if got := amountDue(tt.total, tt.captured); got != tt.want {
+			t.Errorf("%s: amountDue(%v, %v) = %v, want %v", tt.name, tt.total, tt.captured, got, tt.want)
+		}
+	}
+}
@@ -4,17 +4,21 @@ import "time"
 
 // PaymentMethodResponse DTO for payment method response
 type PaymentMethodResponse struct {
-	ID          string `json:"id"`
-	Name        string `json:"name"`
-	Code        string `json:"code"`
-	Description string `json:"description"`
+	ID                string   `json:"id"`
+	Name              string   `json:"name"`
+	Code              string   `json:"code"`
+	Description       string   `json:"description"`
+	MaxOrderValue     float64  `json:"maxOrderValue,omitempty"`
+	DisabledProvinces []string `json:"disabledProvinces,omitempty"`
 }
 
-// CreatePaymentRequest DTO for creating a payment
+// CreatePaymentRequest DTO for creating a payment. The amount is derived from
+// the order; when Amount is sent it must match the outstanding balance. The
+// method defaults to the one chosen at checkout.
 type CreatePaymentRequest struct {
 	OrderID           string  `json:"orderId" binding:"required"`
-	PaymentMethodCode string  `json:"paymentMethodCode" binding:"required"`
-	Amount            float64 `json:"amount" binding:"required"`
+	PaymentMethodCode string  `json:"paymentMethodCode"`
+	Amount            float64 `json:"amount" binding:"omitempty,gt=0"`
 }
 
 // PaymentResponse DTO for payment response
@@ -22,8 +26,77 @@ type PaymentResponse struct {
 	ID            string    `json:"id"`
 	OrderID       string    `json:"orderId"`
 	PaymentMethod string    `json:"paymentMethod"`
+	MethodCode    string    `json:"methodCode"`
 	Amount        float64   `json:"amount"`
+	Refunded      float64   `json:"refunded"`
 	Status        string    `json:"status"`
 	PaidAt        time.Time `json:"paidAt,omitempty"`
 	CreatedAt     time.Time `json:"createdAt"`
 }
+
+// CreatePaymentResponse DTO returned when checkout starts. CheckoutURL is
+// where the client should redirect for online methods; QRPayload is the
+// VietQR string for bank transfers (also served as PNG). Both empty for COD.
+type CreatePaymentResponse struct {
+	Payment     PaymentResponse `json:"payment"`
+	CheckoutURL string          `json:"checkoutUrl,omitempty"`
+	QRPayload   string          `json:"qrPayload,omitempty"`
+}
+
+// CreateRefundRequest DTO for issuing a refund. Amount 0 refunds whatever
+// is left of the captured amount.
+type CreateRefundRequest struct {
+	OrderID   string  `json:"orderId" binding:"required"`
+	PaymentID string  `json:"paymentId"`
+	Amount    float64 `json:"amount" binding:"min=0"`
+	Reason    string  `json:"reason" binding:"required"`
+}
+
+// UpdateRefundStatusRequest DTO for settling a pending refund
+type UpdateRefundStatusRequest struct {
+	Status string `json:"status" binding:"required,oneof=COMPLETED FAILED"`
+}
+
+// RefundResponse DTO for refund response
+type RefundResponse struct {
+	ID              string    `json:"id"`
+	OrderID         string    `json:"orderId"`
+	PaymentID       string    `json:"paymentId"`
+	ReturnRequestID string    `json:"returnRequestId,omitempty"`
+	Amount          float64   `json:"amount"`
+	Status          string    `json:"status"`
+	Reason          string    `json:"reason"`
+	CreatedBy       string    `json:"createdBy"`
+	CreatedAt       time.Time `json:"createdAt"`
+}
+
+// RefundsListResponse DTO for paginated refunds list
+type RefundsListResponse struct {
+	Data       []RefundResponse `json:"data"`
+	Page       int              `json:"page"`
+	Limit      int              `json:"limit"`
+	Total      int64            `json:"total"`
+	TotalPages int              `json:"totalPages"`
+}
+
+// ReconcileLine DTO reports what happened to one bank statement line
+type ReconcileLine struct {
+	Line        int     `json:"line"`
+	Date        string  `json:"date"`
+	Amount      float64 `json:"amount"`
+	Description string  `json:"description"`
+	Reference   string  `json:"reference"`
+	Status      string  `json:"status"` // MATCHED, ALREADY_PROCESSED, PARTIAL, OVERPAID, UNMATCHED
+	OrderNumber string  `json:"orderNumber,omitempty"`
+	PaymentID   string  `json:"paymentId,omitempty"`
+	Expected    float64 `json:"expected,omitempty"`
+}
+
+// ReconcileReport DTO summarizes a bank statement import
+type ReconcileReport struct {
+	TotalLines       int             `json:"totalLines"`
+	Matched          int             `json:"matched"`
+	AlreadyProcessed int             `json:"alreadyProcessed"`
+	NeedsReview      int             `json:"needsReview"` // Partial, overpaid or unmatched
+	Lines            []ReconcileLine `json:"lines"`
+}
@@ -0,0 +1,348 @@
+// Package fakegateway is a local stand-in for the VNPay and MoMo sandboxes.
+// It signs and checks messages like the real gateways and approves every
+// payment, so the online payment flow can run offline: the fakegateway
+// command serves it on a port and the provider tests run it in httptest.
+package fakegateway
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"phone-store-backend/internal/modules/payments"
+)
+
+// momoOrder is a MoMo payment waiting for the customer to "pay"
+type momoOrder struct {
+	PartnerCode string
+	OrderID     string
+	RequestID   string
+	Amount      int64
+	OrderInfo   string
+	RedirectURL string
+	IPNURL      string
+	ExtraData   string
+}
+
+// Config holds the credentials the gateway signs with and where it sends
+// VNPay IPNs. MoMo IPNs go to the ipnUrl of each create request.
+type Config struct {
+	VNPaySecret   string
+	VNPayIPNURL   string
+	MoMoAccessKey string
+	MoMoSecret    string
+}
+
+// Gateway is an http.Handler serving the VNPay endpoints under /vnpay and
+// the MoMo endpoints under /momo
+type Gateway struct {
+	cfg    Config
+	mux    *http.ServeMux
+	client *http.Client
+	mu     sync.Mutex
+	nextTx int64
+	vnpay  map[string]url.Values // vnp_TxnRef -> paid params
+	momo   map[string]*momoOrder // orderId -> order
+	paid   map[string]int64      // MoMo orderId -> transId
+}
+
+// New returns a gateway that approves every payment
+func New(cfg Config) *Gateway {
+	g := &Gateway{
+		cfg:    cfg,
+		mux:    http.NewServeMux(),
+		client: &http.Client{Timeout: 10 * time.Second},
+		nextTx: time.Now().Unix(),
+		vnpay:  make(map[string]url.Values),
+		momo:   make(map[string]*momoOrder),
+		paid:   make(map[string]int64),
+	}
+
+	g.mux.HandleFunc("/vnpay/pay", g.vnpayPay)
+	g.mux.HandleFunc("/vnpay/api", g.vnpayAPI)
+	g.mux.HandleFunc("/momo/v2/gateway/api/create", g.momoCreate)
+	g.mux.HandleFunc("/momo/pay", g.momoPay)
+	g.mux.HandleFunc("/momo/v2/gateway/api/query", g.momoQuery)
+	g.mux.HandleFunc("/momo/v2/gateway/api/refund", g.momoRefund)
+	return g
+}
+
+func (g *Gateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	g.mux.ServeHTTP(w, r)
+}
+
+func (g *Gateway) transactionID() int64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.nextTx++
+	return g.nextTx
+}
+
+// vnpayPay plays the checkout page: it checks the signature, approves the
+// payment, sends the IPN and redirects the browser to vnp_ReturnUrl
+func (g *Gateway) vnpayPay(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	signature := query.Get("vnp_SecureHash")
+	query.Del("vnp_SecureHash")
+	query.Del("vnp_SecureHashType")
+	if signature != payments.VNPaySign(g.cfg.VNPaySecret, query.Encode()) {
+		http.Error(w, "invalid signature", http.StatusBadRequest)
+		return
+	}
+
+	result := url.Values{}
+	for _, key := range []string{"vnp_TmnCode", "vnp_Amount", "vnp_TxnRef", "vnp_OrderInfo"} {
+		result.Set(key, query.Get(key))
+	}
+	result.Set("vnp_BankCode", "NCB")
+	result.Set("vnp_CardType", "ATM")
+	result.Set("vnp_PayDate", time.Now().Format("20060102150405"))
+	result.Set("vnp_ResponseCode", "00")
+	result.Set("vnp_TransactionStatus", "00")
+	result.Set("vnp_TransactionNo", strconv.FormatInt(g.transactionID(), 10))
+	signed := result.Encode() + "&vnp_SecureHash=" + payments.VNPaySign(g.cfg.VNPaySecret, result.Encode())
+
+	g.mu.Lock()
+	g.vnpay[query.Get("vnp_TxnRef")] = result
+	g.mu.Unlock()
+
+	if resp, err := g.client.Get(g.cfg.VNPayIPNURL + "?" + signed); err != nil {
+		log.Printf("VNPay IPN failed: %v", err)
+	} else {
+		resp.Body.Close()
+		log.Printf("VNPay IPN for %s: %s", query.Get("vnp_TxnRef"), resp.Status)
+	}
+
+	http.Redirect(w, r, query.Get("vnp_ReturnUrl")+"?"+signed, http.StatusFound)
+}
+
+// vnpayAPI answers querydr and refund requests
+func (g *Gateway) vnpayAPI(w http.ResponseWriter, r *http.Request) {
+	var req map[string]string
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid body", http.StatusBadRequest)
+		return
+	}
+
+	g.mu.Lock()
+	paid, ok := g.vnpay[req["vnp_TxnRef"]]
+	g.mu.Unlock()
+
+	resp := map[string]string{
+		"vnp_ResponseId":   strconv.FormatInt(g.transactionID(), 10),
+		"vnp_Command":      req["vnp_Command"],
+		"vnp_ResponseCode": "00",
+		"vnp_Message":      "Success",
+		"vnp_TmnCode":      req["vnp_TmnCode"],
+		"vnp_TxnRef":       req["vnp_TxnRef"],
+		"vnp_BankCode":     "NCB",
+		"vnp_OrderInfo":    req["vnp_OrderInfo"],
+	}
+	if !ok {
+		resp["vnp_ResponseCode"] = "91"
+		resp["vnp_Message"] = "Transaction not found"
+	} else {
+		resp["vnp_Amount"] = paid.Get("vnp_Amount")
+		resp["vnp_PayDate"] = paid.Get("vnp_PayDate")
+		resp["vnp_TransactionNo"] = paid.Get("vnp_TransactionNo")
+		resp["vnp_TransactionStatus"] = "00"
+		resp["vnp_TransactionType"] = "01"
+	}
+	if req["vnp_Command"] == "refund" && ok {
+		resp["vnp_Amount"] = req["vnp_Amount"]
+		resp["vnp_TransactionType"] = req["vnp_TransactionType"]
+	}
+
+	keys := []string{
+		"vnp_ResponseId", "vnp_Command", "vnp_ResponseCode", "vnp_Message", "vnp_TmnCode",
+		"vnp_TxnRef", "vnp_Amount", "vnp_BankCode", "vnp_PayDate", "vnp_TransactionNo",
+		"vnp_TransactionType", "vnp_TransactionStatus", "vnp_OrderInfo",
+	}
+	if req["vnp_Command"] != "refund" {
+		keys = append(keys, "vnp_PromotionCode", "vnp_PromotionAmount")
+	}
+	values := make([]string, len(keys))
+	for i, key := range keys {
+		values[i] = resp[key]
+	}
+	resp["vnp_SecureHash"] = payments.VNPaySign(g.cfg.VNPaySecret, strings.Join(values, "|"))
+
+	writeJSON(w, resp)
+}
+
+// momoCreate registers the order and returns a payUrl on this server
+func (g *Gateway) momoCreate(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		PartnerCode string `json:"partnerCode"`
+		RequestID   string `json:"requestId"`
+		Amount      int64  `json:"amount"`
+		OrderID     string `json:"orderId"`
+		OrderInfo   string `json:"orderInfo"`
+		RedirectURL string `json:"redirectUrl"`
+		IPNURL      string `json:"ipnUrl"`
+		RequestType string `json:"requestType"`
+		ExtraData   string `json:"extraData"`
+		Signature   string `json:"signature"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid body", http.StatusBadRequest)
+		return
+	}
+
+	raw := "accessKey=" + g.cfg.MoMoAccessKey +
+		"&amount=" + strconv.FormatInt(req.Amount, 10) +
+		"&extraData=" + req.ExtraData +
+		"&ipnUrl=" + req.IPNURL +
+		"&orderId=" + req.OrderID +
+		"&orderInfo=" + req.OrderInfo +
+		"&partnerCode=" + req.PartnerCode +
+		"&redirectUrl=" + req.RedirectURL +
+		"&requestId=" + req.RequestID +
+		"&requestType=" + req.RequestType
+	if req.Signature != payments.MoMoSign(g.cfg.MoMoSecret, raw) {
+		writeJSON(w, map[string]interface{}{"resultCode": 11, "message": "Invalid signature"})
+		return
+	}
+
+	g.mu.Lock()
+	g.momo[req.OrderID] = &momoOrder{
+		PartnerCode: req.PartnerCode,
+		OrderID:     req.OrderID,
+		RequestID:   req.RequestID,
+		Amount:      req.Amount,
+		OrderInfo:   req.OrderInfo,
+		RedirectURL: req.RedirectURL,
+		IPNURL:      req.IPNURL,
+		ExtraData:   req.ExtraData,
+	}
+	g.mu.Unlock()
+
+	writeJSON(w, map[string]interface{}{
+		"partnerCode":  req.PartnerCode,
+		"orderId":      req.OrderID,
+		"requestId":    req.RequestID,
+		"amount":       req.Amount,
+		"resultCode":   0,
+		"message":      "Success",
+		"payUrl":       fmt.Sprintf("http://%s/momo/pay?orderId=%s", r.Host, url.QueryEscape(req.OrderID)),
+		"responseTime": time.Now().UnixMilli(),
+	})
+}
+
+// momoPay approves the order, posts the IPN and redirects to redirectUrl
+func (g *Gateway) momoPay(w http.ResponseWriter, r *http.Request) {
+	g.mu.Lock()
+	order, ok := g.momo[r.URL.Query().Get("orderId")]
+	g.mu.Unlock()
+	if !ok {
+		http.Error(w, "unknown order", http.StatusNotFound)
+		return
+	}
+
+	transID := g.transactionID()
+	g.mu.Lock()
+	g.paid[order.OrderID] = transID
+	g.mu.Unlock()
+
+	ipn := map[string]interface{}{
+		"partnerCode":  order.PartnerCode,
+		"orderId":      order.OrderID,
+		"requestId":    order.RequestID,
+		"amount":       order.Amount,
+		"orderInfo":    order.OrderInfo,
+		"orderType":    "momo_wallet",
+		"transId":      transID,
+		"resultCode":   0,
+		"message":      "Successful.",
+		"payType":      "qr",
+		"responseTime": time.Now().UnixMilli(),
+		"extraData":    order.ExtraData,
+	}
+	raw := "accessKey=" + g.cfg.MoMoAccessKey +
+		"&amount=" + strconv.FormatInt(order.Amount, 10) +
+		"&extraData=" + order.ExtraData +
+		"&message=Successful." +
+		"&orderId=" + order.OrderID +
+		"&orderInfo=" + order.OrderInfo +
+		"&orderType=momo_wallet" +
+		"&partnerCode=" + order.PartnerCode +
+		"&payType=qr" +
+		"&requestId=" + order.RequestID +
+		"&responseTime=" + strconv.FormatInt(ipn["responseTime"].(int64), 10) +
+		"&resultCode=0" +
+		"&transId=" + strconv.FormatInt(transID, 10)
+	ipn["signature"] = payments.MoMoSign(g.cfg.MoMoSecret, raw)
+
+	body, _ := json.Marshal(ipn)
+	if resp, err := g.client.Post(order.IPNURL, "application/json", bytes.NewReader(body)); err != nil {
+		log.Printf("MoMo IPN failed: %v", err)
+	} else {
+		resp.Body.Close()
+		log.Printf("MoMo IPN for %s: %s", order.OrderID, resp.Status)
+	}
+
+	redirect := url.Values{}
+	redirect.Set("orderId", order.OrderID)
+	redirect.Set("resultCode", "0")
+	redirect.Set("transId", strconv.FormatInt(transID, 10))
+	http.Redirect(w, r, order.RedirectURL+"?"+redirect.Encode(), http.StatusFound)
+}
+
+func (g *Gateway) momoQuery(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		OrderID string `json:"orderId"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid body", http.StatusBadRequest)
+		return
+	}
+
+	g.mu.Lock()
+	order, ok := g.momo[req.OrderID]
+	transID, paid := g.paid[req.OrderID]
+	g.mu.Unlock()
+
+	resp := map[string]interface{}{"orderId": req.OrderID, "resultCode": 1000, "message": "Initiated"}
+	switch {
+	case !ok:
+		resp["resultCode"] = 42
+		resp["message"] = "Order not found"
+	case paid:
+		resp["resultCode"] = 0
+		resp["message"] = "Successful."
+		resp["amount"] = order.Amount
+		resp["transId"] = transID
+	}
+	writeJSON(w, resp)
+}
+
+func (g *Gateway) momoRefund(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		OrderID string `json:"orderId"`
+		Amount  int64  `json:"amount"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid body", http.StatusBadRequest)
+		return
+	}
+
+	writeJSON(w, map[string]interface{}{
+		"orderId":    req.OrderID,
+		"amount":     req.Amount,
+		"transId":    g.transactionID(),
+		"resultCode": 0,
+		"message":    "Successful.",
+	})
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
@@ -1,7 +1,11 @@
 package payments
 
 import (
+	"errors"
+	"log"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
@@ -42,7 +46,10 @@ func (h *Handler) GetPaymentMethods(c *gin.Context) {
 // @Tags Payments
 // @Security BearerAuth
 // @Param request body CreatePaymentRequest true "Payment data"
-// @Success 201
+// @Success 201 {object} CreatePaymentResponse
+// @Failure 400 "Amount does not match the outstanding balance"
+// @Failure 404 "Order not found"
+// @Failure 409 "Order has nothing left to pay"
 // @Router /api/payments [post]
 func (h *Handler) CreatePayment(c *gin.Context) {
 	var req CreatePaymentRequest
@@ -55,8 +62,16 @@ func (h *Handler) CreatePayment(c *gin.Context) {
 		return
 	}
 
-	if err := h.service.CreatePayment(c.Request.Context(), &req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
+	resp, err := h.service.CreatePayment(c.Request.Context(), c.GetString("userID"), &req, c.ClientIP())
+	if err != nil {
+		status := http.StatusBadRequest
+		switch {
+		case errors.Is(err, ErrPaymentNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, ErrOrderNotPayable):
+			status = http.StatusConflict
+		}
+		c.JSON(status, gin.H{
 			"success": false,
 			"message": err.Error(),
 			"data":    nil,
@@ -67,10 +82,59 @@ func (h *Handler) CreatePayment(c *gin.Context) {
 	c.JSON(http.StatusCreated, gin.H{
 		"success": true,
 		"message": "Payment created successfully",
-		"data":    nil,
+		"data":    resp,
 	})
 }
 
+// HandleCallback godoc
+// @Summary Payment gateway notification (IPN)
+// @Description Called by the gateway, not by clients. The response format is
+// @Description the one each gateway expects as acknowledgement.
+// @Tags Payments
+// @Param provider path string true "Provider code (VNPAY, MOMO)"
+// @Success 200
+// @Success 204
+// @Router /api/payments/callback/{provider} [post]
+func (h *Handler) HandleCallback(c *gin.Context) {
+	provider := strings.ToUpper(c.Param("provider"))
+	err := h.service.HandleCallback(c.Request.Context(), provider, c.Request)
+	if err != nil && !errors.Is(err, ErrAlreadyProcessed) {
+		log.Printf("Payment callback from %s rejected: %v", provider, err)
+	}
+
+	switch provider {
+	case "VNPAY":
+		// VNPay retries until it receives RspCode 00 or 02
+		code, message := "00", "Confirm Success"
+		switch {
+		case err == nil:
+		case errors.Is(err, ErrAlreadyProcessed):
+			code, message = "02", "Order already confirmed"
+		case errors.Is(err, ErrInvalidSignature):
+			code, message = "97", "Invalid signature"
+		case errors.Is(err, ErrPaymentNotFound):
+			code, message = "01", "Order not found"
+		case errors.Is(err, ErrAmountMismatch):
+			code, message = "04", "Invalid amount"
+		default:
+			code, message = "99", "Unknown error"
+		}
+		c.JSON(http.StatusOK, gin.H{"RspCode": code, "Message": message})
+	default:
+		// MoMo expects 204 No Content as acknowledgement
+		switch {
+		case err == nil, errors.Is(err, ErrAlreadyProcessed):
+			c.Status(http.StatusNoContent)
+		case errors.Is(err, ErrInvalidSignature), errors.Is(err, ErrAmountMismatch):
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error(), "data": nil})
+		case errors.Is(err, ErrPaymentNotFound), errors.Is(err, ErrNotSupported):
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "message": err.Error(), "data": nil})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": err.Error(), "data": nil})
+		}
+	}
+}
+
 // GetPaymentByOrderID godoc
 // @Summary Get payment by order ID
 // @Tags Payments
@@ -80,8 +144,10 @@ func (h *Handler) CreatePayment(c *gin.Context) {
 // @Router /api/payments/{orderId} [get]
 func (h *Handler) GetPaymentByOrderID(c *gin.Context) {
 	orderID := c.Param("orderId")
+	role := c.GetString("role")
+	isStaff := role == "ADMIN" || role == "STAFF"
 
-	payment, err := h.service.GetPaymentByOrderID(c.Request.Context(), orderID)
+	payment, err := h.service.GetPaymentByOrderID(c.Request.Context(), c.GetString("userID"), orderID, isStaff)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{
 			"success": false,
@@ -97,3 +163,210 @@ func (h *Handler) GetPaymentByOrderID(c *gin.Context) {
 		"data":    payment,
 	})
 }
+
+// GetPaymentQR godoc
+// @Summary Get the VietQR code of an order's pending bank transfer
+// @Tags Payments
+// @Security BearerAuth
+// @Produce png
+// @Param orderId path string true "Order ID"
+// @Success 200 {file} binary
+// @Router /api/payments/{orderId}/qr [get]
+func (h *Handler) GetPaymentQR(c *gin.Context) {
+	role := c.GetString("role")
+	isStaff := role == "ADMIN" || role == "STAFF"
+
+	png, err := h.service.GetPaymentQR(c.Request.Context(), c.GetString("userID"), c.Param("orderId"), isStaff)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, ErrPaymentNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{
+			"success": false,
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+
+	c.Data(http.StatusOK, "image/png", png)
+}
+
+// ReconcileStatement godoc
+// @Summary Match a bank statement CSV to pending bank transfers (admin only)
+// @Tags Payments
+// @Security BearerAuth
+// @Accept multipart/form-data
+// @Param file formData file true "Bank statement CSV"
+// @Success 200 {object} ReconcileReport
+// @Router /api/admin/payments/reconcile [post]
+func (h *Handler) ReconcileStatement(c *gin.Context) {
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Statement file is required",
+			"data":    err.Error(),
+		})
+		return
+	}
+
+	statement, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+	defer statement.Close()
+
+	report, err := h.service.ReconcileStatement(c.Request.Context(), statement)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Statement reconciled",
+		"data":    report,
+	})
+}
+
+// IssueRefund godoc
+// @Summary Issue a full or partial refund (admin only)
+// @Tags Refunds
+// @Security BearerAuth
+// @Param request body CreateRefundRequest true "Refund data"
+// @Success 201 {object} RefundResponse
+// @Failure 409 "Refund exceeds the captured amount"
+// @Router /api/admin/refunds [post]
+func (h *Handler) IssueRefund(c *gin.Context) {
+	var req CreateRefundRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid request data",
+			"data":    err.Error(),
+		})
+		return
+	}
+
+	refund, err := h.service.IssueRefund(c.Request.Context(), c.GetString("userID"), &req)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, ErrRefundExceedsCaptured) {
+			status = http.StatusConflict
+		}
+		c.JSON(status, gin.H{
+			"success": false,
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Refund created successfully",
+		"data":    refund,
+	})
+}
+
+// ProcessRefund godoc
+// @Summary Send a pending refund to the payment gateway (admin only)
+// @Tags Refunds
+// @Security BearerAuth
+// @Param id path string true "Refund ID"
+// @Success 200 {object} RefundResponse
+// @Router /api/admin/refunds/{id}/process [post]
+func (h *Handler) ProcessRefund(c *gin.Context) {
+	refund, err := h.service.ProcessRefund(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Refund processed",
+		"data":    refund,
+	})
+}
+
+// GetRefunds godoc
+// @Summary List refunds (admin only)
+// @Tags Refunds
+// @Security BearerAuth
+// @Param orderId query string false "Order ID"
+// @Param status query string false "Refund status"
+// @Param page query int false "Page number"
+// @Param limit query int false "Limit per page"
+// @Success 200 {object} RefundsListResponse
+// @Router /api/admin/refunds [get]
+func (h *Handler) GetRefunds(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	refunds, err := h.service.GetRefunds(c.Request.Context(), c.Query("orderId"), c.Query("status"), page, limit)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Refunds retrieved successfully",
+		"data":    refunds,
+	})
+}
+
+// UpdateRefundStatus godoc
+// @Summary Mark a pending refund as completed or failed (admin only)
+// @Tags Refunds
+// @Security BearerAuth
+// @Param id path string true "Refund ID"
+// @Param request body UpdateRefundStatusRequest true "Status"
+// @Success 200
+// @Router /api/admin/refunds/{id}/status [put]
+func (h *Handler) UpdateRefundStatus(c *gin.Context) {
+	var req UpdateRefundStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid request data",
+			"data":    err.Error(),
+		})
+		return
+	}
+
+	if err := h.service.UpdateRefundStatus(c.Request.Context(), c.Param("id"), req.Status); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Refund status updated successfully",
+		"data":    nil,
+	})
+}
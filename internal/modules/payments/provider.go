@@ -0,0 +1,90 @@
+package payments
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"time"
+
+	"phone-store-backend/internal/models"
+)
+
+var (
+	// ErrInvalidSignature is returned when a gateway callback or response
+	// fails signature verification
+	ErrInvalidSignature = errors.New("invalid gateway signature")
+
+	// ErrNotSupported is returned by providers that cannot perform an
+	// operation online (e.g. COD refunds are settled by hand)
+	ErrNotSupported = errors.New("operation not supported by payment provider")
+)
+
+// CheckoutRequest is what a provider needs to start collecting a payment
+type CheckoutRequest struct {
+	PaymentRef  string // Our payment ID, echoed back by the gateway
+	OrderNumber string
+	Amount      float64
+	Description string
+	ClientIP    string
+	CreatedAt   time.Time
+}
+
+// CheckoutResult tells the client how to pay. CheckoutURL is set for
+// redirect gateways, QRPayload for bank transfers; both are empty for COD.
+type CheckoutResult struct {
+	CheckoutURL string
+	QRPayload   string
+}
+
+// CallbackResult is a verified gateway notification
+type CallbackResult struct {
+	PaymentRef    string
+	TransactionID string
+	Amount        float64
+	Success       bool
+	Message       string
+}
+
+// StatusResult is the gateway's view of a payment
+type StatusResult struct {
+	Status        models.PaymentStatus
+	TransactionID string
+	Amount        float64
+}
+
+// RefundRequest describes money to send back for a captured payment
+type RefundRequest struct {
+	RefundRef     string // Our refund ID
+	PaymentRef    string
+	TransactionID string
+	Amount        float64
+	PaymentAmount float64 // Full captured amount, to tell full from partial refunds
+	PaymentDate   time.Time
+	Reason        string
+	Operator      string
+	ClientIP      string
+}
+
+// RefundResult is the gateway's answer to a refund request
+type RefundResult struct {
+	Success       bool
+	TransactionID string
+	Message       string
+}
+
+// Provider is a payment gateway adapter. Each implementation handles the
+// signing scheme of its gateway.
+type Provider interface {
+	// Code matches models.PaymentMethod.Code (COD, VNPAY, MOMO, ...)
+	Code() string
+	CreateCheckout(ctx context.Context, req *CheckoutRequest) (*CheckoutResult, error)
+	VerifyCallback(r *http.Request) (*CallbackResult, error)
+	QueryStatus(ctx context.Context, payment *models.Payment) (*StatusResult, error)
+	Refund(ctx context.Context, req *RefundRequest) (*RefundResult, error)
+}
+
+// toMinorUnits rounds a VND amount to a whole number for gateway payloads
+func toMinorUnits(amount float64) int64 {
+	return int64(math.Round(amount))
+}
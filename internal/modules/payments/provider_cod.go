@@ -0,0 +1,40 @@
+package payments
+
+import (
+	"context"
+	"net/http"
+
+	"phone-store-backend/internal/models"
+)
+
+// CODProvider handles cash on delivery. Nothing happens online: the payment
+// stays pending until the courier collects the cash.
+type CODProvider struct{}
+
+func NewCODProvider() *CODProvider {
+	return &CODProvider{}
+}
+
+func (p *CODProvider) Code() string {
+	return models.PaymentMethodCOD
+}
+
+func (p *CODProvider) CreateCheckout(ctx context.Context, req *CheckoutRequest) (*CheckoutResult, error) {
+	return &CheckoutResult{}, nil
+}
+
+func (p *CODProvider) VerifyCallback(r *http.Request) (*CallbackResult, error) {
+	return nil, ErrNotSupported
+}
+
+func (p *CODProvider) QueryStatus(ctx context.Context, payment *models.Payment) (*StatusResult, error) {
+	return &StatusResult{
+		Status:        payment.Status,
+		TransactionID: payment.TransactionID,
+		Amount:        payment.Amount,
+	}, nil
+}
+
+func (p *CODProvider) Refund(ctx context.Context, req *RefundRequest) (*RefundResult, error) {
+	return nil, ErrNotSupported
+}
@@ -0,0 +1,265 @@
+package payments
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"phone-store-backend/internal/models"
+)
+
+// MoMoConfig holds partner credentials and gateway endpoints
+type MoMoConfig struct {
+	PartnerCode string
+	AccessKey   string
+	SecretKey   string
+	Endpoint    string // Base URL, e.g. https://test-payment.momo.vn
+	RedirectURL string
+	IPNURL      string
+	HTTPClient  *http.Client
+}
+
+// MoMoProvider implements the MoMo v2 API HMAC-SHA256 scheme
+type MoMoProvider struct {
+	cfg MoMoConfig
+}
+
+func NewMoMoProvider(cfg MoMoConfig) *MoMoProvider {
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = &http.Client{Timeout: 15 * time.Second}
+	}
+	cfg.Endpoint = strings.TrimRight(cfg.Endpoint, "/")
+	return &MoMoProvider{cfg: cfg}
+}
+
+func (p *MoMoProvider) Code() string {
+	return "MOMO"
+}
+
+// momoResponse covers the fields returned by create, query and refund
+type momoResponse struct {
+	PartnerCode  string `json:"partnerCode"`
+	OrderID      string `json:"orderId"`
+	RequestID    string `json:"requestId"`
+	Amount       int64  `json:"amount"`
+	TransID      int64  `json:"transId"`
+	ResultCode   int    `json:"resultCode"`
+	Message      string `json:"message"`
+	PayURL       string `json:"payUrl"`
+	ResponseTime int64  `json:"responseTime"`
+}
+
+// CreateCheckout creates a captureWallet payment and returns MoMo's payUrl
+func (p *MoMoProvider) CreateCheckout(ctx context.Context, req *CheckoutRequest) (*CheckoutResult, error) {
+	amount := strconv.FormatInt(toMinorUnits(req.Amount), 10)
+	requestID := newRequestID()
+	requestType := "captureWallet"
+	extraData := ""
+
+	raw := "accessKey=" + p.cfg.AccessKey +
+		"&amount=" + amount +
+		"&extraData=" + extraData +
+		"&ipnUrl=" + p.cfg.IPNURL +
+		"&orderId=" + req.PaymentRef +
+		"&orderInfo=" + req.Description +
+		"&partnerCode=" + p.cfg.PartnerCode +
+		"&redirectUrl=" + p.cfg.RedirectURL +
+		"&requestId=" + requestID +
+		"&requestType=" + requestType
+
+	body := map[string]interface{}{
+		"partnerCode": p.cfg.PartnerCode,
+		"requestId":   requestID,
+		"amount":      toMinorUnits(req.Amount),
+		"orderId":     req.PaymentRef,
+		"orderInfo":   req.Description,
+		"redirectUrl": p.cfg.RedirectURL,
+		"ipnUrl":      p.cfg.IPNURL,
+		"requestType": requestType,
+		"extraData":   extraData,
+		"lang":        "vi",
+		"signature":   MoMoSign(p.cfg.SecretKey, raw),
+	}
+
+	resp, err := p.post(ctx, "/v2/gateway/api/create", body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.ResultCode != 0 {
+		return nil, fmt.Errorf("momo create failed: %d %s", resp.ResultCode, resp.Message)
+	}
+
+	return &CheckoutResult{CheckoutURL: resp.PayURL}, nil
+}
+
+// momoIPN is the JSON body MoMo posts to the IPN URL
+type momoIPN struct {
+	PartnerCode  string `json:"partnerCode"`
+	OrderID      string `json:"orderId"`
+	RequestID    string `json:"requestId"`
+	Amount       int64  `json:"amount"`
+	OrderInfo    string `json:"orderInfo"`
+	OrderType    string `json:"orderType"`
+	TransID      int64  `json:"transId"`
+	ResultCode   int    `json:"resultCode"`
+	Message      string `json:"message"`
+	PayType      string `json:"payType"`
+	ResponseTime int64  `json:"responseTime"`
+	ExtraData    string `json:"extraData"`
+	Signature    string `json:"signature"`
+}
+
+// VerifyCallback checks the signature of an IPN body
+func (p *MoMoProvider) VerifyCallback(r *http.Request) (*CallbackResult, error) {
+	var ipn momoIPN
+	if err := json.NewDecoder(r.Body).Decode(&ipn); err != nil {
+		return nil, errors.New("invalid callback body")
+	}
+
+	raw := "accessKey=" + p.cfg.AccessKey +
+		"&amount=" + strconv.FormatInt(ipn.Amount, 10) +
+		"&extraData=" + ipn.ExtraData +
+		"&message=" + ipn.Message +
+		"&orderId=" + ipn.OrderID +
+		"&orderInfo=" + ipn.OrderInfo +
+		"&orderType=" + ipn.OrderType +
+		"&partnerCode=" + ipn.PartnerCode +
+		"&payType=" + ipn.PayType +
+		"&requestId=" + ipn.RequestID +
+		"&responseTime=" + strconv.FormatInt(ipn.ResponseTime, 10) +
+		"&resultCode=" + strconv.Itoa(ipn.ResultCode) +
+		"&transId=" + strconv.FormatInt(ipn.TransID, 10)
+
+	if !hmac.Equal([]byte(ipn.Signature), []byte(MoMoSign(p.cfg.SecretKey, raw))) {
+		return nil, ErrInvalidSignature
+	}
+	if ipn.PartnerCode != p.cfg.PartnerCode {
+		return nil, errors.New("callback is for a different partner")
+	}
+
+	return &CallbackResult{
+		PaymentRef:    ipn.OrderID,
+		TransactionID: strconv.FormatInt(ipn.TransID, 10),
+		Amount:        float64(ipn.Amount),
+		Success:       ipn.ResultCode == 0 || ipn.ResultCode == 9000,
+		Message:       ipn.Message,
+	}, nil
+}
+
+// QueryStatus asks MoMo for the current state of a payment
+func (p *MoMoProvider) QueryStatus(ctx context.Context, payment *models.Payment) (*StatusResult, error) {
+	requestID := newRequestID()
+	orderID := payment.ID.Hex()
+
+	raw := "accessKey=" + p.cfg.AccessKey +
+		"&orderId=" + orderID +
+		"&partnerCode=" + p.cfg.PartnerCode +
+		"&requestId=" + requestID
+
+	resp, err := p.post(ctx, "/v2/gateway/api/query", map[string]interface{}{
+		"partnerCode": p.cfg.PartnerCode,
+		"requestId":   requestID,
+		"orderId":     orderID,
+		"lang":        "vi",
+		"signature":   MoMoSign(p.cfg.SecretKey, raw),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := &StatusResult{
+		TransactionID: strconv.FormatInt(resp.TransID, 10),
+		Amount:        float64(resp.Amount),
+	}
+	switch resp.ResultCode {
+	case 0, 9000:
+		result.Status = models.PaymentStatusCompleted
+	case 1000, 7000, 7002:
+		// Initiated / being processed
+		result.Status = models.PaymentStatusPending
+	default:
+		result.Status = models.PaymentStatusFailed
+	}
+	return result, nil
+}
+
+// Refund refunds all or part of a captured MoMo transaction
+func (p *MoMoProvider) Refund(ctx context.Context, req *RefundRequest) (*RefundResult, error) {
+	transID, err := strconv.ParseInt(req.TransactionID, 10, 64)
+	if err != nil {
+		return nil, errors.New("payment has no MoMo transaction ID")
+	}
+
+	requestID := newRequestID()
+	amount := strconv.FormatInt(toMinorUnits(req.Amount), 10)
+	description := req.Reason
+
+	raw := "accessKey=" + p.cfg.AccessKey +
+		"&amount=" + amount +
+		"&description=" + description +
+		"&orderId=" + req.RefundRef +
+		"&partnerCode=" + p.cfg.PartnerCode +
+		"&requestId=" + requestID +
+		"&transId=" + req.TransactionID
+
+	resp, err := p.post(ctx, "/v2/gateway/api/refund", map[string]interface{}{
+		"partnerCode": p.cfg.PartnerCode,
+		"orderId":     req.RefundRef,
+		"requestId":   requestID,
+		"amount":      toMinorUnits(req.Amount),
+		"transId":     transID,
+		"lang":        "vi",
+		"description": description,
+		"signature":   MoMoSign(p.cfg.SecretKey, raw),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &RefundResult{
+		Success:       resp.ResultCode == 0,
+		TransactionID: strconv.FormatInt(resp.TransID, 10),
+		Message:       resp.Message,
+	}, nil
+}
+
+func (p *MoMoProvider) post(ctx context.Context, path string, body map[string]interface{}) (*momoResponse, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.cfg.Endpoint+path, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	httpResp, err := p.cfg.HTTPClient.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+
+	var resp momoResponse
+	if err := json.NewDecoder(httpResp.Body).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("momo: invalid response: %w", err)
+	}
+	return &resp, nil
+}
+
+// MoMoSign returns the hex HMAC-SHA256 of a MoMo raw signature string
+func MoMoSign(secret, raw string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(raw))
+	return hex.EncodeToString(mac.Sum(nil))
+}
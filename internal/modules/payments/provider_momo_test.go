@@ -0,0 +1,126 @@
+package payments_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"phone-store-backend/internal/models"
+	"phone-store-backend/internal/modules/payments"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func newMoMo(g *testGateway, secret string) *payments.MoMoProvider {
+	return payments.NewMoMoProvider(payments.MoMoConfig{
+		PartnerCode: momoPartner,
+		AccessKey:   momoAccessKey,
+		SecretKey:   secret,
+		Endpoint:    g.URL + "/momo",
+		RedirectURL: testReturnURL,
+		IPNURL:      g.IPNURL,
+	})
+}
+
+func TestMoMoCheckoutCallbackQueryAndRefund(t *testing.T) {
+	g := startGateway(t)
+	provider := newMoMo(g, momoSecretKey)
+	ctx := context.Background()
+
+	payment := &models.Payment{ID: primitive.NewObjectID(), Amount: 25990000}
+	checkout, err := provider.CreateCheckout(ctx, &payments.CheckoutRequest{
+		PaymentRef:  payment.ID.Hex(),
+		OrderNumber: "ORD-1",
+		Amount:      payment.Amount,
+		Description: "Thanh toan don hang ORD-1",
+	})
+	if err != nil {
+		t.Fatalf("create checkout: %v", err)
+	}
+
+	// Nothing is paid until the customer goes through the pay page
+	status, err := provider.QueryStatus(ctx, payment)
+	if err != nil {
+		t.Fatalf("query status: %v", err)
+	}
+	if status.Status != models.PaymentStatusPending {
+		t.Errorf("status before paying = %s, want %s", status.Status, models.PaymentStatusPending)
+	}
+
+	g.pay(t, checkout.CheckoutURL)
+	callback, err := provider.VerifyCallback(g.ipn(t))
+	if err != nil {
+		t.Fatalf("verify IPN: %v", err)
+	}
+	if !callback.Success || callback.PaymentRef != payment.ID.Hex() || callback.Amount != payment.Amount || callback.TransactionID == "" {
+		t.Errorf("callback = %+v, want a successful payment of %.0f for %s", callback, payment.Amount, payment.ID.Hex())
+	}
+
+	status, err = provider.QueryStatus(ctx, payment)
+	if err != nil {
+		t.Fatalf("query status: %v", err)
+	}
+	if status.Status != models.PaymentStatusCompleted || status.TransactionID != callback.TransactionID {
+		t.Errorf("status = %+v, want completed with transaction %s", status, callback.TransactionID)
+	}
+
+	refund, err := provider.Refund(ctx, &payments.RefundRequest{
+		RefundRef:     primitive.NewObjectID().Hex(),
+		PaymentRef:    payment.ID.Hex(),
+		TransactionID: callback.TransactionID,
+		Amount:        1000000,
+		PaymentAmount: payment.Amount,
+		Reason:        "Return",
+	})
+	if err != nil {
+		t.Fatalf("refund: %v", err)
+	}
+	if !refund.Success {
+		t.Errorf("refund = %+v, want success", refund)
+	}
+}
+
+func TestMoMoRejectsBadSignatures(t *testing.T) {
+	g := startGateway(t)
+	ctx := context.Background()
+
+	// The gateway refuses a create request signed with the wrong key
+	if _, err := newMoMo(g, "other-secret").CreateCheckout(ctx, &payments.CheckoutRequest{
+		PaymentRef: primitive.NewObjectID().Hex(),
+		Amount:     25990000,
+	}); err == nil {
+		t.Error("checkout signed with the wrong key was accepted")
+	}
+
+	provider := newMoMo(g, momoSecretKey)
+	checkout, err := provider.CreateCheckout(ctx, &payments.CheckoutRequest{
+		PaymentRef: primitive.NewObjectID().Hex(),
+		Amount:     25990000,
+	})
+	if err != nil {
+		t.Fatalf("create checkout: %v", err)
+	}
+	g.pay(t, checkout.CheckoutURL)
+	ipn := g.ipn(t)
+	body, _ := io.ReadAll(ipn.Body)
+
+	// An IPN whose amount was changed after signing
+	var fields map[string]interface{}
+	if err := json.Unmarshal(body, &fields); err != nil {
+		t.Fatalf("decode IPN: %v", err)
+	}
+	fields["amount"] = 100
+	tampered, _ := json.Marshal(fields)
+	if _, err := provider.VerifyCallback(httptest.NewRequest("POST", ipn.URL.String(), bytes.NewReader(tampered))); !errors.Is(err, payments.ErrInvalidSignature) {
+		t.Errorf("tampered IPN: err = %v, want ErrInvalidSignature", err)
+	}
+
+	// An IPN checked with someone else's key
+	if _, err := newMoMo(g, "other-secret").VerifyCallback(httptest.NewRequest("POST", ipn.URL.String(), bytes.NewReader(body))); !errors.Is(err, payments.ErrInvalidSignature) {
+		t.Errorf("foreign IPN: err = %v, want ErrInvalidSignature", err)
+	}
+}
@@ -0,0 +1,86 @@
+package payments_test
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"phone-store-backend/internal/modules/payments/fakegateway"
+)
+
+const (
+	vnpayTmnCode  = "FAKETMN"
+	vnpaySecret   = "vnpay-secret"
+	momoPartner   = "FAKEMOMO"
+	momoAccessKey = "momo-access"
+	momoSecretKey = "momo-secret"
+	testReturnURL = "http://shop.test/payment/result"
+	ipnTimeout    = 5 * time.Second
+)
+
+// testGateway is a fake gateway plus the merchant endpoint its IPNs are
+// sent to
+type testGateway struct {
+	URL    string
+	IPNURL string
+	ipns   chan *http.Request
+}
+
+func startGateway(t *testing.T) *testGateway {
+	t.Helper()
+	g := &testGateway{ipns: make(chan *http.Request, 1)}
+
+	// The merchant keeps a copy of every IPN for the test to verify
+	merchant := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		ipn := httptest.NewRequest(r.Method, r.URL.String(), bytes.NewReader(body))
+		ipn.Header = r.Header.Clone()
+		g.ipns <- ipn
+	}))
+	t.Cleanup(merchant.Close)
+	g.IPNURL = merchant.URL + "/api/payments/callback"
+
+	gateway := httptest.NewServer(fakegateway.New(fakegateway.Config{
+		VNPaySecret:   vnpaySecret,
+		VNPayIPNURL:   g.IPNURL,
+		MoMoAccessKey: momoAccessKey,
+		MoMoSecret:    momoSecretKey,
+	}))
+	t.Cleanup(gateway.Close)
+	g.URL = gateway.URL
+
+	return g
+}
+
+// pay opens a checkout URL like the customer's browser would, without
+// following the redirect back to the shop
+func (g *testGateway) pay(t *testing.T, checkoutURL string) *http.Response {
+	t.Helper()
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	resp, err := client.Get(checkoutURL)
+	if err != nil {
+		t.Fatalf("open checkout: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("checkout answered %s, want a redirect", resp.Status)
+	}
+	return resp
+}
+
+// ipn waits for the gateway's notification to the merchant
+func (g *testGateway) ipn(t *testing.T) *http.Request {
+	t.Helper()
+	select {
+	case r := <-g.ipns:
+		return r
+	case <-time.After(ipnTimeout):
+		t.Fatal("no IPN received")
+		return nil
+	}
+}
@@ -0,0 +1,119 @@
+package payments
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"phone-store-backend/internal/models"
+)
+
+// VietQR (NAPAS 247) constants from the EMVCo merchant-presented QR spec
+const (
+	vietQRGUID        = "A000000727"
+	vietQRServiceCode = "QRIBFTTA" // Transfer to a bank account
+	vietQRCurrencyVND = "704"
+)
+
+// memoPattern strips what banks drop from transfer descriptions
+var memoPattern = regexp.MustCompile(`[^A-Z0-9]`)
+
+// VietQRConfig holds the receiving bank account
+type VietQRConfig struct {
+	BankBIN     string // NAPAS bank identification number, e.g. 970436
+	AccountNo   string
+	AccountName string
+}
+
+// BankTransferProvider shows a VietQR code for a bank transfer. There is no
+// gateway callback: transfers are matched from bank statement imports.
+type BankTransferProvider struct {
+	cfg VietQRConfig
+}
+
+func NewBankTransferProvider(cfg VietQRConfig) *BankTransferProvider {
+	return &BankTransferProvider{cfg: cfg}
+}
+
+func (p *BankTransferProvider) Code() string {
+	return "BANK_TRANSFER"
+}
+
+// CreateCheckout returns the VietQR payload with the order number as memo
+func (p *BankTransferProvider) CreateCheckout(ctx context.Context, req *CheckoutRequest) (*CheckoutResult, error) {
+	return &CheckoutResult{
+		QRPayload: VietQRPayload(p.cfg, req.Amount, TransferMemo(req.OrderNumber)),
+	}, nil
+}
+
+func (p *BankTransferProvider) VerifyCallback(r *http.Request) (*CallbackResult, error) {
+	return nil, ErrNotSupported
+}
+
+func (p *BankTransferProvider) QueryStatus(ctx context.Context, payment *models.Payment) (*StatusResult, error) {
+	return &StatusResult{
+		Status:        payment.Status,
+		TransactionID: payment.TransactionID,
+		Amount:        payment.Amount,
+	}, nil
+}
+
+func (p *BankTransferProvider) Refund(ctx context.Context, req *RefundRequest) (*RefundResult, error) {
+	return nil, ErrNotSupported
+}
+
+// TransferMemo is the transfer description customers are asked to use. It
+// only keeps characters every bank preserves.
+func TransferMemo(orderNumber string) string {
+	return memoPattern.ReplaceAllString(strings.ToUpper(orderNumber), "")
+}
+
+// VietQRPayload builds a dynamic EMVCo QR payload for a transfer of amount
+// to the configured account
+func VietQRPayload(cfg VietQRConfig, amount float64, memo string) string {
+	beneficiary := tlv("00", cfg.BankBIN) + tlv("01", cfg.AccountNo)
+	merchantAccount := tlv("00", vietQRGUID) + tlv("01", beneficiary) + tlv("02", vietQRServiceCode)
+
+	var b strings.Builder
+	b.WriteString(tlv("00", "01"))
+	b.WriteString(tlv("01", "12")) // Dynamic QR: amount is fixed
+	b.WriteString(tlv("38", merchantAccount))
+	b.WriteString(tlv("53", vietQRCurrencyVND))
+	b.WriteString(tlv("54", strconv.FormatInt(toMinorUnits(amount), 10)))
+	b.WriteString(tlv("58", "VN"))
+	if cfg.AccountName != "" {
+		b.WriteString(tlv("59", cfg.AccountName))
+	}
+	if memo != "" {
+		b.WriteString(tlv("62", tlv("08", memo)))
+	}
+
+	b.WriteString("6304")
+	b.WriteString(fmt.Sprintf("%04X", crc16CCITT(b.String())))
+	return b.String()
+}
+
+// tlv encodes one EMVCo data object: ID, two-digit length, value
+func tlv(id, value string) string {
+	return fmt.Sprintf("%s%02d%s", id, len(value), value)
+}
+
+// crc16CCITT is CRC-16/CCITT-FALSE (poly 0x1021, init 0xFFFF) as required
+// by the EMVCo checksum field
+func crc16CCITT(data string) uint16 {
+	crc := uint16(0xFFFF)
+	for i := 0; i < len(data); i++ {
+		crc ^= uint16(data[i]) << 8
+		for bit := 0; bit < 8; bit++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
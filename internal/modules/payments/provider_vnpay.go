@@ -0,0 +1,298 @@
+package payments
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"phone-store-backend/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	vnpayVersion    = "2.1.0"
+	vnpayDateFormat = "20060102150405"
+)
+
+// vnpayZone is the timezone VNPay expects all dates in (GMT+7)
+var vnpayZone = time.FixedZone("ICT", 7*60*60)
+
+// VNPayConfig holds merchant credentials and gateway endpoints
+type VNPayConfig struct {
+	TmnCode    string
+	HashSecret string
+	PayURL     string // Browser checkout page
+	APIURL     string // querydr / refund API
+	ReturnURL  string
+	HTTPClient *http.Client
+}
+
+// VNPayProvider implements the VNPay 2.1.0 HMAC-SHA512 scheme
+type VNPayProvider struct {
+	cfg VNPayConfig
+}
+
+func NewVNPayProvider(cfg VNPayConfig) *VNPayProvider {
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = &http.Client{Timeout: 15 * time.Second}
+	}
+	return &VNPayProvider{cfg: cfg}
+}
+
+func (p *VNPayProvider) Code() string {
+	return "VNPAY"
+}
+
+// CreateCheckout builds the signed payment URL the customer is redirected to
+func (p *VNPayProvider) CreateCheckout(ctx context.Context, req *CheckoutRequest) (*CheckoutResult, error) {
+	createdAt := req.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = time.Now()
+	}
+
+	params := url.Values{}
+	params.Set("vnp_Version", vnpayVersion)
+	params.Set("vnp_Command", "pay")
+	params.Set("vnp_TmnCode", p.cfg.TmnCode)
+	params.Set("vnp_Amount", strconv.FormatInt(toMinorUnits(req.Amount)*100, 10))
+	params.Set("vnp_CurrCode", "VND")
+	params.Set("vnp_TxnRef", req.PaymentRef)
+	params.Set("vnp_OrderInfo", req.Description)
+	params.Set("vnp_OrderType", "other")
+	params.Set("vnp_Locale", "vn")
+	params.Set("vnp_ReturnUrl", p.cfg.ReturnURL)
+	params.Set("vnp_IpAddr", req.ClientIP)
+	params.Set("vnp_CreateDate", createdAt.In(vnpayZone).Format(vnpayDateFormat))
+	params.Set("vnp_ExpireDate", createdAt.Add(15*time.Minute).In(vnpayZone).Format(vnpayDateFormat))
+
+	query := params.Encode()
+	checkoutURL := p.cfg.PayURL + "?" + query + "&vnp_SecureHash=" + VNPaySign(p.cfg.HashSecret, query)
+
+	return &CheckoutResult{CheckoutURL: checkoutURL}, nil
+}
+
+// VerifyCallback checks the signature of an IPN or return-URL request.
+// VNPay sends its parameters in the query string.
+func (p *VNPayProvider) VerifyCallback(r *http.Request) (*CallbackResult, error) {
+	query := r.URL.Query()
+
+	signature := query.Get("vnp_SecureHash")
+	params := url.Values{}
+	for key, values := range query {
+		if !strings.HasPrefix(key, "vnp_") || key == "vnp_SecureHash" || key == "vnp_SecureHashType" {
+			continue
+		}
+		if len(values) > 0 && values[0] != "" {
+			params.Set(key, values[0])
+		}
+	}
+
+	if !hmac.Equal([]byte(strings.ToLower(signature)), []byte(VNPaySign(p.cfg.HashSecret, params.Encode()))) {
+		return nil, ErrInvalidSignature
+	}
+	if params.Get("vnp_TmnCode") != p.cfg.TmnCode {
+		return nil, errors.New("callback is for a different merchant")
+	}
+
+	amount, err := strconv.ParseInt(params.Get("vnp_Amount"), 10, 64)
+	if err != nil {
+		return nil, errors.New("invalid callback amount")
+	}
+
+	return &CallbackResult{
+		PaymentRef:    params.Get("vnp_TxnRef"),
+		TransactionID: params.Get("vnp_TransactionNo"),
+		Amount:        float64(amount) / 100,
+		Success:       params.Get("vnp_ResponseCode") == "00" && params.Get("vnp_TransactionStatus") == "00",
+		Message:       params.Get("vnp_ResponseCode"),
+	}, nil
+}
+
+// vnpayAPIResponse covers the fields returned by querydr and refund
+type vnpayAPIResponse struct {
+	ResponseID        string `json:"vnp_ResponseId"`
+	Command           string `json:"vnp_Command"`
+	ResponseCode      string `json:"vnp_ResponseCode"`
+	Message           string `json:"vnp_Message"`
+	TmnCode           string `json:"vnp_TmnCode"`
+	TxnRef            string `json:"vnp_TxnRef"`
+	Amount            string `json:"vnp_Amount"`
+	BankCode          string `json:"vnp_BankCode"`
+	PayDate           string `json:"vnp_PayDate"`
+	TransactionNo     string `json:"vnp_TransactionNo"`
+	TransactionType   string `json:"vnp_TransactionType"`
+	TransactionStatus string `json:"vnp_TransactionStatus"`
+	OrderInfo         string `json:"vnp_OrderInfo"`
+	PromotionCode     string `json:"vnp_PromotionCode"`
+	PromotionAmount   string `json:"vnp_PromotionAmount"`
+	SecureHash        string `json:"vnp_SecureHash"`
+}
+
+// QueryStatus asks VNPay (querydr) for the current state of a payment
+func (p *VNPayProvider) QueryStatus(ctx context.Context, payment *models.Payment) (*StatusResult, error) {
+	now := time.Now().In(vnpayZone).Format(vnpayDateFormat)
+	fields := map[string]string{
+		"vnp_RequestId":       newRequestID(),
+		"vnp_Version":         vnpayVersion,
+		"vnp_Command":         "querydr",
+		"vnp_TmnCode":         p.cfg.TmnCode,
+		"vnp_TxnRef":          payment.ID.Hex(),
+		"vnp_OrderInfo":       "Query payment " + payment.ID.Hex(),
+		"vnp_TransactionDate": payment.CreatedAt.In(vnpayZone).Format(vnpayDateFormat),
+		"vnp_CreateDate":      now,
+		"vnp_IpAddr":          "127.0.0.1",
+	}
+	fields["vnp_SecureHash"] = VNPaySign(p.cfg.HashSecret, joinFields(fields,
+		"vnp_RequestId", "vnp_Version", "vnp_Command", "vnp_TmnCode", "vnp_TxnRef",
+		"vnp_TransactionDate", "vnp_CreateDate", "vnp_IpAddr", "vnp_OrderInfo"))
+
+	resp, err := p.callAPI(ctx, fields)
+	if err != nil {
+		return nil, err
+	}
+	if resp.ResponseCode != "00" {
+		return nil, fmt.Errorf("vnpay querydr failed: %s %s", resp.ResponseCode, resp.Message)
+	}
+
+	amount, _ := strconv.ParseInt(resp.Amount, 10, 64)
+	result := &StatusResult{
+		Status:        models.PaymentStatusPending,
+		TransactionID: resp.TransactionNo,
+		Amount:        float64(amount) / 100,
+	}
+	switch resp.TransactionStatus {
+	case "00":
+		result.Status = models.PaymentStatusCompleted
+	case "01":
+		result.Status = models.PaymentStatusPending
+	default:
+		result.Status = models.PaymentStatusFailed
+	}
+	return result, nil
+}
+
+// Refund sends a full (02) or partial (03) refund request
+func (p *VNPayProvider) Refund(ctx context.Context, req *RefundRequest) (*RefundResult, error) {
+	transactionType := "03"
+	if toMinorUnits(req.Amount) >= toMinorUnits(req.PaymentAmount) {
+		transactionType = "02"
+	}
+
+	operator := req.Operator
+	if operator == "" {
+		operator = "system"
+	}
+	clientIP := req.ClientIP
+	if clientIP == "" {
+		clientIP = "127.0.0.1"
+	}
+
+	fields := map[string]string{
+		"vnp_RequestId":       newRequestID(),
+		"vnp_Version":         vnpayVersion,
+		"vnp_Command":         "refund",
+		"vnp_TmnCode":         p.cfg.TmnCode,
+		"vnp_TransactionType": transactionType,
+		"vnp_TxnRef":          req.PaymentRef,
+		"vnp_Amount":          strconv.FormatInt(toMinorUnits(req.Amount)*100, 10),
+		"vnp_OrderInfo":       "Refund " + req.RefundRef,
+		"vnp_TransactionNo":   req.TransactionID,
+		"vnp_TransactionDate": req.PaymentDate.In(vnpayZone).Format(vnpayDateFormat),
+		"vnp_CreateBy":        operator,
+		"vnp_CreateDate":      time.Now().In(vnpayZone).Format(vnpayDateFormat),
+		"vnp_IpAddr":          clientIP,
+	}
+	fields["vnp_SecureHash"] = VNPaySign(p.cfg.HashSecret, joinFields(fields,
+		"vnp_RequestId", "vnp_Version", "vnp_Command", "vnp_TmnCode", "vnp_TransactionType",
+		"vnp_TxnRef", "vnp_Amount", "vnp_TransactionNo", "vnp_TransactionDate", "vnp_CreateBy",
+		"vnp_CreateDate", "vnp_IpAddr", "vnp_OrderInfo"))
+
+	resp, err := p.callAPI(ctx, fields)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RefundResult{
+		Success:       resp.ResponseCode == "00",
+		TransactionID: resp.TransactionNo,
+		Message:       resp.Message,
+	}, nil
+}
+
+// callAPI posts a signed request to the merchant API and verifies the
+// signature of the response
+func (p *VNPayProvider) callAPI(ctx context.Context, fields map[string]string) (*vnpayAPIResponse, error) {
+	body, err := json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.cfg.APIURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	httpResp, err := p.cfg.HTTPClient.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+
+	var resp vnpayAPIResponse
+	if err := json.NewDecoder(httpResp.Body).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("vnpay: invalid response: %w", err)
+	}
+
+	raw := strings.Join([]string{
+		resp.ResponseID, resp.Command, resp.ResponseCode, resp.Message, resp.TmnCode,
+		resp.TxnRef, resp.Amount, resp.BankCode, resp.PayDate, resp.TransactionNo,
+		resp.TransactionType, resp.TransactionStatus, resp.OrderInfo, resp.PromotionCode,
+		resp.PromotionAmount,
+	}, "|")
+	if resp.Command == "refund" {
+		raw = strings.Join([]string{
+			resp.ResponseID, resp.Command, resp.ResponseCode, resp.Message, resp.TmnCode,
+			resp.TxnRef, resp.Amount, resp.BankCode, resp.PayDate, resp.TransactionNo,
+			resp.TransactionType, resp.TransactionStatus, resp.OrderInfo,
+		}, "|")
+	}
+	if !hmac.Equal([]byte(strings.ToLower(resp.SecureHash)), []byte(VNPaySign(p.cfg.HashSecret, raw))) {
+		return nil, ErrInvalidSignature
+	}
+
+	return &resp, nil
+}
+
+// VNPaySign returns the hex HMAC-SHA512 of data, as used by every VNPay
+// request and response
+func VNPaySign(secret, data string) string {
+	mac := hmac.New(sha512.New, []byte(secret))
+	mac.Write([]byte(data))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// joinFields joins the given keys of fields with "|", in order
+func joinFields(fields map[string]string, keys ...string) string {
+	values := make([]string, len(keys))
+	for i, key := range keys {
+		values[i] = fields[key]
+	}
+	return strings.Join(values, "|")
+}
+
+// newRequestID returns a unique ID for gateway API calls
+func newRequestID() string {
+	return primitive.NewObjectID().Hex()
+}
@@ -0,0 +1,116 @@
+package payments_test
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"phone-store-backend/internal/models"
+	"phone-store-backend/internal/modules/payments"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func newVNPay(g *testGateway, secret string) *payments.VNPayProvider {
+	return payments.NewVNPayProvider(payments.VNPayConfig{
+		TmnCode:    vnpayTmnCode,
+		HashSecret: secret,
+		PayURL:     g.URL + "/vnpay/pay",
+		APIURL:     g.URL + "/vnpay/api",
+		ReturnURL:  testReturnURL,
+	})
+}
+
+func TestVNPayCheckoutCallbackQueryAndRefund(t *testing.T) {
+	g := startGateway(t)
+	provider := newVNPay(g, vnpaySecret)
+	ctx := context.Background()
+
+	payment := &models.Payment{ID: primitive.NewObjectID(), Amount: 25990000, CreatedAt: time.Now()}
+	checkout, err := provider.CreateCheckout(ctx, &payments.CheckoutRequest{
+		PaymentRef:  payment.ID.Hex(),
+		OrderNumber: "ORD-1",
+		Amount:      payment.Amount,
+		Description: "Thanh toan don hang ORD-1",
+		ClientIP:    "127.0.0.1",
+		CreatedAt:   payment.CreatedAt,
+	})
+	if err != nil {
+		t.Fatalf("create checkout: %v", err)
+	}
+
+	// The gateway checks our signature before it takes the payment
+	resp := g.pay(t, checkout.CheckoutURL)
+	if location := resp.Header.Get("Location"); !strings.HasPrefix(location, testReturnURL+"?") {
+		t.Errorf("redirected to %q, want the return URL", location)
+	}
+
+	callback, err := provider.VerifyCallback(g.ipn(t))
+	if err != nil {
+		t.Fatalf("verify IPN: %v", err)
+	}
+	if !callback.Success || callback.PaymentRef != payment.ID.Hex() || callback.Amount != payment.Amount || callback.TransactionID == "" {
+		t.Errorf("callback = %+v, want a successful payment of %.0f for %s", callback, payment.Amount, payment.ID.Hex())
+	}
+
+	status, err := provider.QueryStatus(ctx, payment)
+	if err != nil {
+		t.Fatalf("query status: %v", err)
+	}
+	if status.Status != models.PaymentStatusCompleted || status.Amount != payment.Amount || status.TransactionID != callback.TransactionID {
+		t.Errorf("status = %+v, want completed %.0f with transaction %s", status, payment.Amount, callback.TransactionID)
+	}
+
+	refund, err := provider.Refund(ctx, &payments.RefundRequest{
+		RefundRef:     primitive.NewObjectID().Hex(),
+		PaymentRef:    payment.ID.Hex(),
+		TransactionID: callback.TransactionID,
+		Amount:        1000000,
+		PaymentAmount: payment.Amount,
+		PaymentDate:   payment.CreatedAt,
+		Reason:        "Return",
+	})
+	if err != nil {
+		t.Fatalf("refund: %v", err)
+	}
+	if !refund.Success {
+		t.Errorf("refund = %+v, want success", refund)
+	}
+}
+
+func TestVNPayRejectsBadSignatures(t *testing.T) {
+	g := startGateway(t)
+	provider := newVNPay(g, vnpaySecret)
+	ctx := context.Background()
+
+	payment := &models.Payment{ID: primitive.NewObjectID(), Amount: 25990000, CreatedAt: time.Now()}
+	checkout, err := provider.CreateCheckout(ctx, &payments.CheckoutRequest{
+		PaymentRef: payment.ID.Hex(),
+		Amount:     payment.Amount,
+		CreatedAt:  payment.CreatedAt,
+	})
+	if err != nil {
+		t.Fatalf("create checkout: %v", err)
+	}
+	g.pay(t, checkout.CheckoutURL)
+	ipn := g.ipn(t)
+
+	// A callback whose amount was changed after signing
+	tampered := httptest.NewRequest("GET", strings.Replace(ipn.URL.String(), "vnp_Amount=2599000000", "vnp_Amount=100", 1), nil)
+	if _, err := provider.VerifyCallback(tampered); !errors.Is(err, payments.ErrInvalidSignature) {
+		t.Errorf("tampered callback: err = %v, want ErrInvalidSignature", err)
+	}
+
+	// A callback signed with someone else's secret
+	if _, err := newVNPay(g, "other-secret").VerifyCallback(ipn); !errors.Is(err, payments.ErrInvalidSignature) {
+		t.Errorf("foreign callback: err = %v, want ErrInvalidSignature", err)
+	}
+
+	// An API response that does not carry our signature
+	if _, err := newVNPay(g, "other-secret").QueryStatus(ctx, payment); !errors.Is(err, payments.ErrInvalidSignature) {
+		t.Errorf("query with a foreign signature: err = %v, want ErrInvalidSignature", err)
+	}
+}
@@ -0,0 +1,229 @@
+package payments
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+
+	"phone-store-backend/internal/models"
+)
+
+const bankTransferCode = "BANK_TRANSFER"
+
+// Reconcile line statuses
+const (
+	LineMatched          = "MATCHED"
+	LineAlreadyProcessed = "ALREADY_PROCESSED"
+	LinePartial          = "PARTIAL"
+	LineOverpaid         = "OVERPAID"
+	LineUnmatched        = "UNMATCHED"
+)
+
+// statementColumns maps accepted CSV header names to a column
+var statementColumns = map[string]string{
+	"date":             "date",
+	"transaction date": "date",
+	"ngay":             "date",
+	"amount":           "amount",
+	"credit":           "amount",
+	"so tien":          "amount",
+	"description":      "description",
+	"memo":             "description",
+	"content":          "description",
+	"noi dung":         "description",
+	"reference":        "reference",
+	"ref":              "reference",
+	"transaction id":   "reference",
+	"so tham chieu":    "reference",
+}
+
+// pendingTransfer is a bank transfer payment waiting for money
+type pendingTransfer struct {
+	payment *models.Payment
+	order   *models.Order
+	memo    string
+}
+
+// ReconcileStatement matches the credit lines of a bank statement CSV to
+// pending bank transfers by memo and amount. Exact matches complete the
+// payment; everything else is reported for review. Lines go through the
+// payment ledger, so importing the same statement twice is harmless.
+func (s *Service) ReconcileStatement(ctx context.Context, statement io.Reader) (*ReconcileReport, error) {
+	reader := csv.NewReader(statement)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, errors.New("statement is empty or not a CSV file")
+	}
+	columns := make(map[string]int)
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if column, ok := statementColumns[name]; ok {
+			columns[column] = i
+		}
+	}
+	if _, ok := columns["amount"]; !ok {
+		return nil, errors.New("statement has no amount column")
+	}
+	if _, ok := columns["description"]; !ok {
+		return nil, errors.New("statement has no description column")
+	}
+
+	pending, err := s.pendingTransfers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ReconcileReport{Lines: []ReconcileLine{}}
+	for lineNo := 2; ; lineNo++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		amount, ok := parseStatementAmount(field(record, columns, "amount"))
+		if !ok {
+			// Debits, fees and blank lines are not customer transfers
+			continue
+		}
+
+		line := ReconcileLine{
+			Line:        lineNo,
+			Date:        field(record, columns, "date"),
+			Amount:      amount,
+			Description: field(record, columns, "description"),
+			Reference:   field(record, columns, "reference"),
+		}
+		s.reconcileLine(ctx, &line, pending)
+
+		report.TotalLines++
+		switch line.Status {
+		case LineMatched:
+			report.Matched++
+		case LineAlreadyProcessed:
+			report.AlreadyProcessed++
+		default:
+			report.NeedsReview++
+		}
+		report.Lines = append(report.Lines, line)
+	}
+
+	return report, nil
+}
+
+func (s *Service) reconcileLine(ctx context.Context, line *ReconcileLine, pending []*pendingTransfer) {
+	// Statement lines without a bank reference are keyed by their content
+	transactionID := line.Reference
+	if transactionID == "" {
+		sum := sha256.Sum256([]byte(line.Date + "|" + strconv.FormatFloat(line.Amount, 'f', 0, 64) + "|" + line.Description))
+		transactionID = "line:" + hex.EncodeToString(sum[:12])
+	}
+
+	if exists, err := s.repo.TransactionExists(ctx, bankTransferCode, transactionID); err == nil && exists {
+		line.Status = LineAlreadyProcessed
+		return
+	}
+
+	description := memoPattern.ReplaceAllString(strings.ToUpper(line.Description), "")
+	var match *pendingTransfer
+	for _, p := range pending {
+		if p.payment != nil && strings.Contains(description, p.memo) {
+			match = p
+			break
+		}
+	}
+	if match == nil {
+		line.Status = LineUnmatched
+		return
+	}
+
+	line.OrderNumber = match.order.OrderNumber
+	line.PaymentID = match.payment.ID.Hex()
+	line.Expected = match.payment.Amount
+
+	switch {
+	case toMinorUnits(line.Amount) < toMinorUnits(match.payment.Amount):
+		line.Status = LinePartial
+		return
+	case toMinorUnits(line.Amount) > toMinorUnits(match.payment.Amount):
+		line.Status = LineOverpaid
+		return
+	}
+
+	err := s.applyTransaction(ctx, bankTransferCode, transactionID, match.payment, &CallbackResult{
+		PaymentRef:    match.payment.ID.Hex(),
+		TransactionID: transactionID,
+		Amount:        line.Amount,
+		Success:       true,
+		Message:       line.Description,
+	})
+	switch {
+	case err == nil:
+		line.Status = LineMatched
+		// A payment is only completed once per import
+		match.payment = nil
+	case errors.Is(err, ErrAlreadyProcessed):
+		line.Status = LineAlreadyProcessed
+	default:
+		line.Status = LineUnmatched
+	}
+}
+
+// pendingTransfers loads the pending bank transfers with their orders
+func (s *Service) pendingTransfers(ctx context.Context) ([]*pendingTransfer, error) {
+	payments, err := s.repo.FindPendingPaymentsByMethod(ctx, bankTransferCode)
+	if err != nil {
+		return nil, err
+	}
+
+	var transfers []*pendingTransfer
+	for _, payment := range payments {
+		order, err := s.repo.FindOrderByID(ctx, payment.OrderID)
+		if err != nil {
+			continue
+		}
+		transfers = append(transfers, &pendingTransfer{
+			payment: payment,
+			order:   order,
+			memo:    TransferMemo(order.OrderNumber),
+		})
+	}
+	return transfers, nil
+}
+
+func field(record []string, columns map[string]int, name string) string {
+	i, ok := columns[name]
+	if !ok || i >= len(record) {
+		return ""
+	}
+	return strings.TrimSpace(record[i])
+}
+
+// parseStatementAmount reads a VND credit such as "29,990,000" or
+// "29.990.000". VND has no minor unit, so separators are dropped.
+func parseStatementAmount(value string) (float64, bool) {
+	if strings.HasPrefix(value, "-") {
+		return 0, false
+	}
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, value)
+	amount, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil || amount <= 0 {
+		return 0, false
+	}
+	return float64(amount), true
+}
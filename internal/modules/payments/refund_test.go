@@ -0,0 +1,92 @@
+package payments_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"phone-store-backend/internal/models"
+	"phone-store-backend/internal/modules/payments"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestProcessRefundConcurrentCallsRefundOnce(t *testing.T) {
+	const calls = 10
+	gateway := &stubProvider{refundDelay: 50 * time.Millisecond}
+	f := newPaymentFixture(t, gateway)
+	ctx := context.Background()
+
+	// A captured payment with a pending full refund already reserved on it
+	payment := &models.Payment{Method: stubMethod, Amount: orderTotal, Refunded: orderTotal, Status: models.PaymentStatusCompleted, TransactionID: "T-1"}
+	order := f.addOrder(t, models.OrderStatusCanceled, payment)
+	refund := &models.Refund{
+		ID:        primitive.NewObjectID(),
+		OrderID:   order.ID,
+		PaymentID: payment.ID,
+		Amount:    orderTotal,
+		Status:    models.RefundStatusPending,
+		Reason:    "Order canceled",
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	if _, err := f.db.Collection("refunds").InsertOne(ctx, refund); err != nil {
+		t.Fatalf("seed refund: %v", err)
+	}
+
+	var (
+		wg         sync.WaitGroup
+		mu         sync.Mutex
+		processed  int
+		unexpected []error
+	)
+	start := make(chan struct{})
+	for i := 0; i < calls; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			_, err := f.service.ProcessRefund(ctx, refund.ID.Hex())
+
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				processed++
+			case !errors.Is(err, payments.ErrRefundNotPending):
+				unexpected = append(unexpected, err)
+			}
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	for _, err := range unexpected {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if processed != 1 {
+		t.Errorf("%d calls processed the refund, want 1", processed)
+	}
+	if sent := gateway.refundCount(); sent != 1 {
+		t.Errorf("gateway asked to refund %d times, want 1", sent)
+	}
+
+	var saved models.Refund
+	if err := f.db.Collection("refunds").FindOne(ctx, bson.M{"_id": refund.ID}).Decode(&saved); err != nil {
+		t.Fatalf("load refund: %v", err)
+	}
+	if saved.Status != models.RefundStatusCompleted {
+		t.Errorf("refund is %s, want %s", saved.Status, models.RefundStatusCompleted)
+	}
+
+	var refunded models.Order
+	if err := f.db.Collection("orders").FindOne(ctx, bson.M{"_id": order.ID}).Decode(&refunded); err != nil {
+		t.Fatalf("load order: %v", err)
+	}
+	if refunded.RefundedTotal != orderTotal {
+		t.Errorf("order refunded total = %.0f, want %d", refunded.RefundedTotal, orderTotal)
+	}
+}
@@ -2,26 +2,50 @@ package payments
 
 import (
 	"context"
+	"time"
 
 	"phone-store-backend/internal/models"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type Repository struct {
+	db                      *mongo.Database
 	paymentCollection       *mongo.Collection
 	paymentMethodCollection *mongo.Collection
+	refundCollection        *mongo.Collection
+	orderCollection         *mongo.Collection
+	transactionCollection   *mongo.Collection
 }
 
 func NewRepository(db *mongo.Database) *Repository {
 	return &Repository{
+		db:                      db,
 		paymentCollection:       db.Collection("payments"),
 		paymentMethodCollection: db.Collection("payment_methods"),
+		refundCollection:        db.Collection("refunds"),
+		orderCollection:         db.Collection("orders"),
+		transactionCollection:   db.Collection("payment_transactions"),
 	}
 }
 
+// WithTransaction runs fn inside a multi-document transaction
+func (r *Repository) WithTransaction(ctx context.Context, fn func(sessCtx mongo.SessionContext) error) error {
+	session, err := r.db.Client().StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sessCtx)
+	})
+	return err
+}
+
 // FindAllPaymentMethods returns all active payment methods
 func (r *Repository) FindAllPaymentMethods(ctx context.Context) ([]*models.PaymentMethod, error) {
 	cursor, err := r.paymentMethodCollection.Find(ctx, bson.M{"isActive": true})
@@ -38,10 +62,11 @@ func (r *Repository) FindAllPaymentMethods(ctx context.Context) ([]*models.Payme
 	return methods, nil
 }
 
-// FindPaymentByOrderID finds payment by order ID
+// FindPaymentByOrderID finds the latest payment of an order
 func (r *Repository) FindPaymentByOrderID(ctx context.Context, orderID primitive.ObjectID) (*models.Payment, error) {
 	var payment models.Payment
-	err := r.paymentCollection.FindOne(ctx, bson.M{"orderId": orderID}).Decode(&payment)
+	opts := options.FindOne().SetSort(bson.D{{Key: "createdAt", Value: -1}})
+	err := r.paymentCollection.FindOne(ctx, bson.M{"orderId": orderID}, opts).Decode(&payment)
 	if err != nil {
 		return nil, err
 	}
@@ -73,3 +98,250 @@ func (r *Repository) FindPaymentMethodByCode(ctx context.Context, code string) (
 	}
 	return &method, nil
 }
+
+// FindPaymentByID finds payment by ID
+func (r *Repository) FindPaymentByID(ctx context.Context, id primitive.ObjectID) (*models.Payment, error) {
+	var payment models.Payment
+	err := r.paymentCollection.FindOne(ctx, bson.M{"_id": id}).Decode(&payment)
+	if err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
+
+// FindCompletedPaymentByOrderID finds the latest completed payment of an order
+func (r *Repository) FindCompletedPaymentByOrderID(ctx context.Context, orderID primitive.ObjectID) (*models.Payment, error) {
+	var payment models.Payment
+	opts := options.FindOne().SetSort(bson.D{{Key: "createdAt", Value: -1}})
+	err := r.paymentCollection.FindOne(ctx, bson.M{
+		"orderId": orderID,
+		"status":  models.PaymentStatusCompleted,
+	}, opts).Decode(&payment)
+	if err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
+
+// ReserveRefund adds amount to the payment's refunded total only if the
+// result stays within the captured amount. It returns false when the
+// payment is not completed or the refund would exceed what was captured.
+func (r *Repository) ReserveRefund(ctx context.Context, paymentID primitive.ObjectID, amount float64) (bool, error) {
+	result, err := r.paymentCollection.UpdateOne(
+		ctx,
+		bson.M{
+			"_id":    paymentID,
+			"status": models.PaymentStatusCompleted,
+			"$expr": bson.M{"$lte": bson.A{
+				bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$refunded", 0}}, amount}},
+				"$amount",
+			}},
+		},
+		bson.M{
+			"$inc": bson.M{"refunded": amount},
+			"$set": bson.M{"updatedAt": time.Now()},
+		},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
+// ReleaseRefund gives back a reservation made by ReserveRefund
+func (r *Repository) ReleaseRefund(ctx context.Context, paymentID primitive.ObjectID, amount float64) error {
+	_, err := r.paymentCollection.UpdateOne(
+		ctx,
+		bson.M{"_id": paymentID},
+		bson.M{
+			"$inc": bson.M{"refunded": -amount},
+			"$set": bson.M{"updatedAt": time.Now()},
+		},
+	)
+	return err
+}
+
+// Refund methods
+func (r *Repository) CreateRefund(ctx context.Context, refund *models.Refund) error {
+	_, err := r.refundCollection.InsertOne(ctx, refund)
+	return err
+}
+
+func (r *Repository) FindRefundByID(ctx context.Context, id primitive.ObjectID) (*models.Refund, error) {
+	var refund models.Refund
+	err := r.refundCollection.FindOne(ctx, bson.M{"_id": id}).Decode(&refund)
+	if err != nil {
+		return nil, err
+	}
+	return &refund, nil
+}
+
+func (r *Repository) FindRefunds(ctx context.Context, filter bson.M, opts *options.FindOptions) ([]*models.Refund, error) {
+	cursor, err := r.refundCollection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var refunds []*models.Refund
+	if err := cursor.All(ctx, &refunds); err != nil {
+		return nil, err
+	}
+	return refunds, nil
+}
+
+func (r *Repository) CountRefunds(ctx context.Context, filter bson.M) (int64, error) {
+	return r.refundCollection.CountDocuments(ctx, filter)
+}
+
+// UpdateRefundStatus moves a refund that is in one of the from statuses to
+// status, returning false if it was in none of them
+func (r *Repository) UpdateRefundStatus(ctx context.Context, id primitive.ObjectID, from []models.RefundStatus, status models.RefundStatus) (bool, error) {
+	result, err := r.refundCollection.UpdateOne(
+		ctx,
+		bson.M{"_id": id, "status": bson.M{"$in": from}},
+		bson.M{"$set": bson.M{"status": status, "updatedAt": time.Now()}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount > 0, nil
+}
+
+// ClaimRefund moves a pending refund to PROCESSING, returning false when
+// another request claimed or settled it first
+func (r *Repository) ClaimRefund(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	return r.UpdateRefundStatus(ctx, id, []models.RefundStatus{models.RefundStatusPending}, models.RefundStatusProcessing)
+}
+
+// SumCompletedPayments returns the amount captured for an order
+func (r *Repository) SumCompletedPayments(ctx context.Context, orderID primitive.ObjectID) (float64, error) {
+	cursor, err := r.paymentCollection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"orderId": orderID, "status": models.PaymentStatusCompleted}}},
+		{{Key: "$group", Value: bson.M{"_id": nil, "total": bson.M{"$sum": "$amount"}}}},
+	})
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var result struct {
+		Total float64 `bson:"total"`
+	}
+	if cursor.Next(ctx) {
+		if err := cursor.Decode(&result); err != nil {
+			return 0, err
+		}
+	}
+	return result.Total, cursor.Err()
+}
+
+// VoidPendingPayments voids the pending payments of an order
+func (r *Repository) VoidPendingPayments(ctx context.Context, orderID primitive.ObjectID) error {
+	_, err := r.paymentCollection.UpdateMany(
+		ctx,
+		bson.M{"orderId": orderID, "status": models.PaymentStatusPending},
+		bson.M{"$set": bson.M{
+			"status":    models.PaymentStatusVoided,
+			"updatedAt": time.Now(),
+		}},
+	)
+	return err
+}
+
+// CreateTransaction records a gateway notification. It returns false when the
+// same provider transaction has already been recorded.
+func (r *Repository) CreateTransaction(ctx context.Context, tx *models.PaymentTransaction) (bool, error) {
+	_, err := r.transactionCollection.InsertOne(ctx, tx)
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// TransactionExists reports whether a provider transaction is in the ledger
+func (r *Repository) TransactionExists(ctx context.Context, provider, transactionID string) (bool, error) {
+	count, err := r.transactionCollection.CountDocuments(ctx, bson.M{
+		"provider":      provider,
+		"transactionId": transactionID,
+	})
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// FindPendingPaymentsByMethod finds the pending payments of a method
+func (r *Repository) FindPendingPaymentsByMethod(ctx context.Context, method string) ([]*models.Payment, error) {
+	cursor, err := r.paymentCollection.Find(ctx, bson.M{
+		"method": method,
+		"status": models.PaymentStatusPending,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var payments []*models.Payment
+	if err := cursor.All(ctx, &payments); err != nil {
+		return nil, err
+	}
+	return payments, nil
+}
+
+// CapturePayment marks a payment as completed unless it already is
+func (r *Repository) CapturePayment(ctx context.Context, id primitive.ObjectID, transactionID string, paidAt time.Time) (bool, error) {
+	result, err := r.paymentCollection.UpdateOne(
+		ctx,
+		bson.M{"_id": id, "status": bson.M{"$ne": models.PaymentStatusCompleted}},
+		bson.M{"$set": bson.M{
+			"status":        models.PaymentStatusCompleted,
+			"transactionId": transactionID,
+			"paidAt":        paidAt,
+			"updatedAt":     paidAt,
+		}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount > 0, nil
+}
+
+// FailPayment marks a pending payment as failed
+func (r *Repository) FailPayment(ctx context.Context, id primitive.ObjectID) error {
+	_, err := r.paymentCollection.UpdateOne(
+		ctx,
+		bson.M{"_id": id, "status": models.PaymentStatusPending},
+		bson.M{"$set": bson.M{
+			"status":    models.PaymentStatusFailed,
+			"updatedAt": time.Now(),
+		}},
+	)
+	return err
+}
+
+// FindOrderByID finds the order a payment belongs to
+func (r *Repository) FindOrderByID(ctx context.Context, id primitive.ObjectID) (*models.Order, error) {
+	var order models.Order
+	err := r.orderCollection.FindOne(ctx, bson.M{"_id": id}).Decode(&order)
+	if err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+// AddOrderRefundedTotal increases the refunded total shown on the order
+func (r *Repository) AddOrderRefundedTotal(ctx context.Context, orderID primitive.ObjectID, amount float64) error {
+	_, err := r.orderCollection.UpdateOne(
+		ctx,
+		bson.M{"_id": orderID},
+		bson.M{
+			"$inc": bson.M{"refundedTotal": amount},
+			"$set": bson.M{"updatedAt": time.Now()},
+		},
+	)
+	return err
+}
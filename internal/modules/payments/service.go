@@ -3,23 +3,83 @@ package payments
 import (
 	"context"
 	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
 	"time"
 
 	"phone-store-backend/internal/models"
 
+	"github.com/skip2/go-qrcode"
+
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+var (
+	// ErrRefundExceedsCaptured is returned when a refund would take the total
+	// refunded above what was captured for the payment
+	ErrRefundExceedsCaptured = errors.New("refund exceeds the captured amount")
+
+	// ErrPaymentNotFound is returned when a callback references no payment
+	// of the calling provider
+	ErrPaymentNotFound = errors.New("payment not found")
+
+	// ErrAmountMismatch is returned when a client or callback amount differs
+	// from what the server expects
+	ErrAmountMismatch = errors.New("amount does not match the payment")
+
+	// ErrOrderNotPayable is returned when an order is not pending or has no
+	// balance left to pay
+	ErrOrderNotPayable = errors.New("order has nothing left to pay")
+
+	// ErrMethodMismatch is returned when a payment uses another method than
+	// the one chosen for the order at checkout
+	ErrMethodMismatch = errors.New("payment method differs from the one chosen at checkout")
+
+	// ErrAlreadyProcessed is returned when a gateway transaction has already
+	// been applied; gateways retry until they get an acknowledgement
+	ErrAlreadyProcessed = errors.New("transaction already processed")
+
+	// ErrRefundNotPending is returned when a refund was settled, or is
+	// being sent to the gateway, by another request
+	ErrRefundNotPending = errors.New("refund has already been settled or is being processed")
+)
+
+// OrderPayer moves an order to PAID inside the caller's transaction once its
+// payment is captured. It returns true when the order was canceled and the
+// payment has to be refunded. Implemented by orders.Service.
+type OrderPayer interface {
+	MarkOrderPaid(sessCtx mongo.SessionContext, orderID primitive.ObjectID, note string) (bool, error)
+}
+
 type Service struct {
-	repo *Repository
+	repo      *Repository
+	orders    OrderPayer
+	providers map[string]Provider
+}
+
+func NewService(repo *Repository, orders OrderPayer, providers ...Provider) *Service {
+	registry := make(map[string]Provider, len(providers))
+	for _, p := range providers {
+		registry[p.Code()] = p
+	}
+	return &Service{repo: repo, orders: orders, providers: registry}
 }
 
-func NewService(repo *Repository) *Service {
-	return &Service{repo: repo}
+// provider returns the gateway adapter for a payment method code
+func (s *Service) provider(code string) (Provider, error) {
+	p, ok := s.providers[code]
+	if !ok {
+		return nil, fmt.Errorf("payment method %s is not supported", code)
+	}
+	return p, nil
 }
 
-// GetPaymentMethods returns all active payment methods
+// GetPaymentMethods returns the active payment methods that have a provider
 func (s *Service) GetPaymentMethods(ctx context.Context) ([]PaymentMethodResponse, error) {
 	methods, err := s.repo.FindAllPaymentMethods(ctx)
 	if err != nil {
@@ -28,62 +88,161 @@ func (s *Service) GetPaymentMethods(ctx context.Context) ([]PaymentMethodRespons
 
 	var response []PaymentMethodResponse
 	for _, method := range methods {
+		if _, ok := s.providers[method.Code]; !ok {
+			continue
+		}
 		response = append(response, PaymentMethodResponse{
-			ID:          method.ID.Hex(),
-			Name:        method.Name,
-			Code:        method.Code,
-			Description: method.Description,
+			ID:                method.ID.Hex(),
+			Name:              method.Name,
+			Code:              method.Code,
+			Description:       method.Description,
+			MaxOrderValue:     method.MaxOrderValue,
+			DisabledProvinces: method.DisabledProvinces,
 		})
 	}
 
 	return response, nil
 }
 
-// CreatePayment creates a payment for an order
-func (s *Service) CreatePayment(ctx context.Context, req *CreatePaymentRequest) error {
+// CreatePayment creates a payment for the caller's order and starts checkout
+// with the gateway of the chosen payment method. The amount is always the
+// order's outstanding balance; a client-supplied amount must match it.
+func (s *Service) CreatePayment(ctx context.Context, userID string, req *CreatePaymentRequest, clientIP string) (*CreatePaymentResponse, error) {
 	orderID, err := primitive.ObjectIDFromHex(req.OrderID)
 	if err != nil {
-		return errors.New("invalid order ID")
+		return nil, errors.New("invalid order ID")
+	}
+
+	order, err := s.repo.FindOrderByID(ctx, orderID)
+	if err != nil || order.UserID.Hex() != userID {
+		return nil, ErrPaymentNotFound
+	}
+	if order.Status != models.OrderStatusPending {
+		return nil, ErrOrderNotPayable
+	}
+
+	// The method was checked against the order at checkout, so it cannot be
+	// swapped for another one here
+	code := req.PaymentMethodCode
+	if code == "" {
+		code = order.PaymentMethod
+	}
+	if order.PaymentMethod != "" && code != order.PaymentMethod {
+		return nil, ErrMethodMismatch
+	}
+
+	method, err := s.repo.FindPaymentMethodByCode(ctx, code)
+	if err != nil {
+		return nil, errors.New("payment method not available")
+	}
+
+	provider, err := s.provider(method.Code)
+	if err != nil {
+		return nil, err
+	}
+
+	captured, err := s.repo.SumCompletedPayments(ctx, order.ID)
+	if err != nil {
+		return nil, err
+	}
+	amount := amountDue(order.Total, captured)
+	if amount <= 0 {
+		return nil, ErrOrderNotPayable
+	}
+	if req.Amount != 0 && toMinorUnits(req.Amount) != toMinorUnits(amount) {
+		return nil, ErrAmountMismatch
 	}
 
 	// Create payment
 	payment := &models.Payment{
+		ID:        primitive.NewObjectID(),
 		OrderID:   orderID,
-		Amount:    req.Amount,
+		Method:    method.Code,
+		Amount:    amount,
 		Status:    models.PaymentStatusPending,
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 	}
 
-	// If COD, payment is pending until delivered
-	// Other methods would need payment gateway integration
+	// A new attempt supersedes any pending one, so a late callback for the
+	// old attempt cannot leave two payments open for the same balance
+	err = s.repo.WithTransaction(ctx, func(sessCtx mongo.SessionContext) error {
+		if err := s.repo.VoidPendingPayments(sessCtx, orderID); err != nil {
+			return err
+		}
+		return s.repo.CreatePayment(sessCtx, payment)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// COD stays pending until delivered; online methods redirect to the gateway
+	checkout, err := provider.CreateCheckout(ctx, &CheckoutRequest{
+		PaymentRef:  payment.ID.Hex(),
+		OrderNumber: order.OrderNumber,
+		Amount:      payment.Amount,
+		Description: "Thanh toan don hang " + order.OrderNumber,
+		ClientIP:    clientIP,
+		CreatedAt:   payment.CreatedAt,
+	})
+	if err != nil {
+		s.repo.UpdatePayment(ctx, payment.ID, bson.M{
+			"status":    models.PaymentStatusFailed,
+			"updatedAt": time.Now(),
+		})
+		return nil, fmt.Errorf("failed to start checkout: %w", err)
+	}
 
-	return s.repo.CreatePayment(ctx, payment)
+	return &CreatePaymentResponse{
+		Payment:     *transformPayment(payment, method.Name),
+		CheckoutURL: checkout.CheckoutURL,
+		QRPayload:   checkout.QRPayload,
+	}, nil
 }
 
-// GetPaymentByOrderID returns payment information for an order
-func (s *Service) GetPaymentByOrderID(ctx context.Context, orderID string) (*PaymentResponse, error) {
+// amountDue is the order total minus what has already been captured, in
+// whole VND so that float leftovers never look like money still owed
+func amountDue(total, captured float64) float64 {
+	return float64(toMinorUnits(total) - toMinorUnits(captured))
+}
+
+// GetPaymentByOrderID returns payment information for an order. Customers
+// only see payments of their own orders.
+func (s *Service) GetPaymentByOrderID(ctx context.Context, userID, orderID string, isStaff bool) (*PaymentResponse, error) {
 	objectID, err := primitive.ObjectIDFromHex(orderID)
 	if err != nil {
 		return nil, errors.New("invalid order ID")
 	}
 
+	if !isStaff {
+		order, err := s.repo.FindOrderByID(ctx, objectID)
+		if err != nil || order.UserID.Hex() != userID {
+			return nil, ErrPaymentNotFound
+		}
+	}
+
 	payment, err := s.repo.FindPaymentByOrderID(ctx, objectID)
 	if err != nil {
-		return nil, errors.New("payment not found")
+		return nil, ErrPaymentNotFound
 	}
 
 	// Get payment method name
 	var paymentMethodName string
-	if paymentMethod, err := s.repo.FindPaymentMethodByCode(ctx, ""); err == nil {
+	if paymentMethod, err := s.repo.FindPaymentMethodByCode(ctx, payment.Method); err == nil {
 		paymentMethodName = paymentMethod.Name
 	}
 
+	return transformPayment(payment, paymentMethodName), nil
+}
+
+func transformPayment(payment *models.Payment, methodName string) *PaymentResponse {
 	response := &PaymentResponse{
 		ID:            payment.ID.Hex(),
 		OrderID:       payment.OrderID.Hex(),
-		PaymentMethod: paymentMethodName,
+		PaymentMethod: methodName,
+		MethodCode:    payment.Method,
 		Amount:        payment.Amount,
+		Refunded:      payment.Refunded,
 		Status:        string(payment.Status),
 		CreatedAt:     payment.CreatedAt,
 	}
@@ -92,7 +251,181 @@ func (s *Service) GetPaymentByOrderID(ctx context.Context, orderID string) (*Pay
 		response.PaidAt = *payment.PaidAt
 	}
 
-	return response, nil
+	return response
+}
+
+// HandleCallback verifies and applies a gateway notification. Each gateway
+// transaction is applied exactly once: replays return ErrAlreadyProcessed.
+func (s *Service) HandleCallback(ctx context.Context, providerCode string, r *http.Request) error {
+	provider, ok := s.providers[providerCode]
+	if !ok {
+		return ErrNotSupported
+	}
+
+	result, err := provider.VerifyCallback(r)
+	if err != nil {
+		return err
+	}
+
+	paymentID, err := primitive.ObjectIDFromHex(result.PaymentRef)
+	if err != nil {
+		return ErrPaymentNotFound
+	}
+	payment, err := s.repo.FindPaymentByID(ctx, paymentID)
+	if err != nil || payment.Method != provider.Code() {
+		return ErrPaymentNotFound
+	}
+	if toMinorUnits(result.Amount) != toMinorUnits(payment.Amount) {
+		return ErrAmountMismatch
+	}
+
+	// Failed attempts may come without a gateway transaction number
+	transactionID := result.TransactionID
+	if transactionID == "" || transactionID == "0" {
+		transactionID = "ref:" + result.PaymentRef
+	}
+
+	return s.applyTransaction(ctx, provider.Code(), transactionID, payment, result)
+}
+
+// applyTransaction records a gateway transaction in the ledger and applies it
+// to the payment and order. It returns ErrAlreadyProcessed for replays.
+func (s *Service) applyTransaction(ctx context.Context, providerCode, transactionID string, payment *models.Payment, result *CallbackResult) error {
+	return s.repo.WithTransaction(ctx, func(sessCtx mongo.SessionContext) error {
+		now := time.Now()
+		created, err := s.repo.CreateTransaction(sessCtx, &models.PaymentTransaction{
+			ID:            primitive.NewObjectID(),
+			Provider:      providerCode,
+			TransactionID: transactionID,
+			PaymentID:     payment.ID,
+			OrderID:       payment.OrderID,
+			Amount:        result.Amount,
+			Success:       result.Success,
+			Message:       result.Message,
+			CreatedAt:     now,
+		})
+		if err != nil {
+			return err
+		}
+		if !created {
+			return ErrAlreadyProcessed
+		}
+
+		if !result.Success {
+			return s.repo.FailPayment(sessCtx, payment.ID)
+		}
+
+		order, err := s.repo.FindOrderByID(sessCtx, payment.OrderID)
+		if err != nil {
+			return err
+		}
+		capturedBefore, err := s.repo.SumCompletedPayments(sessCtx, payment.OrderID)
+		if err != nil {
+			return err
+		}
+
+		captured, err := s.repo.CapturePayment(sessCtx, payment.ID, result.TransactionID, now)
+		if err != nil {
+			return err
+		}
+
+		// Money received for an order that is already paid in full, e.g. an
+		// attempt that was voided and then paid anyway, or a second charge of
+		// the same payment, is kept as captured and queued for refund
+		outcome := classifyCapture(order.Total, capturedBefore, payment.Amount)
+		if !captured || outcome == captureDuplicate {
+			log.Printf("Warning: order %s paid twice (payment %s, transaction %s), refund required",
+				payment.OrderID.Hex(), payment.ID.Hex(), result.TransactionID)
+			return s.queueRefund(sessCtx, payment, "Duplicate payment: order already paid")
+		}
+		if outcome == capturePartial {
+			return nil
+		}
+
+		refund, err := s.orders.MarkOrderPaid(sessCtx, payment.OrderID, "Paid via "+providerCode)
+		if err != nil {
+			return err
+		}
+		if refund {
+			return s.queueRefund(sessCtx, payment, "Paid after the order was canceled")
+		}
+		return nil
+	})
+}
+
+// captureOutcome is what a successful capture does to its order
+type captureOutcome int
+
+const (
+	captureDuplicate  captureOutcome = iota // The order was already paid in full
+	capturePartial                          // Part of the total is still due
+	capturePaidInFull                       // The order is now paid in full
+)
+
+// classifyCapture tells what capturing amount means for an order of total
+// that had capturedBefore captured already
+func classifyCapture(total, capturedBefore, amount float64) captureOutcome {
+	switch {
+	case toMinorUnits(capturedBefore) >= toMinorUnits(total):
+		return captureDuplicate
+	case toMinorUnits(capturedBefore+amount) < toMinorUnits(total):
+		return capturePartial
+	default:
+		return capturePaidInFull
+	}
+}
+
+// queueRefund records a pending refund of what is left of a captured
+// payment, so staff find it in the refunds list
+func (s *Service) queueRefund(sessCtx mongo.SessionContext, payment *models.Payment, reason string) error {
+	_, err := s.RecordRefund(sessCtx, &RefundInput{
+		OrderID:   payment.OrderID,
+		PaymentID: payment.ID,
+		Reason:    reason,
+	})
+	if errors.Is(err, ErrRefundExceedsCaptured) {
+		// Already refunded in full
+		return nil
+	}
+	return err
+}
+
+// GetPaymentQR renders the VietQR code of an order's pending bank transfer
+// as a PNG
+func (s *Service) GetPaymentQR(ctx context.Context, userID, orderID string, isStaff bool) ([]byte, error) {
+	objectID, err := primitive.ObjectIDFromHex(orderID)
+	if err != nil {
+		return nil, errors.New("invalid order ID")
+	}
+
+	order, err := s.repo.FindOrderByID(ctx, objectID)
+	if err != nil || (!isStaff && order.UserID.Hex() != userID) {
+		return nil, ErrPaymentNotFound
+	}
+
+	payment, err := s.repo.FindPaymentByOrderID(ctx, objectID)
+	if err != nil || payment.Status != models.PaymentStatusPending {
+		return nil, ErrPaymentNotFound
+	}
+
+	provider, err := s.provider(payment.Method)
+	if err != nil {
+		return nil, err
+	}
+	checkout, err := provider.CreateCheckout(ctx, &CheckoutRequest{
+		PaymentRef:  payment.ID.Hex(),
+		OrderNumber: order.OrderNumber,
+		Amount:      payment.Amount,
+		CreatedAt:   payment.CreatedAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if checkout.QRPayload == "" {
+		return nil, errors.New("payment method has no QR code")
+	}
+
+	return qrcode.Encode(checkout.QRPayload, qrcode.Medium, 512)
 }
 
 // UpdatePaymentStatus updates payment status (for admin/system)
@@ -119,3 +452,291 @@ func (s *Service) UpdatePaymentStatus(ctx context.Context, orderID, status strin
 
 	return s.repo.UpdatePayment(ctx, payment.ID, update)
 }
+
+// RefundInput describes a refund to record against a payment
+type RefundInput struct {
+	OrderID         primitive.ObjectID
+	PaymentID       primitive.ObjectID // Zero means the order's latest completed payment
+	ReturnRequestID *primitive.ObjectID
+	Amount          float64 // Zero means a full refund of what is left
+	Reason          string
+	OperatorID      primitive.ObjectID
+}
+
+// IssueRefund records a pending refund for an order
+func (s *Service) IssueRefund(ctx context.Context, operatorID string, req *CreateRefundRequest) (*RefundResponse, error) {
+	opID, err := primitive.ObjectIDFromHex(operatorID)
+	if err != nil {
+		return nil, errors.New("invalid user ID")
+	}
+
+	orderID, err := primitive.ObjectIDFromHex(req.OrderID)
+	if err != nil {
+		return nil, errors.New("invalid order ID")
+	}
+
+	input := &RefundInput{
+		OrderID:    orderID,
+		Amount:     req.Amount,
+		Reason:     req.Reason,
+		OperatorID: opID,
+	}
+
+	if req.PaymentID != "" {
+		if input.PaymentID, err = primitive.ObjectIDFromHex(req.PaymentID); err != nil {
+			return nil, errors.New("invalid payment ID")
+		}
+	}
+
+	var refund *models.Refund
+	err = s.repo.WithTransaction(ctx, func(sessCtx mongo.SessionContext) error {
+		var err error
+		refund, err = s.RecordRefund(sessCtx, input)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Online gateways refund straight away; other methods stay pending
+	// until an operator settles them by hand
+	if err := s.processRefund(ctx, refund); err != nil && !errors.Is(err, ErrNotSupported) {
+		log.Printf("Warning: refund %s could not be sent to the gateway: %v", refund.ID.Hex(), err)
+	}
+
+	return transformRefund(refund), nil
+}
+
+// ProcessRefund sends a pending refund to the payment's gateway
+func (s *Service) ProcessRefund(ctx context.Context, refundID string) (*RefundResponse, error) {
+	id, err := primitive.ObjectIDFromHex(refundID)
+	if err != nil {
+		return nil, errors.New("invalid refund ID")
+	}
+
+	refund, err := s.repo.FindRefundByID(ctx, id)
+	if err != nil {
+		return nil, errors.New("refund not found")
+	}
+
+	if err := s.processRefund(ctx, refund); err != nil {
+		return nil, err
+	}
+	return transformRefund(refund), nil
+}
+
+// processRefund asks the gateway to send the money back and settles the
+// refund with the outcome. The refund is claimed first, so concurrent calls
+// for the same refund reach the gateway once. refund.Status is updated in
+// place.
+func (s *Service) processRefund(ctx context.Context, refund *models.Refund) error {
+	payment, err := s.repo.FindPaymentByID(ctx, refund.PaymentID)
+	if err != nil {
+		return errors.New("payment not found")
+	}
+
+	provider, err := s.provider(payment.Method)
+	if err != nil {
+		return ErrNotSupported
+	}
+
+	claimed, err := s.repo.ClaimRefund(ctx, refund.ID)
+	if err != nil {
+		return err
+	}
+	if !claimed {
+		return ErrRefundNotPending
+	}
+
+	result, err := provider.Refund(ctx, &RefundRequest{
+		RefundRef:     refund.ID.Hex(),
+		PaymentRef:    payment.ID.Hex(),
+		TransactionID: payment.TransactionID,
+		Amount:        refund.Amount,
+		PaymentAmount: payment.Amount,
+		PaymentDate:   payment.CreatedAt,
+		Reason:        refund.Reason,
+		Operator:      refund.CreatedBy.Hex(),
+	})
+	if err != nil {
+		// No answer from the gateway: back to pending so it can be retried
+		processing := []models.RefundStatus{models.RefundStatusProcessing}
+		if _, releaseErr := s.repo.UpdateRefundStatus(ctx, refund.ID, processing, models.RefundStatusPending); releaseErr != nil {
+			log.Printf("Warning: refund %s left processing: %v", refund.ID.Hex(), releaseErr)
+		}
+		return err
+	}
+
+	status := models.RefundStatusCompleted
+	if !result.Success {
+		status = models.RefundStatusFailed
+	}
+	if err := s.settleRefund(ctx, refund, models.RefundStatusProcessing, status); err != nil {
+		return err
+	}
+	refund.Status = status
+	return nil
+}
+
+// RecordRefund reserves the refund amount on the payment and inserts a
+// pending refund. It must be called inside a transaction so the reservation
+// and the refund record are written together.
+func (s *Service) RecordRefund(sessCtx mongo.SessionContext, in *RefundInput) (*models.Refund, error) {
+	var payment *models.Payment
+	var err error
+	if in.PaymentID.IsZero() {
+		payment, err = s.repo.FindCompletedPaymentByOrderID(sessCtx, in.OrderID)
+	} else {
+		payment, err = s.repo.FindPaymentByID(sessCtx, in.PaymentID)
+	}
+	if err != nil || payment.OrderID != in.OrderID {
+		return nil, errors.New("payment not found for order")
+	}
+	if payment.Status != models.PaymentStatusCompleted {
+		return nil, errors.New("payment has not been captured")
+	}
+
+	amount := in.Amount
+	if amount == 0 {
+		amount = payment.Amount - payment.Refunded
+	}
+	if amount <= 0 {
+		return nil, ErrRefundExceedsCaptured
+	}
+
+	ok, err := s.repo.ReserveRefund(sessCtx, payment.ID, amount)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrRefundExceedsCaptured
+	}
+
+	now := time.Now()
+	refund := &models.Refund{
+		ID:              primitive.NewObjectID(),
+		OrderID:         in.OrderID,
+		PaymentID:       payment.ID,
+		ReturnRequestID: in.ReturnRequestID,
+		Amount:          amount,
+		Status:          models.RefundStatusPending,
+		Reason:          in.Reason,
+		CreatedBy:       in.OperatorID,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+
+	if err := s.repo.CreateRefund(sessCtx, refund); err != nil {
+		return nil, err
+	}
+	return refund, nil
+}
+
+// UpdateRefundStatus settles a refund by hand: a pending one, or one left
+// processing when the gateway's answer never arrived. Completed refunds are
+// added to the order's refunded total; failed ones free up the reserved
+// amount.
+func (s *Service) UpdateRefundStatus(ctx context.Context, refundID, status string) error {
+	id, err := primitive.ObjectIDFromHex(refundID)
+	if err != nil {
+		return errors.New("invalid refund ID")
+	}
+
+	refund, err := s.repo.FindRefundByID(ctx, id)
+	if err != nil {
+		return errors.New("refund not found")
+	}
+
+	return s.settleRefund(ctx, refund, refund.Status, models.RefundStatus(status))
+}
+
+// settleRefund moves a refund from status from to a final status
+func (s *Service) settleRefund(ctx context.Context, refund *models.Refund, from, newStatus models.RefundStatus) error {
+	if from != models.RefundStatusPending && from != models.RefundStatusProcessing {
+		return ErrRefundNotPending
+	}
+	return s.repo.WithTransaction(ctx, func(sessCtx mongo.SessionContext) error {
+		ok, err := s.repo.UpdateRefundStatus(sessCtx, refund.ID, []models.RefundStatus{from}, newStatus)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrRefundNotPending
+		}
+
+		switch newStatus {
+		case models.RefundStatusCompleted:
+			return s.repo.AddOrderRefundedTotal(sessCtx, refund.OrderID, refund.Amount)
+		case models.RefundStatusFailed:
+			return s.repo.ReleaseRefund(sessCtx, refund.PaymentID, refund.Amount)
+		}
+		return nil
+	})
+}
+
+// GetRefunds returns refunds (admin) with optional order/status filters
+func (s *Service) GetRefunds(ctx context.Context, orderID, status string, page, limit int) (*RefundsListResponse, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 20
+	}
+
+	filter := bson.M{}
+	if orderID != "" {
+		oid, err := primitive.ObjectIDFromHex(orderID)
+		if err != nil {
+			return nil, errors.New("invalid order ID")
+		}
+		filter["orderId"] = oid
+	}
+	if status != "" {
+		filter["status"] = status
+	}
+
+	opts := options.Find()
+	opts.SetSkip(int64((page - 1) * limit))
+	opts.SetLimit(int64(limit))
+	opts.SetSort(bson.D{{Key: "createdAt", Value: -1}})
+
+	refunds, err := s.repo.FindRefunds(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	total, err := s.repo.CountRefunds(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	var data []RefundResponse
+	for _, refund := range refunds {
+		data = append(data, *transformRefund(refund))
+	}
+
+	return &RefundsListResponse{
+		Data:       data,
+		Page:       page,
+		Limit:      limit,
+		Total:      total,
+		TotalPages: int(math.Ceil(float64(total) / float64(limit))),
+	}, nil
+}
+
+func transformRefund(refund *models.Refund) *RefundResponse {
+	resp := &RefundResponse{
+		ID:        refund.ID.Hex(),
+		OrderID:   refund.OrderID.Hex(),
+		PaymentID: refund.PaymentID.Hex(),
+		Amount:    refund.Amount,
+		Status:    string(refund.Status),
+		Reason:    refund.Reason,
+		CreatedBy: refund.CreatedBy.Hex(),
+		CreatedAt: refund.CreatedAt,
+	}
+	if refund.ReturnRequestID != nil {
+		resp.ReturnRequestID = refund.ReturnRequestID.Hex()
+	}
+	return resp
+}
@@ -0,0 +1,180 @@
+package payments_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"phone-store-backend/internal/models"
+	"phone-store-backend/internal/modules/orders"
+	"phone-store-backend/internal/modules/payments"
+	"phone-store-backend/internal/testdb"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const orderTotal = 30000000
+
+type paymentFixture struct {
+	db      *mongo.Database
+	service *payments.Service
+	owner   primitive.ObjectID
+}
+
+// newPaymentFixture sets up COD and the given providers, with the orders
+// service marking orders paid
+func newPaymentFixture(t *testing.T, providers ...payments.Provider) *paymentFixture {
+	t.Helper()
+	database := testdb.Open(t)
+
+	providers = append([]payments.Provider{payments.NewCODProvider()}, providers...)
+	codes := make([]string, 0, len(providers))
+	for _, p := range providers {
+		method := &models.PaymentMethod{ID: primitive.NewObjectID(), Name: p.Code(), Code: p.Code(), IsActive: true}
+		if _, err := database.Collection("payment_methods").InsertOne(context.Background(), method); err != nil {
+			t.Fatalf("seed payment method: %v", err)
+		}
+		codes = append(codes, p.Code())
+	}
+
+	orderService := orders.NewService(orders.NewRepository(database), nil, nil, codes)
+	return &paymentFixture{
+		db:      database,
+		service: payments.NewService(payments.NewRepository(database), orderService, providers...),
+		owner:   primitive.NewObjectID(),
+	}
+}
+
+// addOrder saves an order of the fixture's customer with the given payments
+func (f *paymentFixture) addOrder(t *testing.T, status models.OrderStatus, existing ...*models.Payment) *models.Order {
+	t.Helper()
+	ctx := context.Background()
+	order := &models.Order{
+		ID:            primitive.NewObjectID(),
+		OrderNumber:   "ORD-1",
+		UserID:        f.owner,
+		PaymentMethod: models.PaymentMethodCOD,
+		Total:         orderTotal,
+		Status:        status,
+		CreatedAt:     time.Now(),
+		UpdatedAt:     time.Now(),
+	}
+	if _, err := f.db.Collection("orders").InsertOne(ctx, order); err != nil {
+		t.Fatalf("seed order: %v", err)
+	}
+	for _, payment := range existing {
+		payment.ID = primitive.NewObjectID()
+		payment.OrderID = order.ID
+		if payment.Method == "" {
+			payment.Method = models.PaymentMethodCOD
+		}
+		payment.CreatedAt = time.Now()
+		if _, err := f.db.Collection("payments").InsertOne(ctx, payment); err != nil {
+			t.Fatalf("seed payment: %v", err)
+		}
+	}
+	return order
+}
+
+// serve sends a request through the payment routes as the given user
+func (f *paymentFixture) serve(method, path, body string, userID primitive.ObjectID, role string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("userID", userID.Hex())
+		c.Set("role", role)
+	})
+	handler := payments.NewHandler(f.service)
+	router.POST("/api/payments", handler.CreatePayment)
+	router.GET("/api/payments/:orderId", handler.GetPaymentByOrderID)
+
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestGetPaymentByOrderIDOnlyShowsOwnOrders(t *testing.T) {
+	f := newPaymentFixture(t)
+	order := f.addOrder(t, models.OrderStatusPending, &models.Payment{Amount: orderTotal, Status: models.PaymentStatusPending})
+	path := "/api/payments/" + order.ID.Hex()
+
+	if w := f.serve("GET", path, "", primitive.NewObjectID(), "CUSTOMER"); w.Code != http.StatusNotFound {
+		t.Errorf("another customer got %d, want %d", w.Code, http.StatusNotFound)
+	}
+	if w := f.serve("GET", path, "", f.owner, "CUSTOMER"); w.Code != http.StatusOK {
+		t.Errorf("the owner got %d, want %d", w.Code, http.StatusOK)
+	}
+	if w := f.serve("GET", path, "", primitive.NewObjectID(), "STAFF"); w.Code != http.StatusOK {
+		t.Errorf("staff got %d, want %d", w.Code, http.StatusOK)
+	}
+}
+
+func TestCreatePaymentOnlyForOwnOrders(t *testing.T) {
+	f := newPaymentFixture(t)
+	order := f.addOrder(t, models.OrderStatusPending)
+	body := `{"orderId":"` + order.ID.Hex() + `"}`
+
+	if w := f.serve("POST", "/api/payments", body, primitive.NewObjectID(), "CUSTOMER"); w.Code != http.StatusNotFound {
+		t.Errorf("another customer got %d, want %d", w.Code, http.StatusNotFound)
+	}
+	if n, _ := f.db.Collection("payments").CountDocuments(context.Background(), bson.M{"orderId": order.ID}); n != 0 {
+		t.Errorf("%d payments created for another customer's order, want 0", n)
+	}
+	if w := f.serve("POST", "/api/payments", body, f.owner, "CUSTOMER"); w.Code != http.StatusCreated {
+		t.Errorf("the owner got %d, want %d: %s", w.Code, http.StatusCreated, w.Body)
+	}
+}
+
+func TestCreatePaymentAmountIsTotalMinusCaptured(t *testing.T) {
+	f := newPaymentFixture(t)
+	ctx := context.Background()
+	const captured = 10000000
+	order := f.addOrder(t, models.OrderStatusPending, &models.Payment{Amount: captured, Status: models.PaymentStatusCompleted})
+
+	for _, amount := range []float64{1, orderTotal} {
+		_, err := f.service.CreatePayment(ctx, f.owner.Hex(), &payments.CreatePaymentRequest{OrderID: order.ID.Hex(), Amount: amount}, "127.0.0.1")
+		if !errors.Is(err, payments.ErrAmountMismatch) {
+			t.Errorf("amount %.0f: err = %v, want ErrAmountMismatch", amount, err)
+		}
+	}
+
+	for _, amount := range []float64{0, orderTotal - captured} {
+		resp, err := f.service.CreatePayment(ctx, f.owner.Hex(), &payments.CreatePaymentRequest{OrderID: order.ID.Hex(), Amount: amount}, "127.0.0.1")
+		if err != nil {
+			t.Fatalf("amount %.0f: %v", amount, err)
+		}
+		if resp.Payment.Amount != orderTotal-captured {
+			t.Errorf("amount %.0f: payment of %.0f, want %.0f", amount, resp.Payment.Amount, float64(orderTotal-captured))
+		}
+	}
+}
+
+func TestCreatePaymentRejectsPaidOrders(t *testing.T) {
+	f := newPaymentFixture(t)
+	ctx := context.Background()
+
+	paid := f.addOrder(t, models.OrderStatusPaid, &models.Payment{Amount: orderTotal, Status: models.PaymentStatusCompleted})
+	if _, err := f.service.CreatePayment(ctx, f.owner.Hex(), &payments.CreatePaymentRequest{OrderID: paid.ID.Hex()}, "127.0.0.1"); !errors.Is(err, payments.ErrOrderNotPayable) {
+		t.Errorf("paid order: err = %v, want ErrOrderNotPayable", err)
+	}
+
+	// Fully captured but not moved to PAID yet
+	captured := f.addOrder(t, models.OrderStatusPending, &models.Payment{Amount: orderTotal, Status: models.PaymentStatusCompleted})
+	if _, err := f.service.CreatePayment(ctx, f.owner.Hex(), &payments.CreatePaymentRequest{OrderID: captured.ID.Hex()}, "127.0.0.1"); !errors.Is(err, payments.ErrOrderNotPayable) {
+		t.Errorf("fully captured order: err = %v, want ErrOrderNotPayable", err)
+	}
+
+	body := `{"orderId":"` + paid.ID.Hex() + `"}`
+	if w := f.serve("POST", "/api/payments", body, f.owner, "CUSTOMER"); w.Code != http.StatusConflict {
+		t.Errorf("paid order got %d, want %d", w.Code, http.StatusConflict)
+	}
+}
@@ -0,0 +1,64 @@
+package payments_test
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"phone-store-backend/internal/models"
+	"phone-store-backend/internal/modules/payments"
+)
+
+const stubMethod = "STUBPAY"
+
+// stubProvider is a gateway whose callbacks are plain query strings and
+// whose refunds always succeed, counting how often money was sent back
+type stubProvider struct {
+	refundDelay time.Duration
+
+	mu      sync.Mutex
+	refunds int
+}
+
+func (p *stubProvider) Code() string {
+	return stubMethod
+}
+
+func (p *stubProvider) CreateCheckout(ctx context.Context, req *payments.CheckoutRequest) (*payments.CheckoutResult, error) {
+	return &payments.CheckoutResult{}, nil
+}
+
+// VerifyCallback reads ref, txn and amount from the query string
+func (p *stubProvider) VerifyCallback(r *http.Request) (*payments.CallbackResult, error) {
+	query := r.URL.Query()
+	amount, err := strconv.ParseFloat(query.Get("amount"), 64)
+	if err != nil {
+		return nil, payments.ErrInvalidSignature
+	}
+	return &payments.CallbackResult{
+		PaymentRef:    query.Get("ref"),
+		TransactionID: query.Get("txn"),
+		Amount:        amount,
+		Success:       true,
+	}, nil
+}
+
+func (p *stubProvider) QueryStatus(ctx context.Context, payment *models.Payment) (*payments.StatusResult, error) {
+	return &payments.StatusResult{Status: payment.Status, TransactionID: payment.TransactionID, Amount: payment.Amount}, nil
+}
+
+func (p *stubProvider) Refund(ctx context.Context, req *payments.RefundRequest) (*payments.RefundResult, error) {
+	time.Sleep(p.refundDelay)
+	p.mu.Lock()
+	p.refunds++
+	p.mu.Unlock()
+	return &payments.RefundResult{Success: true, TransactionID: "R-" + req.RefundRef}, nil
+}
+
+func (p *stubProvider) refundCount() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.refunds
+}
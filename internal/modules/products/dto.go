@@ -6,6 +6,8 @@ type ProductQuery struct {
 	Category string  `form:"category"`
 	MinPrice float64 `form:"minPrice"`
 	MaxPrice float64 `form:"maxPrice"`
+	Color    string  `form:"color"`   // Comma-separated
+	Storage  string  `form:"storage"` // Comma-separated
 	Page     int     `form:"page"`
 	Limit    int     `form:"limit"`
 	Sort     string  `form:"sort"` // price_asc, price_desc, name_asc, name_desc, newest
@@ -23,6 +25,7 @@ type ProductResponse struct {
 	MaxPrice    float64  `json:"maxPrice"`
 	IsActive    bool     `json:"isActive"`
 	IsFeatured  bool     `json:"isFeatured"`
+	IsPreOrder  bool     `json:"isPreOrder"`
 }
 
 type ProductDetailResponse struct {
@@ -62,6 +65,27 @@ type PaginatedResponse struct {
 	TotalPages int         `json:"totalPages"`
 }
 
+type FacetsResponse struct {
+	Total      int64        `json:"total"`
+	Brands     []FacetCount `json:"brands"`
+	Categories []FacetCount `json:"categories"`
+	Colors     []FacetCount `json:"colors"`
+	Storages   []FacetCount `json:"storages"`
+	Prices     []PriceFacet `json:"prices"`
+}
+
+type FacetCount struct {
+	Value string `json:"value"` // What to pass back in the query
+	Name  string `json:"name"`
+	Count int64  `json:"count"`
+}
+
+type PriceFacet struct {
+	Min   float64 `json:"min"`
+	Max   float64 `json:"max"` // 0 for the open-ended top bucket
+	Count int64   `json:"count"`
+}
+
 type CreateProductRequest struct {
 	Name        string               `json:"name" binding:"required"`
 	Slug        string               `json:"slug" binding:"required"`
@@ -70,6 +94,7 @@ type CreateProductRequest struct {
 	CategoryID  string               `json:"categoryId" binding:"required"`
 	Images      []string             `json:"images"`
 	IsFeatured  bool                 `json:"isFeatured"`
+	IsPreOrder  bool                 `json:"isPreOrder"`
 }
 
 type UpdateProductRequest struct {
@@ -79,6 +104,7 @@ type UpdateProductRequest struct {
 	CategoryID  string   `json:"categoryId"`
 	Images      []string `json:"images"`
 	IsFeatured  bool     `json:"isFeatured"`
+	IsPreOrder  bool     `json:"isPreOrder"`
 	IsActive    bool     `json:"isActive"`
 }
 
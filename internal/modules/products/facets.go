@@ -0,0 +1,134 @@
+package products
+
+import (
+	"context"
+	"math"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Listing filters that have facets
+const (
+	facetBrand    = "brand"
+	facetCategory = "category"
+	facetColor    = "color"
+	facetStorage  = "storage"
+	facetPrice    = "price"
+)
+
+// priceBoundaries are the lower bounds of the price buckets, in VND. The last
+// bucket has no upper bound.
+var priceBoundaries = []float64{0, 5000000, 10000000, 20000000, 30000000}
+
+// GetFacets counts the products matching a listing query by brand, category,
+// color, storage size and price bucket. Each facet is counted with every
+// active filter but its own, so selecting one brand still shows how many
+// products the other brands would add.
+func (s *Service) GetFacets(ctx context.Context, query *ProductQuery) (*FacetsResponse, error) {
+	f, err := s.buildFilter(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	priceMatch := f.facetsExcept(facetPrice)
+	priceMatch["minPrice"] = bson.M{"$gt": 0}
+
+	// The search has to be matched before $facet, so base is only matched
+	// in the shared stage and every facet matches the other filters on its
+	// own
+	facets := bson.M{
+		"total": mongo.Pipeline{
+			{{Key: "$match", Value: f.facetsExcept("")}},
+			{{Key: "$count", Value: "count"}},
+		},
+		"brands":     referenceFacet(f.facetsExcept(facetBrand), "brandId", "brands"),
+		"categories": referenceFacet(f.facetsExcept(facetCategory), "categoryId", "categories"),
+		"colors":     valueFacet(f.facetsExcept(facetColor), "colors"),
+		"storages":   valueFacet(f.facetsExcept(facetStorage), "storages"),
+		"prices": mongo.Pipeline{
+			{{Key: "$match", Value: priceMatch}},
+			{{Key: "$bucket", Value: bson.M{
+				"groupBy":    "$minPrice",
+				"boundaries": append(append([]float64{}, priceBoundaries...), math.MaxFloat64),
+				"output":     bson.M{"count": bson.M{"$sum": 1}},
+			}}},
+		},
+	}
+
+	result, err := s.repo.FindFacets(ctx, f.base, facets)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &FacetsResponse{
+		Brands:     toFacetCounts(result.Brands),
+		Categories: toFacetCounts(result.Categories),
+		Colors:     toFacetCounts(result.Colors),
+		Storages:   toFacetCounts(result.Storages),
+		Prices:     make([]PriceFacet, len(priceBoundaries)),
+	}
+	if len(result.Total) > 0 {
+		resp.Total = result.Total[0].Count
+	}
+
+	// $bucket leaves out empty buckets, so every bucket is listed here
+	counts := make(map[float64]int64)
+	for _, b := range result.Prices {
+		if min, ok := b.ID.(float64); ok {
+			counts[min] = b.Count
+		}
+	}
+	for i, min := range priceBoundaries {
+		resp.Prices[i] = PriceFacet{Min: min, Count: counts[min]}
+		if i+1 < len(priceBoundaries) {
+			resp.Prices[i].Max = priceBoundaries[i+1]
+		}
+	}
+
+	return resp, nil
+}
+
+// referenceFacet counts matching products by a brand or category reference,
+// joined with its name and slug
+func referenceFacet(match bson.M, field, from string) mongo.Pipeline {
+	return mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$group", Value: bson.M{"_id": "$" + field, "count": bson.M{"$sum": 1}}}},
+		{{Key: "$lookup", Value: bson.M{
+			"from":         from,
+			"localField":   "_id",
+			"foreignField": "_id",
+			"as":           "ref",
+		}}},
+		{{Key: "$unwind", Value: "$ref"}},
+		{{Key: "$project", Value: bson.M{"count": 1, "name": "$ref.name", "slug": "$ref.slug"}}},
+		{{Key: "$sort", Value: bson.D{{Key: "count", Value: -1}, {Key: "name", Value: 1}}}},
+	}
+}
+
+// valueFacet counts matching products by each value of an array field
+func valueFacet(match bson.M, field string) mongo.Pipeline {
+	return mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$unwind", Value: "$" + field}},
+		{{Key: "$group", Value: bson.M{"_id": "$" + field, "count": bson.M{"$sum": 1}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}}},
+	}
+}
+
+func toFacetCounts(buckets []FacetBucket) []FacetCount {
+	counts := make([]FacetCount, 0, len(buckets))
+	for _, b := range buckets {
+		count := FacetCount{Name: b.Name, Count: b.Count}
+		if b.Slug != "" {
+			// Brands and categories are filtered by slug
+			count.Value = b.Slug
+		} else if value, ok := b.ID.(string); ok {
+			count.Value = value
+			count.Name = value
+		}
+		counts = append(counts, count)
+	}
+	return counts
+}
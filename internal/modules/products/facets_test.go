@@ -0,0 +1,107 @@
+package products
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"phone-store-backend/internal/models"
+	"phone-store-backend/internal/search"
+	"phone-store-backend/internal/testdb"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestFacetConditionsLeaveOutBase(t *testing.T) {
+	f := &productFilter{
+		base: bson.M{"isActive": true, "$text": bson.M{"$search": "iphone"}},
+		facets: map[string]bson.M{
+			facetColor: {"colors": bson.M{"$in": []string{"Black"}}},
+			facetPrice: {"minPrice": bson.M{"$gt": 0, "$lte": 30000000}},
+		},
+	}
+
+	for _, facet := range []string{"", facetBrand, facetColor, facetPrice} {
+		conditions := f.facetsExcept(facet)
+		if _, ok := conditions["$text"]; ok {
+			t.Errorf("conditions without %q repeat the search", facet)
+		}
+		if _, ok := conditions["colors"]; ok == (facet == facetColor) {
+			t.Errorf("conditions without %q: colors condition present = %v", facet, ok)
+		}
+	}
+
+	if all := f.all(); all["$text"] == nil || all["colors"] == nil || all["minPrice"] == nil {
+		t.Errorf("all() = %v, want the search and every facet", all)
+	}
+}
+
+func TestGetFacetsWithSearch(t *testing.T) {
+	database := testdb.Open(t)
+	service := NewService(NewRepository(database))
+	ctx := context.Background()
+	now := time.Now()
+
+	for _, p := range []struct {
+		name   string
+		color  string
+		price  float64
+		active bool
+	}{
+		{"iPhone 15", "Black", 20000000, true},
+		{"iPhone 15 Pro", "White", 28000000, true},
+		{"iPhone 14", "Black", 15000000, false},
+		{"Galaxy S24", "Black", 22000000, true},
+	} {
+		product := &models.Product{
+			ID:        primitive.NewObjectID(),
+			Name:      p.name,
+			Slug:      strings.ReplaceAll(search.Text(p.name), " ", "-"),
+			MinPrice:  p.price,
+			MaxPrice:  p.price,
+			Colors:    []string{p.color},
+			Storages:  []string{"128GB"},
+			IsActive:  p.active,
+			CreatedAt: now,
+			UpdatedAt: now,
+			Search: &models.ProductSearch{
+				Name:       search.Text(p.name),
+				Attributes: search.Text(p.color),
+				Terms:      search.Terms(p.name, p.color),
+			},
+		}
+		if _, err := database.Collection("products").InsertOne(ctx, product); err != nil {
+			t.Fatalf("seed product: %v", err)
+		}
+	}
+
+	resp, err := service.GetFacets(ctx, &ProductQuery{Search: "iphone", Color: "Black"})
+	if err != nil {
+		t.Fatalf("facets: %v", err)
+	}
+
+	// Only the active black iPhone matches everything
+	if resp.Total != 1 {
+		t.Errorf("total = %d, want 1", resp.Total)
+	}
+
+	// Colors are counted without the color filter, but still within the
+	// search
+	colors := map[string]int64{}
+	for _, c := range resp.Colors {
+		colors[c.Value] = c.Count
+	}
+	if colors["Black"] != 1 || colors["White"] != 1 || len(colors) != 2 {
+		t.Errorf("colors = %v, want Black 1 and White 1", colors)
+	}
+
+	var priced int64
+	for _, bucket := range resp.Prices {
+		priced += bucket.Count
+	}
+	if priced != 1 {
+		t.Errorf("price buckets count %d products, want 1", priced)
+	}
+}
@@ -1,6 +1,7 @@
 package products
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -27,6 +28,48 @@ func (h *Handler) GetProducts(c *gin.Context) {
 	}
 
 	resp, err := h.service.GetProducts(c.Request.Context(), &query)
+	if errors.Is(err, ErrUnknownFilter) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": err.Error(),
+			"code":    "INVALID_FILTER",
+			"details": nil,
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"code":    "INTERNAL_ERROR",
+			"details": nil,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetFacets takes the same query as GetProducts and returns the facet counts
+// of its results
+func (h *Handler) GetFacets(c *gin.Context) {
+	var query ProductQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Invalid query parameters",
+			"code":    "BAD_REQUEST",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	resp, err := h.service.GetFacets(c.Request.Context(), &query)
+	if errors.Is(err, ErrUnknownFilter) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": err.Error(),
+			"code":    "INVALID_FILTER",
+			"details": nil,
+		})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"message": err.Error(),
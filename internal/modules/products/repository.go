@@ -34,10 +34,113 @@ func (r *Repository) FindProducts(ctx context.Context, filter bson.M, opts *opti
 	return products, nil
 }
 
+// CatalogProduct is a product with its brand and category joined in
+type CatalogProduct struct {
+	models.Product `bson:",inline"`
+	Brand          *models.Brand    `bson:"brand,omitempty"`
+	Category       *models.Category `bson:"category,omitempty"`
+}
+
+// FindCatalog returns one page of products matching filter, with their brand
+// and category, in a single aggregation
+func (r *Repository) FindCatalog(ctx context.Context, filter bson.M, sort bson.D, skip, limit int64) ([]*CatalogProduct, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: filter}},
+		{{Key: "$sort", Value: sort}},
+		{{Key: "$skip", Value: skip}},
+		{{Key: "$limit", Value: limit}},
+		{{Key: "$lookup", Value: bson.M{
+			"from":         "brands",
+			"localField":   "brandId",
+			"foreignField": "_id",
+			"as":           "brand",
+		}}},
+		{{Key: "$lookup", Value: bson.M{
+			"from":         "categories",
+			"localField":   "categoryId",
+			"foreignField": "_id",
+			"as":           "category",
+		}}},
+		{{Key: "$unwind", Value: bson.M{"path": "$brand", "preserveNullAndEmptyArrays": true}}},
+		{{Key: "$unwind", Value: bson.M{"path": "$category", "preserveNullAndEmptyArrays": true}}},
+	}
+
+	cursor, err := r.db.Collection("products").Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var products []*CatalogProduct
+	if err := cursor.All(ctx, &products); err != nil {
+		return nil, err
+	}
+	return products, nil
+}
+
+// FacetBucket is one value of a facet and how many products have it
+type FacetBucket struct {
+	ID    interface{} `bson:"_id"`
+	Count int64       `bson:"count"`
+	Name  string      `bson:"name,omitempty"`
+	Slug  string      `bson:"slug,omitempty"`
+}
+
+// CatalogFacets is the result of the facet aggregation
+type CatalogFacets struct {
+	Total []struct {
+		Count int64 `bson:"count"`
+	} `bson:"total"`
+	Brands     []FacetBucket `bson:"brands"`
+	Categories []FacetBucket `bson:"categories"`
+	Colors     []FacetBucket `bson:"colors"`
+	Storages   []FacetBucket `bson:"storages"`
+	Prices     []FacetBucket `bson:"prices"`
+}
+
+// FindFacets runs the facet sub-pipelines over the products matching filter
+// in a single aggregation
+func (r *Repository) FindFacets(ctx context.Context, filter bson.M, facets bson.M) (*CatalogFacets, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: filter}},
+		{{Key: "$facet", Value: facets}},
+	}
+
+	cursor, err := r.db.Collection("products").Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var result CatalogFacets
+	if cursor.Next(ctx) {
+		if err := cursor.Decode(&result); err != nil {
+			return nil, err
+		}
+	}
+	return &result, cursor.Err()
+}
+
 func (r *Repository) CountProducts(ctx context.Context, filter bson.M) (int64, error) {
 	return r.db.Collection("products").CountDocuments(ctx, filter)
 }
 
+// FindSearchTerms returns every word active products can be found by
+func (r *Repository) FindSearchTerms(ctx context.Context) ([]string, error) {
+	values, err := r.db.Collection("products").Distinct(ctx, "search.terms", bson.M{"isActive": true})
+	if err != nil {
+		return nil, err
+	}
+
+	terms := make([]string, 0, len(values))
+	for _, value := range values {
+		if term, ok := value.(string); ok {
+			terms = append(terms, term)
+		}
+	}
+	return terms, nil
+}
+
 func (r *Repository) FindProductBySlug(ctx context.Context, slug string) (*models.Product, error) {
 	var product models.Product
 	err := r.db.Collection("products").FindOne(ctx, bson.M{"slug": slug, "isActive": true}).Decode(&product)
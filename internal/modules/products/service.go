@@ -3,39 +3,39 @@ package products
 import (
 	"context"
 	"errors"
+	"fmt"
 	"math"
+	"strings"
 	"time"
 
 	"phone-store-backend/internal/models"
+	"phone-store-backend/internal/search"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// ErrUnknownFilter is returned when a listing filter names a brand or
+// category that does not exist
+var ErrUnknownFilter = errors.New("unknown filter value")
+
 type Service struct {
-	repo *Repository
+	repo       *Repository
+	vocabulary *vocabulary
 }
 
 func NewService(repo *Repository) *Service {
-	return &Service{repo: repo}
+	return &Service{repo: repo, vocabulary: newVocabulary(repo.FindSearchTerms)}
 }
 
 func (s *Service) GetProducts(ctx context.Context, query *ProductQuery) (*PaginatedResponse, error) {
-	// Build filter
-	filter := bson.M{"isActive": true}
-
-	if query.Search != "" {
-		filter["name"] = bson.M{"$regex": query.Search, "$options": "i"}
-	}
-
-	if query.Brand != "" {
-		filter["brandId"] = query.Brand
-	}
-
-	if query.Category != "" {
-		filter["categoryId"] = query.Category
+	f, err := s.buildFilter(ctx, query)
+	if err != nil {
+		return nil, err
 	}
+	filter := f.all()
 
 	// Set defaults
 	if query.Page < 1 {
@@ -45,24 +45,41 @@ func (s *Service) GetProducts(ctx context.Context, query *ProductQuery) (*Pagina
 		query.Limit = 20
 	}
 
-	// Build options
-	opts := options.Find()
-	opts.SetSkip(int64((query.Page - 1) * query.Limit))
-	opts.SetLimit(int64(query.Limit))
-
-	// Sorting
+	// Sorting. Ties are broken by _id so pages never overlap.
+	var sort bson.D
 	switch query.Sort {
 	case "newest":
-		opts.SetSort(bson.D{{Key: "createdAt", Value: -1}})
+		sort = bson.D{{Key: "createdAt", Value: -1}, {Key: "_id", Value: 1}}
+	case "price_asc", "price_desc":
+		direction := 1
+		if query.Sort == "price_desc" {
+			direction = -1
+		}
+		sort = bson.D{{Key: "minPrice", Value: direction}, {Key: "_id", Value: 1}}
+
+		// Products without an active variant have no price; sorting by
+		// price leaves them out instead of listing them as the cheapest.
+		// The count below uses the same filter, so the pages add up.
+		if _, ok := filter["minPrice"]; !ok {
+			filter["minPrice"] = bson.M{"$gt": 0}
+		}
 	case "name_asc":
-		opts.SetSort(bson.D{{Key: "name", Value: 1}})
+		sort = bson.D{{Key: "name", Value: 1}, {Key: "_id", Value: 1}}
 	case "name_desc":
-		opts.SetSort(bson.D{{Key: "name", Value: -1}})
+		sort = bson.D{{Key: "name", Value: -1}, {Key: "_id", Value: 1}}
 	default:
-		opts.SetSort(bson.D{{Key: "createdAt", Value: -1}})
+		if f.searching {
+			// Best matches first: name matches weigh most, then brand,
+			// variant attributes and description
+			sort = bson.D{{Key: "score", Value: bson.M{"$meta": "textScore"}}, {Key: "_id", Value: 1}}
+		} else {
+			sort = bson.D{{Key: "createdAt", Value: -1}, {Key: "_id", Value: 1}}
+		}
 	}
 
-	products, err := s.repo.FindProducts(ctx, filter, opts)
+	// The page comes back with brands and categories already joined, and
+	// prices are stored on the product, so a page costs two queries
+	products, err := s.repo.FindCatalog(ctx, filter, sort, int64((query.Page-1)*query.Limit), int64(query.Limit))
 	if err != nil {
 		return nil, err
 	}
@@ -75,20 +92,7 @@ func (s *Service) GetProducts(ctx context.Context, query *ProductQuery) (*Pagina
 	// Transform to response
 	var productResponses []ProductResponse
 	for _, product := range products {
-		resp, err := s.transformProduct(ctx, product)
-		if err != nil {
-			continue
-		}
-
-		// Get variants to calculate price range
-		variants, _ := s.repo.FindVariantsByProductID(ctx, product.ID)
-		if len(variants) > 0 {
-			minPrice, maxPrice := s.calculatePriceRange(variants)
-			resp.MinPrice = minPrice
-			resp.MaxPrice = maxPrice
-		}
-
-		productResponses = append(productResponses, *resp)
+		productResponses = append(productResponses, *newProductResponse(&product.Product, product.Brand, product.Category))
 	}
 
 	totalPages := int(math.Ceil(float64(total) / float64(query.Limit)))
@@ -102,6 +106,115 @@ func (s *Service) GetProducts(ctx context.Context, query *ProductQuery) (*Pagina
 	}, nil
 }
 
+// productFilter is a listing filter split by facet, so that each facet can
+// be counted with every filter but its own
+type productFilter struct {
+	base      bson.M            // Active products matching the search
+	facets    map[string]bson.M // Conditions of the other filters, by facet
+	searching bool
+}
+
+// all is the filter with every condition applied
+func (f *productFilter) all() bson.M {
+	filter := f.facetsExcept("")
+	for key, value := range f.base {
+		filter[key] = value
+	}
+	return filter
+}
+
+// facetsExcept is the conditions of every facet but one, without base. It
+// is what a stage after base has been matched still has to match; $text in
+// particular is only allowed in the first stage of a pipeline.
+func (f *productFilter) facetsExcept(facet string) bson.M {
+	filter := bson.M{}
+	for name, condition := range f.facets {
+		if name == facet {
+			continue
+		}
+		for key, value := range condition {
+			filter[key] = value
+		}
+	}
+	return filter
+}
+
+// buildFilter turns the listing query into a filter
+func (s *Service) buildFilter(ctx context.Context, query *ProductQuery) (*productFilter, error) {
+	f := &productFilter{
+		base:   bson.M{"isActive": true},
+		facets: map[string]bson.M{},
+	}
+
+	// Search matches the folded words of the query, plus the known words
+	// they are a typo or the start of, against the product text index
+	if query.Search != "" {
+		vocabulary, err := s.vocabulary.Terms(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if terms := search.Expand(query.Search, vocabulary); len(terms) > 0 {
+			f.base["$text"] = bson.M{"$search": strings.Join(terms, " ")}
+			f.searching = true
+		}
+	}
+
+	if query.Brand != "" {
+		brandIDs, err := resolveFilterIDs(ctx, query.Brand, "brand", s.brandID)
+		if err != nil {
+			return nil, err
+		}
+		if len(brandIDs) > 0 {
+			f.facets[facetBrand] = bson.M{"brandId": bson.M{"$in": brandIDs}}
+		}
+	}
+
+	if query.Category != "" {
+		categoryIDs, err := resolveFilterIDs(ctx, query.Category, "category", s.categoryID)
+		if err != nil {
+			return nil, err
+		}
+		if len(categoryIDs) > 0 {
+			f.facets[facetCategory] = bson.M{"categoryId": bson.M{"$in": categoryIDs}}
+		}
+	}
+
+	// A product matches a color or storage size when one of its active
+	// variants has it
+	if colors := splitValues(query.Color); len(colors) > 0 {
+		f.facets[facetColor] = bson.M{"colors": bson.M{"$in": colors}}
+	}
+	if storages := splitValues(query.Storage); len(storages) > 0 {
+		f.facets[facetStorage] = bson.M{"storages": bson.M{"$in": storages}}
+	}
+
+	// Prices filter on the lowest variant price. Products without an active
+	// variant have no price and never match.
+	if query.MinPrice > 0 || query.MaxPrice > 0 {
+		price := bson.M{"$gt": 0}
+		if query.MinPrice > 0 {
+			price["$gte"] = query.MinPrice
+		}
+		if query.MaxPrice > 0 {
+			price["$lte"] = query.MaxPrice
+		}
+		f.facets[facetPrice] = bson.M{"minPrice": price}
+	}
+
+	return f, nil
+}
+
+// splitValues splits a comma-separated query value, dropping blanks
+func splitValues(values string) []string {
+	var result []string
+	for _, value := range strings.Split(values, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			result = append(result, value)
+		}
+	}
+	return result
+}
+
 func (s *Service) GetProductBySlug(ctx context.Context, slug string) (*ProductDetailResponse, error) {
 	product, err := s.repo.FindProductBySlug(ctx, slug)
 	if err != nil {
@@ -196,11 +309,15 @@ func (s *Service) CreateProduct(ctx context.Context, req *CreateProductRequest)
 		Images:      req.Images,
 		IsActive:    true,
 		IsFeatured:  req.IsFeatured,
+		IsPreOrder:  req.IsPreOrder,
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
 	}
 
-	return s.repo.CreateProduct(ctx, product)
+	if err := s.repo.CreateProduct(ctx, product); err != nil {
+		return err
+	}
+	return s.syncProduct(ctx, product.ID)
 }
 
 func (s *Service) UpdateProduct(ctx context.Context, id string, req *UpdateProductRequest) error {
@@ -235,9 +352,13 @@ func (s *Service) UpdateProduct(ctx context.Context, id string, req *UpdateProdu
 		update["images"] = req.Images
 	}
 	update["isFeatured"] = req.IsFeatured
+	update["isPreOrder"] = req.IsPreOrder
 	update["isActive"] = req.IsActive
 
-	return s.repo.UpdateProduct(ctx, productID, update)
+	if err := s.repo.UpdateProduct(ctx, productID, update); err != nil {
+		return err
+	}
+	return s.syncProduct(ctx, productID)
 }
 
 func (s *Service) DeleteProduct(ctx context.Context, id string) error {
@@ -245,7 +366,11 @@ func (s *Service) DeleteProduct(ctx context.Context, id string) error {
 	if err != nil {
 		return errors.New("invalid product ID")
 	}
-	return s.repo.DeleteProduct(ctx, productID)
+	if err := s.repo.DeleteProduct(ctx, productID); err != nil {
+		return err
+	}
+	s.vocabulary.Invalidate()
+	return nil
 }
 
 func (s *Service) CreateVariant(ctx context.Context, req *CreateVariantRequest) error {
@@ -267,7 +392,10 @@ func (s *Service) CreateVariant(ctx context.Context, req *CreateVariantRequest)
 		UpdatedAt: time.Now(),
 	}
 
-	return s.repo.CreateVariant(ctx, variant)
+	if err := s.repo.CreateVariant(ctx, variant); err != nil {
+		return err
+	}
+	return s.syncProduct(ctx, productID)
 }
 
 func (s *Service) UpdateVariant(ctx context.Context, id string, req *UpdateVariantRequest) error {
@@ -276,6 +404,11 @@ func (s *Service) UpdateVariant(ctx context.Context, id string, req *UpdateVaria
 		return errors.New("invalid variant ID")
 	}
 
+	variant, err := s.repo.FindVariantByID(ctx, variantID)
+	if err != nil {
+		return errors.New("variant not found")
+	}
+
 	update := bson.M{"updatedAt": time.Now()}
 
 	if req.Color != "" {
@@ -292,7 +425,10 @@ func (s *Service) UpdateVariant(ctx context.Context, id string, req *UpdateVaria
 	}
 	update["isActive"] = req.IsActive
 
-	return s.repo.UpdateVariant(ctx, variantID, update)
+	if err := s.repo.UpdateVariant(ctx, variantID, update); err != nil {
+		return err
+	}
+	return s.syncProduct(ctx, variant.ProductID)
 }
 
 func (s *Service) DeleteVariant(ctx context.Context, id string) error {
@@ -300,14 +436,173 @@ func (s *Service) DeleteVariant(ctx context.Context, id string) error {
 	if err != nil {
 		return errors.New("invalid variant ID")
 	}
-	return s.repo.DeleteVariant(ctx, variantID)
+
+	variant, err := s.repo.FindVariantByID(ctx, variantID)
+	if err != nil {
+		return errors.New("variant not found")
+	}
+
+	if err := s.repo.DeleteVariant(ctx, variantID); err != nil {
+		return err
+	}
+	return s.syncProduct(ctx, variant.ProductID)
+}
+
+// syncProduct stores what listings need from a product's brand and active
+// variants on the product itself: the price range listings filter and sort
+// on, the colors and storage sizes on offer, and the folded text it is
+// searched by
+func (s *Service) syncProduct(ctx context.Context, productID primitive.ObjectID) error {
+	product, err := s.repo.FindProductByID(ctx, productID)
+	if err != nil {
+		return err
+	}
+
+	variants, err := s.repo.FindVariantsByProductID(ctx, productID)
+	if err != nil {
+		return err
+	}
+
+	brandName := ""
+	if brand, err := s.repo.FindBrandByID(ctx, product.BrandID); err == nil {
+		brandName = brand.Name
+	}
+
+	var attributes []string
+	colors := []string{}
+	storages := []string{}
+	for _, v := range variants {
+		attributes = append(attributes, v.SKU, v.Color, v.Storage)
+		colors = appendDistinct(colors, v.Color)
+		storages = appendDistinct(storages, v.Storage)
+	}
+
+	// The product's words may have changed
+	defer s.vocabulary.Invalidate()
+
+	minPrice, maxPrice := s.calculatePriceRange(variants)
+	return s.repo.UpdateProduct(ctx, productID, bson.M{
+		"minPrice": minPrice,
+		"maxPrice": maxPrice,
+		"colors":   colors,
+		"storages": storages,
+		"search": &models.ProductSearch{
+			Name:        search.Text(product.Name),
+			Brand:       search.Text(brandName),
+			Attributes:  search.Text(attributes...),
+			Description: search.Text(product.Description),
+			Terms:       search.Terms(append([]string{product.Name, brandName, product.Description}, attributes...)...),
+		},
+	})
+}
+
+// appendDistinct appends value to values unless it is empty or already there
+func appendDistinct(values []string, value string) []string {
+	if value == "" {
+		return values
+	}
+	for _, v := range values {
+		if v == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
+
+// SyncMissingProducts fills in the price range, variant options and search
+// text of products saved before they were stored on them. It only touches
+// products without them, so it is cheap to run on every start.
+func (s *Service) SyncMissingProducts(ctx context.Context) error {
+	products, err := s.repo.FindProducts(ctx, bson.M{"$or": bson.A{
+		bson.M{"minPrice": bson.M{"$exists": false}},
+		bson.M{"colors": bson.M{"$exists": false}},
+		bson.M{"search": bson.M{"$exists": false}},
+	}}, options.Find())
+	if err != nil {
+		return err
+	}
+
+	for _, product := range products {
+		if err := s.syncProduct(ctx, product.ID); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // Helper methods
+
+// resolveFilterIDs turns a comma-separated list of slugs or hex IDs into
+// IDs, using find to look each one up
+func resolveFilterIDs(ctx context.Context, values, kind string, find func(ctx context.Context, value string) (primitive.ObjectID, error)) ([]primitive.ObjectID, error) {
+	var ids []primitive.ObjectID
+	for _, value := range splitValues(values) {
+		id, err := find(ctx, value)
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("%w: %s %q", ErrUnknownFilter, kind, value)
+		} else if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// brandID finds a brand by slug, or by ID when value is one
+func (s *Service) brandID(ctx context.Context, value string) (primitive.ObjectID, error) {
+	if brand, err := s.repo.FindBrandBySlug(ctx, value); err != mongo.ErrNoDocuments {
+		if err != nil {
+			return primitive.NilObjectID, err
+		}
+		return brand.ID, nil
+	}
+
+	id, err := primitive.ObjectIDFromHex(value)
+	if err != nil {
+		return primitive.NilObjectID, mongo.ErrNoDocuments
+	}
+	brand, err := s.repo.FindBrandByID(ctx, id)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+	return brand.ID, nil
+}
+
+// categoryID finds a category by slug, or by ID when value is one
+func (s *Service) categoryID(ctx context.Context, value string) (primitive.ObjectID, error) {
+	if category, err := s.repo.FindCategoryBySlug(ctx, value); err != mongo.ErrNoDocuments {
+		if err != nil {
+			return primitive.NilObjectID, err
+		}
+		return category.ID, nil
+	}
+
+	id, err := primitive.ObjectIDFromHex(value)
+	if err != nil {
+		return primitive.NilObjectID, mongo.ErrNoDocuments
+	}
+	category, err := s.repo.FindCategoryByID(ctx, id)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+	return category.ID, nil
+}
 func (s *Service) transformProduct(ctx context.Context, product *models.Product) (*ProductResponse, error) {
-	brand, _ := s.repo.FindBrandByID(ctx, product.BrandID)
-	category, _ := s.repo.FindCategoryByID(ctx, product.CategoryID)
+	brand, err := s.repo.FindBrandByID(ctx, product.BrandID)
+	if err != nil {
+		brand = nil
+	}
+	category, err := s.repo.FindCategoryByID(ctx, product.CategoryID)
+	if err != nil {
+		category = nil
+	}
+
+	return newProductResponse(product, brand, category), nil
+}
 
+// newProductResponse builds a product response from a product and its
+// brand and category, either of which may be missing
+func newProductResponse(product *models.Product, brand *models.Brand, category *models.Category) *ProductResponse {
 	resp := &ProductResponse{
 		ID:          product.ID.Hex(),
 		Name:        product.Name,
@@ -316,6 +611,9 @@ func (s *Service) transformProduct(ctx context.Context, product *models.Product)
 		Images:      product.Images,
 		IsActive:    product.IsActive,
 		IsFeatured:  product.IsFeatured,
+		IsPreOrder:  product.IsPreOrder,
+		MinPrice:    product.MinPrice,
+		MaxPrice:    product.MaxPrice,
 	}
 
 	if brand != nil {
@@ -336,7 +634,7 @@ func (s *Service) transformProduct(ctx context.Context, product *models.Product)
 		}
 	}
 
-	return resp, nil
+	return resp
 }
 
 func (s *Service) calculatePriceRange(variants []*models.ProductVariant) (float64, float64) {
@@ -398,7 +696,24 @@ func (s *Service) UpdateBrand(ctx context.Context, id string, req *UpdateBrandRe
 		update["isActive"] = *req.IsActive
 	}
 
-	return s.repo.UpdateBrand(ctx, brandID, update)
+	if err := s.repo.UpdateBrand(ctx, brandID, update); err != nil {
+		return err
+	}
+	if req.Name == "" {
+		return nil
+	}
+
+	// Products are also found by their brand's name
+	products, err := s.repo.FindProducts(ctx, bson.M{"brandId": brandID}, options.Find())
+	if err != nil {
+		return err
+	}
+	for _, product := range products {
+		if err := s.syncProduct(ctx, product.ID); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func (s *Service) DeleteBrand(ctx context.Context, id string) error {
@@ -0,0 +1,180 @@
+package products
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"phone-store-backend/internal/models"
+	"phone-store-backend/internal/testdb"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// seedProducts saves one active product per price; a zero price is a
+// product without active variants
+func seedProducts(t *testing.T, database *mongo.Database, prices ...float64) {
+	t.Helper()
+	now := time.Now()
+	for i, price := range prices {
+		product := &models.Product{
+			ID:        primitive.NewObjectID(),
+			Name:      "Phone " + string(rune('A'+i)),
+			Slug:      "phone-" + string(rune('a'+i)),
+			MinPrice:  price,
+			MaxPrice:  price,
+			IsActive:  true,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+		if _, err := database.Collection("products").InsertOne(context.Background(), product); err != nil {
+			t.Fatalf("seed product: %v", err)
+		}
+	}
+}
+
+func TestGetProductsPriceSortLeavesOutUnpricedProducts(t *testing.T) {
+	database := testdb.Open(t)
+	service := NewService(NewRepository(database))
+	seedProducts(t, database, 0, 15000000, 0, 5000000, 25000000)
+
+	for _, sort := range []string{"price_asc", "price_desc"} {
+		var prices []float64
+		for page := 1; ; page++ {
+			resp, err := service.GetProducts(context.Background(), &ProductQuery{Sort: sort, Page: page, Limit: 2})
+			if err != nil {
+				t.Fatalf("%s page %d: %v", sort, page, err)
+			}
+			if resp.Total != 3 || resp.TotalPages != 2 {
+				t.Errorf("%s page %d: total %d in %d pages, want 3 in 2", sort, page, resp.Total, resp.TotalPages)
+			}
+			for _, product := range resp.Data.([]ProductResponse) {
+				prices = append(prices, product.MinPrice)
+			}
+			if page >= resp.TotalPages {
+				break
+			}
+		}
+
+		if len(prices) != 3 {
+			t.Fatalf("%s listed %v, want the 3 priced products", sort, prices)
+		}
+		for i := 1; i < len(prices); i++ {
+			if (sort == "price_asc") != (prices[i-1] < prices[i]) {
+				t.Errorf("%s listed %v out of order", sort, prices)
+			}
+		}
+	}
+
+	resp, err := service.GetProducts(context.Background(), &ProductQuery{Page: 1, Limit: 10})
+	if err != nil {
+		t.Fatalf("default sort: %v", err)
+	}
+	if listed := resp.Data.([]ProductResponse); resp.Total != 5 || len(listed) != 5 {
+		t.Errorf("default sort listed %d of %d products, want 5", len(listed), resp.Total)
+	}
+}
+
+// seedCatalog saves n active products spread over a few brands and
+// categories, each with three variants and the price range and attributes
+// the products service would keep in sync
+func seedCatalog(tb testing.TB, database *mongo.Database, n int) {
+	tb.Helper()
+	ctx := context.Background()
+	now := time.Now()
+	colors := []string{"Black", "White", "Blue"}
+	storages := []string{"128GB", "256GB", "512GB"}
+
+	var brands, categories, products, variants []interface{}
+	brandIDs := make([]primitive.ObjectID, 5)
+	for i := range brandIDs {
+		brandIDs[i] = primitive.NewObjectID()
+		brands = append(brands, &models.Brand{ID: brandIDs[i], Name: fmt.Sprintf("Brand %d", i), Slug: fmt.Sprintf("brand-%d", i), IsActive: true, CreatedAt: now, UpdatedAt: now})
+	}
+	categoryIDs := make([]primitive.ObjectID, 4)
+	for i := range categoryIDs {
+		categoryIDs[i] = primitive.NewObjectID()
+		categories = append(categories, &models.Category{ID: categoryIDs[i], Name: fmt.Sprintf("Category %d", i), Slug: fmt.Sprintf("category-%d", i), IsActive: true, CreatedAt: now, UpdatedAt: now})
+	}
+
+	for i := 0; i < n; i++ {
+		product := &models.Product{
+			ID:         primitive.NewObjectID(),
+			Name:       fmt.Sprintf("Phone %d", i),
+			Slug:       fmt.Sprintf("phone-%d", i),
+			BrandID:    brandIDs[i%len(brandIDs)],
+			CategoryID: categoryIDs[i%len(categoryIDs)],
+			Colors:     colors,
+			Storages:   storages,
+			IsActive:   true,
+			CreatedAt:  now.Add(time.Duration(i) * time.Second),
+			UpdatedAt:  now,
+		}
+		for j := range storages {
+			price := float64(i%50+1)*1000000 + float64(j)*2000000
+			if j == 0 {
+				product.MinPrice = price
+			}
+			product.MaxPrice = price
+			variants = append(variants, &models.ProductVariant{
+				ID:        primitive.NewObjectID(),
+				ProductID: product.ID,
+				SKU:       fmt.Sprintf("PH-%d-%d", i, j),
+				Color:     colors[j],
+				Storage:   storages[j],
+				Price:     price,
+				Stock:     10,
+				IsActive:  true,
+				CreatedAt: now,
+				UpdatedAt: now,
+			})
+		}
+		products = append(products, product)
+	}
+
+	for name, docs := range map[string][]interface{}{
+		"brands":           brands,
+		"categories":       categories,
+		"products":         products,
+		"product_variants": variants,
+	} {
+		if _, err := database.Collection(name).InsertMany(ctx, docs); err != nil {
+			tb.Fatalf("seed %s: %v", name, err)
+		}
+	}
+}
+
+func BenchmarkGetProducts(b *testing.B) {
+	database := testdb.Open(b)
+	service := NewService(NewRepository(database))
+	seedCatalog(b, database, 300)
+	ctx := context.Background()
+
+	// Every query has at least three full pages; the filtered one matches
+	// the 60 products of one brand
+	for _, query := range []ProductQuery{
+		{Sort: "newest"},
+		{Sort: "price_asc"},
+		{Brand: "brand-0", Storage: "256GB"},
+	} {
+		name := query.Sort
+		if name == "" {
+			name = "filtered"
+		}
+		b.Run(name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				q := query
+				q.Page, q.Limit = i%3+1, 20
+				resp, err := service.GetProducts(ctx, &q)
+				if err != nil {
+					b.Fatalf("get products: %v", err)
+				}
+				if len(resp.Data.([]ProductResponse)) != 20 {
+					b.Fatalf("page %d listed %d products, want 20", q.Page, len(resp.Data.([]ProductResponse)))
+				}
+			}
+		})
+	}
+}
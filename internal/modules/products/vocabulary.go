@@ -0,0 +1,55 @@
+package products
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// vocabularyTTL is how long the search vocabulary is reused before it is
+// loaded again. Changes made through this server refresh it at once; the
+// TTL only bounds how long changes made elsewhere (another instance, a
+// script) take to show up in typo matching.
+const vocabularyTTL = 5 * time.Minute
+
+// vocabulary caches every word active products can be found by, so that a
+// search does not collect the terms of the whole catalog each time
+type vocabulary struct {
+	load func(ctx context.Context) ([]string, error)
+
+	mu       sync.Mutex
+	terms    []string
+	loadedAt time.Time
+	stale    bool
+}
+
+func newVocabulary(load func(ctx context.Context) ([]string, error)) *vocabulary {
+	return &vocabulary{load: load, stale: true}
+}
+
+// Terms returns the cached words, loading them first when they are stale or
+// older than vocabularyTTL. The returned slice must not be modified.
+func (v *vocabulary) Terms(ctx context.Context) ([]string, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if !v.stale && time.Since(v.loadedAt) < vocabularyTTL {
+		return v.terms, nil
+	}
+
+	terms, err := v.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	v.terms = terms
+	v.loadedAt = time.Now()
+	v.stale = false
+	return terms, nil
+}
+
+// Invalidate makes the next search load the words again
+func (v *vocabulary) Invalidate() {
+	v.mu.Lock()
+	v.stale = true
+	v.mu.Unlock()
+}
@@ -0,0 +1,56 @@
+package products
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestVocabularyReloadsOnlyWhenStale(t *testing.T) {
+	loads := 0
+	v := newVocabulary(func(ctx context.Context) ([]string, error) {
+		loads++
+		return []string{"iphone"}, nil
+	})
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if _, err := v.Terms(ctx); err != nil {
+			t.Fatalf("terms: %v", err)
+		}
+	}
+	if loads != 1 {
+		t.Errorf("loaded %d times for 3 searches, want 1", loads)
+	}
+
+	v.Invalidate()
+	v.Terms(ctx)
+	if loads != 2 {
+		t.Errorf("loaded %d times after invalidating, want 2", loads)
+	}
+
+	v.loadedAt = time.Now().Add(-vocabularyTTL)
+	v.Terms(ctx)
+	if loads != 3 {
+		t.Errorf("loaded %d times after the TTL, want 3", loads)
+	}
+}
+
+func TestVocabularyRetriesFailedLoads(t *testing.T) {
+	fail := true
+	v := newVocabulary(func(ctx context.Context) ([]string, error) {
+		if fail {
+			return nil, errors.New("connection reset")
+		}
+		return []string{"iphone"}, nil
+	})
+
+	if _, err := v.Terms(context.Background()); err == nil {
+		t.Fatal("failed load returned no error")
+	}
+	fail = false
+	if terms, err := v.Terms(context.Background()); err != nil || len(terms) != 1 {
+		t.Errorf("terms after a failed load = %q, %v", terms, err)
+	}
+}
@@ -0,0 +1,99 @@
+package promotions
+
+import (
+	"time"
+
+	"phone-store-backend/internal/models"
+)
+
+// TierRequest DTO for one step of a tiered spend discount
+type TierRequest struct {
+	MinSubTotal     float64 `json:"minSubTotal" binding:"gte=0"`
+	DiscountPercent float64 `json:"discountPercent" binding:"gte=0,lte=100"`
+	DiscountAmount  float64 `json:"discountAmount" binding:"gte=0"`
+}
+
+// CreatePromotionRequest DTO for creating a promotion
+type CreatePromotionRequest struct {
+	Name                  string        `json:"name" binding:"required,max=100"`
+	Description           string        `json:"description"`
+	Priority              int           `json:"priority"`
+	Exclusive             bool          `json:"exclusive"`
+	CombinableWithVoucher bool          `json:"combinableWithVoucher"`
+	ProductIDs            []string      `json:"productIds"`
+	BrandIDs              []string      `json:"brandIds"`
+	CategoryIDs           []string      `json:"categoryIds"`
+	RequiredProductIDs    []string      `json:"requiredProductIds"`
+	RequiredCategoryIDs   []string      `json:"requiredCategoryIds"`
+	MinQuantity           int           `json:"minQuantity" binding:"gte=0"`
+	MinSubTotal           float64       `json:"minSubTotal" binding:"gte=0"`
+	StartsAt              time.Time     `json:"startsAt" binding:"required"`
+	EndsAt                time.Time     `json:"endsAt" binding:"required"`
+	DiscountType          string        `json:"discountType" binding:"omitempty,oneof=PERCENT FIXED"`
+	DiscountPercent       float64       `json:"discountPercent" binding:"gte=0,lte=100"`
+	DiscountAmount        float64       `json:"discountAmount" binding:"gte=0"`
+	MaxDiscount           float64       `json:"maxDiscount" binding:"gte=0"`
+	Tiers                 []TierRequest `json:"tiers" binding:"dive"`
+	IsActive              *bool         `json:"isActive"`
+}
+
+// UpdatePromotionRequest DTO for updating a promotion. Omitted fields are
+// left unchanged.
+type UpdatePromotionRequest struct {
+	Name                  *string        `json:"name" binding:"omitempty,max=100"`
+	Description           *string        `json:"description"`
+	Priority              *int           `json:"priority"`
+	Exclusive             *bool          `json:"exclusive"`
+	CombinableWithVoucher *bool          `json:"combinableWithVoucher"`
+	ProductIDs            *[]string      `json:"productIds"`
+	BrandIDs              *[]string      `json:"brandIds"`
+	CategoryIDs           *[]string      `json:"categoryIds"`
+	RequiredProductIDs    *[]string      `json:"requiredProductIds"`
+	RequiredCategoryIDs   *[]string      `json:"requiredCategoryIds"`
+	MinQuantity           *int           `json:"minQuantity" binding:"omitempty,gte=0"`
+	MinSubTotal           *float64       `json:"minSubTotal" binding:"omitempty,gte=0"`
+	StartsAt              *time.Time     `json:"startsAt"`
+	EndsAt                *time.Time     `json:"endsAt"`
+	DiscountType          *string        `json:"discountType" binding:"omitempty,oneof=PERCENT FIXED"`
+	DiscountPercent       *float64       `json:"discountPercent" binding:"omitempty,gte=0,lte=100"`
+	DiscountAmount        *float64       `json:"discountAmount" binding:"omitempty,gte=0"`
+	MaxDiscount           *float64       `json:"maxDiscount" binding:"omitempty,gte=0"`
+	Tiers                 *[]TierRequest `json:"tiers" binding:"omitempty,dive"`
+	IsActive              *bool          `json:"isActive"`
+}
+
+// PromotionResponse DTO for promotion response
+type PromotionResponse struct {
+	ID                    string                 `json:"id"`
+	Name                  string                 `json:"name"`
+	Description           string                 `json:"description"`
+	Priority              int                    `json:"priority"`
+	Exclusive             bool                   `json:"exclusive"`
+	CombinableWithVoucher bool                   `json:"combinableWithVoucher"`
+	ProductIDs            []string               `json:"productIds"`
+	BrandIDs              []string               `json:"brandIds"`
+	CategoryIDs           []string               `json:"categoryIds"`
+	RequiredProductIDs    []string               `json:"requiredProductIds"`
+	RequiredCategoryIDs   []string               `json:"requiredCategoryIds"`
+	MinQuantity           int                    `json:"minQuantity"`
+	MinSubTotal           float64                `json:"minSubTotal"`
+	StartsAt              time.Time              `json:"startsAt"`
+	EndsAt                time.Time              `json:"endsAt"`
+	DiscountType          string                 `json:"discountType"`
+	DiscountPercent       float64                `json:"discountPercent"`
+	DiscountAmount        float64                `json:"discountAmount"`
+	MaxDiscount           float64                `json:"maxDiscount"`
+	Tiers                 []models.PromotionTier `json:"tiers"`
+	IsActive              bool                   `json:"isActive"`
+	CreatedAt             time.Time              `json:"createdAt"`
+	UpdatedAt             time.Time              `json:"updatedAt"`
+}
+
+// PromotionsListResponse DTO for paginated promotions list
+type PromotionsListResponse struct {
+	Data       []PromotionResponse `json:"data"`
+	Page       int                 `json:"page"`
+	Limit      int                 `json:"limit"`
+	Total      int64               `json:"total"`
+	TotalPages int                 `json:"totalPages"`
+}
@@ -0,0 +1,171 @@
+package promotions
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// GetPromotions godoc
+// @Summary Get promotions (admin only)
+// @Tags Promotions
+// @Security BearerAuth
+// @Param q query string false "Search name or description"
+// @Param isActive query bool false "Filter by active state"
+// @Param page query int false "Page number"
+// @Param limit query int false "Limit per page"
+// @Success 200 {object} PromotionsListResponse
+// @Router /api/admin/promotions [get]
+func (h *Handler) GetPromotions(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	var isActive *bool
+	if value, err := strconv.ParseBool(c.Query("isActive")); err == nil {
+		isActive = &value
+	}
+
+	resp, err := h.service.GetPromotions(c.Request.Context(), c.Query("q"), isActive, page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Promotions retrieved successfully",
+		"data":    resp,
+	})
+}
+
+// GetPromotionByID godoc
+// @Summary Get promotion by ID (admin only)
+// @Tags Promotions
+// @Security BearerAuth
+// @Param id path string true "Promotion ID"
+// @Success 200 {object} PromotionResponse
+// @Router /api/admin/promotions/{id} [get]
+func (h *Handler) GetPromotionByID(c *gin.Context) {
+	resp, err := h.service.GetPromotionByID(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Promotion retrieved successfully",
+		"data":    resp,
+	})
+}
+
+// CreatePromotion godoc
+// @Summary Create a promotion (admin only)
+// @Tags Promotions
+// @Security BearerAuth
+// @Param request body CreatePromotionRequest true "Promotion data"
+// @Success 201 {object} PromotionResponse
+// @Router /api/admin/promotions [post]
+func (h *Handler) CreatePromotion(c *gin.Context) {
+	var req CreatePromotionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid request data",
+			"data":    err.Error(),
+		})
+		return
+	}
+
+	resp, err := h.service.CreatePromotion(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Promotion created successfully",
+		"data":    resp,
+	})
+}
+
+// UpdatePromotion godoc
+// @Summary Update a promotion (admin only)
+// @Tags Promotions
+// @Security BearerAuth
+// @Param id path string true "Promotion ID"
+// @Param request body UpdatePromotionRequest true "Promotion data"
+// @Success 200
+// @Router /api/admin/promotions/{id} [put]
+func (h *Handler) UpdatePromotion(c *gin.Context) {
+	var req UpdatePromotionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid request data",
+			"data":    err.Error(),
+		})
+		return
+	}
+
+	if err := h.service.UpdatePromotion(c.Request.Context(), c.Param("id"), &req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Promotion updated successfully",
+		"data":    nil,
+	})
+}
+
+// DeactivatePromotion godoc
+// @Summary Deactivate a promotion (admin only)
+// @Tags Promotions
+// @Security BearerAuth
+// @Param id path string true "Promotion ID"
+// @Success 200
+// @Router /api/admin/promotions/{id} [delete]
+func (h *Handler) DeactivatePromotion(c *gin.Context) {
+	if err := h.service.DeactivatePromotion(c.Request.Context(), c.Param("id")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Promotion deactivated successfully",
+		"data":    nil,
+	})
+}
@@ -0,0 +1,75 @@
+package promotions
+
+import (
+	"context"
+
+	"phone-store-backend/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type Repository struct {
+	promotionCollection *mongo.Collection
+}
+
+func NewRepository(db *mongo.Database) *Repository {
+	return &Repository{
+		promotionCollection: db.Collection("promotions"),
+	}
+}
+
+// FindPromotions returns promotions matching filter with pagination
+func (r *Repository) FindPromotions(ctx context.Context, filter bson.M, opts *options.FindOptions) ([]*models.Promotion, error) {
+	cursor, err := r.promotionCollection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var promotions []*models.Promotion
+	if err := cursor.All(ctx, &promotions); err != nil {
+		return nil, err
+	}
+
+	return promotions, nil
+}
+
+// CountPromotions counts promotions matching filter
+func (r *Repository) CountPromotions(ctx context.Context, filter bson.M) (int64, error) {
+	return r.promotionCollection.CountDocuments(ctx, filter)
+}
+
+// FindPromotionByID finds a promotion by ID
+func (r *Repository) FindPromotionByID(ctx context.Context, id primitive.ObjectID) (*models.Promotion, error) {
+	var promotion models.Promotion
+	err := r.promotionCollection.FindOne(ctx, bson.M{"_id": id}).Decode(&promotion)
+	if err != nil {
+		return nil, err
+	}
+	return &promotion, nil
+}
+
+// CreatePromotion creates a new promotion
+func (r *Repository) CreatePromotion(ctx context.Context, promotion *models.Promotion) error {
+	result, err := r.promotionCollection.InsertOne(ctx, promotion)
+	if err != nil {
+		return err
+	}
+	promotion.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// ReplacePromotion saves every field of a promotion
+func (r *Repository) ReplacePromotion(ctx context.Context, promotion *models.Promotion) error {
+	_, err := r.promotionCollection.ReplaceOne(ctx, bson.M{"_id": promotion.ID}, promotion)
+	return err
+}
+
+// UpdatePromotion updates a promotion
+func (r *Repository) UpdatePromotion(ctx context.Context, id primitive.ObjectID, update bson.M) error {
+	_, err := r.promotionCollection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": update})
+	return err
+}
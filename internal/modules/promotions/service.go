@@ -0,0 +1,365 @@
+package promotions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+
+	"phone-store-backend/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type Service struct {
+	repo *Repository
+}
+
+func NewService(repo *Repository) *Service {
+	return &Service{repo: repo}
+}
+
+// GetPromotions returns a paginated list of promotions, highest priority
+// first. q searches name and description; isActive filters by state when
+// set.
+func (s *Service) GetPromotions(ctx context.Context, q string, isActive *bool, page, limit int) (*PromotionsListResponse, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 20
+	}
+
+	filter := bson.M{}
+	if q = strings.TrimSpace(q); q != "" {
+		pattern := primitive.Regex{Pattern: regexp.QuoteMeta(q), Options: "i"}
+		filter["$or"] = bson.A{
+			bson.M{"name": pattern},
+			bson.M{"description": pattern},
+		}
+	}
+	if isActive != nil {
+		filter["isActive"] = *isActive
+	}
+
+	opts := options.Find()
+	opts.SetSkip(int64((page - 1) * limit))
+	opts.SetLimit(int64(limit))
+	opts.SetSort(bson.D{{Key: "priority", Value: -1}, {Key: "createdAt", Value: -1}})
+
+	promotions, err := s.repo.FindPromotions(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	total, err := s.repo.CountPromotions(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	promotionResponses := []PromotionResponse{}
+	for _, promotion := range promotions {
+		promotionResponses = append(promotionResponses, *transformPromotion(promotion))
+	}
+
+	totalPages := int(math.Ceil(float64(total) / float64(limit)))
+
+	return &PromotionsListResponse{
+		Data:       promotionResponses,
+		Page:       page,
+		Limit:      limit,
+		Total:      total,
+		TotalPages: totalPages,
+	}, nil
+}
+
+// GetPromotionByID returns a promotion
+func (s *Service) GetPromotionByID(ctx context.Context, id string) (*PromotionResponse, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errors.New("invalid promotion ID")
+	}
+
+	promotion, err := s.repo.FindPromotionByID(ctx, objectID)
+	if err != nil {
+		return nil, errors.New("promotion not found")
+	}
+
+	return transformPromotion(promotion), nil
+}
+
+// CreatePromotion creates a new promotion
+func (s *Service) CreatePromotion(ctx context.Context, req *CreatePromotionRequest) (*PromotionResponse, error) {
+	isActive := true
+	if req.IsActive != nil {
+		isActive = *req.IsActive
+	}
+
+	promotion := &models.Promotion{
+		Name:                  strings.TrimSpace(req.Name),
+		Description:           req.Description,
+		Priority:              req.Priority,
+		Exclusive:             req.Exclusive,
+		CombinableWithVoucher: req.CombinableWithVoucher,
+		MinQuantity:           req.MinQuantity,
+		MinSubTotal:           req.MinSubTotal,
+		StartsAt:              req.StartsAt,
+		EndsAt:                req.EndsAt,
+		DiscountType:          models.DiscountType(req.DiscountType),
+		DiscountPercent:       req.DiscountPercent,
+		DiscountAmount:        req.DiscountAmount,
+		MaxDiscount:           req.MaxDiscount,
+		Tiers:                 transformTiers(req.Tiers),
+		IsActive:              isActive,
+		CreatedAt:             time.Now(),
+		UpdatedAt:             time.Now(),
+	}
+	if promotion.DiscountType == "" {
+		promotion.DiscountType = models.DiscountTypePercent
+	}
+
+	scopeFields := []struct {
+		ids   *[]primitive.ObjectID
+		hexes []string
+	}{
+		{&promotion.ProductIDs, req.ProductIDs},
+		{&promotion.BrandIDs, req.BrandIDs},
+		{&promotion.CategoryIDs, req.CategoryIDs},
+		{&promotion.RequiredProductIDs, req.RequiredProductIDs},
+		{&promotion.RequiredCategoryIDs, req.RequiredCategoryIDs},
+	}
+	for _, f := range scopeFields {
+		ids, err := parseIDs(f.hexes)
+		if err != nil {
+			return nil, err
+		}
+		*f.ids = ids
+	}
+
+	if err := validatePromotion(promotion); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.CreatePromotion(ctx, promotion); err != nil {
+		return nil, err
+	}
+
+	return transformPromotion(promotion), nil
+}
+
+// UpdatePromotion updates a promotion. The result is validated as a whole
+// so a partial update cannot leave an inconsistent rule behind.
+func (s *Service) UpdatePromotion(ctx context.Context, id string, req *UpdatePromotionRequest) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return errors.New("invalid promotion ID")
+	}
+
+	promotion, err := s.repo.FindPromotionByID(ctx, objectID)
+	if err != nil {
+		return errors.New("promotion not found")
+	}
+
+	if req.Name != nil {
+		promotion.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Description != nil {
+		promotion.Description = *req.Description
+	}
+	if req.Priority != nil {
+		promotion.Priority = *req.Priority
+	}
+	if req.Exclusive != nil {
+		promotion.Exclusive = *req.Exclusive
+	}
+	if req.CombinableWithVoucher != nil {
+		promotion.CombinableWithVoucher = *req.CombinableWithVoucher
+	}
+	if req.MinQuantity != nil {
+		promotion.MinQuantity = *req.MinQuantity
+	}
+	if req.MinSubTotal != nil {
+		promotion.MinSubTotal = *req.MinSubTotal
+	}
+	if req.StartsAt != nil {
+		promotion.StartsAt = *req.StartsAt
+	}
+	if req.EndsAt != nil {
+		promotion.EndsAt = *req.EndsAt
+	}
+	if req.DiscountType != nil {
+		promotion.DiscountType = models.DiscountType(*req.DiscountType)
+	}
+	if req.DiscountPercent != nil {
+		promotion.DiscountPercent = *req.DiscountPercent
+	}
+	if req.DiscountAmount != nil {
+		promotion.DiscountAmount = *req.DiscountAmount
+	}
+	if req.MaxDiscount != nil {
+		promotion.MaxDiscount = *req.MaxDiscount
+	}
+	if req.Tiers != nil {
+		promotion.Tiers = transformTiers(*req.Tiers)
+	}
+	if req.IsActive != nil {
+		promotion.IsActive = *req.IsActive
+	}
+
+	scopeFields := []struct {
+		ids   *[]primitive.ObjectID
+		hexes *[]string
+	}{
+		{&promotion.ProductIDs, req.ProductIDs},
+		{&promotion.BrandIDs, req.BrandIDs},
+		{&promotion.CategoryIDs, req.CategoryIDs},
+		{&promotion.RequiredProductIDs, req.RequiredProductIDs},
+		{&promotion.RequiredCategoryIDs, req.RequiredCategoryIDs},
+	}
+	for _, f := range scopeFields {
+		if f.hexes == nil {
+			continue
+		}
+		ids, err := parseIDs(*f.hexes)
+		if err != nil {
+			return err
+		}
+		*f.ids = ids
+	}
+
+	if err := validatePromotion(promotion); err != nil {
+		return err
+	}
+
+	promotion.UpdatedAt = time.Now()
+	return s.repo.ReplacePromotion(ctx, promotion)
+}
+
+// DeactivatePromotion soft deletes a promotion. Orders keep the record of
+// what it took off.
+func (s *Service) DeactivatePromotion(ctx context.Context, id string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return errors.New("invalid promotion ID")
+	}
+
+	if _, err := s.repo.FindPromotionByID(ctx, objectID); err != nil {
+		return errors.New("promotion not found")
+	}
+
+	return s.repo.UpdatePromotion(ctx, objectID, bson.M{
+		"isActive":  false,
+		"updatedAt": time.Now(),
+	})
+}
+
+func validatePromotion(promotion *models.Promotion) error {
+	if promotion.Name == "" {
+		return errors.New("name is required")
+	}
+	if !promotion.EndsAt.After(promotion.StartsAt) {
+		return errors.New("end date must be after the start date")
+	}
+
+	if len(promotion.Tiers) == 0 {
+		return validateDiscount(promotion.DiscountType, promotion.DiscountPercent, promotion.DiscountAmount)
+	}
+
+	seen := map[float64]bool{}
+	for _, tier := range promotion.Tiers {
+		if seen[tier.MinSubTotal] {
+			return fmt.Errorf("more than one tier starts at %.0f", tier.MinSubTotal)
+		}
+		seen[tier.MinSubTotal] = true
+		if err := validateDiscount(promotion.DiscountType, tier.DiscountPercent, tier.DiscountAmount); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateDiscount(discountType models.DiscountType, percent, amount float64) error {
+	switch discountType {
+	case models.DiscountTypePercent:
+		if percent <= 0 || percent > 100 {
+			return errors.New("discount percent must be between 0 and 100")
+		}
+	case models.DiscountTypeFixed:
+		if amount <= 0 {
+			return errors.New("discount amount must be greater than 0")
+		}
+	default:
+		return errors.New("invalid discount type")
+	}
+	return nil
+}
+
+func transformTiers(requests []TierRequest) []models.PromotionTier {
+	tiers := make([]models.PromotionTier, 0, len(requests))
+	for _, tier := range requests {
+		tiers = append(tiers, models.PromotionTier{
+			MinSubTotal:     tier.MinSubTotal,
+			DiscountPercent: tier.DiscountPercent,
+			DiscountAmount:  tier.DiscountAmount,
+		})
+	}
+	return tiers
+}
+
+func parseIDs(hexes []string) ([]primitive.ObjectID, error) {
+	ids := make([]primitive.ObjectID, 0, len(hexes))
+	for _, hex := range hexes {
+		id, err := primitive.ObjectIDFromHex(hex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ID in promotion conditions: %s", hex)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func hexIDs(ids []primitive.ObjectID) []string {
+	hexes := make([]string, 0, len(ids))
+	for _, id := range ids {
+		hexes = append(hexes, id.Hex())
+	}
+	return hexes
+}
+
+func transformPromotion(promotion *models.Promotion) *PromotionResponse {
+	tiers := promotion.Tiers
+	if tiers == nil {
+		tiers = []models.PromotionTier{}
+	}
+
+	return &PromotionResponse{
+		ID:                    promotion.ID.Hex(),
+		Name:                  promotion.Name,
+		Description:           promotion.Description,
+		Priority:              promotion.Priority,
+		Exclusive:             promotion.Exclusive,
+		CombinableWithVoucher: promotion.CombinableWithVoucher,
+		ProductIDs:            hexIDs(promotion.ProductIDs),
+		BrandIDs:              hexIDs(promotion.BrandIDs),
+		CategoryIDs:           hexIDs(promotion.CategoryIDs),
+		RequiredProductIDs:    hexIDs(promotion.RequiredProductIDs),
+		RequiredCategoryIDs:   hexIDs(promotion.RequiredCategoryIDs),
+		MinQuantity:           promotion.MinQuantity,
+		MinSubTotal:           promotion.MinSubTotal,
+		StartsAt:              promotion.StartsAt,
+		EndsAt:                promotion.EndsAt,
+		DiscountType:          string(promotion.DiscountType),
+		DiscountPercent:       promotion.DiscountPercent,
+		DiscountAmount:        promotion.DiscountAmount,
+		MaxDiscount:           promotion.MaxDiscount,
+		Tiers:                 tiers,
+		IsActive:              promotion.IsActive,
+		CreatedAt:             promotion.CreatedAt,
+		UpdatedAt:             promotion.UpdatedAt,
+	}
+}
@@ -0,0 +1,52 @@
+package returns
+
+import "time"
+
+// CreateReturnRequest DTO for a customer opening a return on an order item
+type CreateReturnRequest struct {
+	OrderID     string   `json:"orderId" binding:"required"`
+	OrderItemID string   `json:"orderItemId" binding:"required"`
+	Quantity    int      `json:"quantity" binding:"required,min=1"`
+	Reason      string   `json:"reason" binding:"required"`
+	Photos      []string `json:"photos"`
+}
+
+// ReviewReturnRequest DTO for staff approving or rejecting a return
+type ReviewReturnRequest struct {
+	Note string `json:"note"`
+}
+
+// ReceiveReturnRequest DTO for staff receiving returned goods
+type ReceiveReturnRequest struct {
+	Disposition string `json:"disposition" binding:"required,oneof=RESTOCK WRITE_OFF"`
+	Note        string `json:"note"`
+}
+
+// ReturnResponse DTO for return request response
+type ReturnResponse struct {
+	ID           string     `json:"id"`
+	OrderID      string     `json:"orderId"`
+	OrderItemID  string     `json:"orderItemId"`
+	VariantID    string     `json:"variantId"`
+	UserID       string     `json:"userId"`
+	Quantity     int        `json:"quantity"`
+	Reason       string     `json:"reason"`
+	Photos       []string   `json:"photos"`
+	Status       string     `json:"status"`
+	Disposition  string     `json:"disposition,omitempty"`
+	RefundAmount float64    `json:"refundAmount"`
+	RefundID     string     `json:"refundId,omitempty"`
+	StaffNote    string     `json:"staffNote,omitempty"`
+	ReviewedAt   *time.Time `json:"reviewedAt,omitempty"`
+	ReceivedAt   *time.Time `json:"receivedAt,omitempty"`
+	CreatedAt    time.Time  `json:"createdAt"`
+}
+
+// ReturnsListResponse DTO for paginated returns list
+type ReturnsListResponse struct {
+	Data       []ReturnResponse `json:"data"`
+	Page       int              `json:"page"`
+	Limit      int              `json:"limit"`
+	Total      int64            `json:"total"`
+	TotalPages int              `json:"totalPages"`
+}
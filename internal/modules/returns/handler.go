@@ -0,0 +1,186 @@
+package returns
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// CreateReturn godoc
+// @Summary Open a return request for a delivered order item
+// @Tags Returns
+// @Security BearerAuth
+// @Param request body CreateReturnRequest true "Return data"
+// @Success 201 {object} ReturnResponse
+// @Router /api/returns [post]
+func (h *Handler) CreateReturn(c *gin.Context) {
+	userID := c.GetString("userID")
+
+	var req CreateReturnRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid request data",
+			"data":    err.Error(),
+		})
+		return
+	}
+
+	ret, err := h.service.CreateReturn(c.Request.Context(), userID, &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Return request created successfully",
+		"data":    ret,
+	})
+}
+
+// GetMyReturns godoc
+// @Summary Get my return requests
+// @Tags Returns
+// @Security BearerAuth
+// @Success 200 {array} ReturnResponse
+// @Router /api/returns/me [get]
+func (h *Handler) GetMyReturns(c *gin.Context) {
+	userID := c.GetString("userID")
+
+	returns, err := h.service.GetMyReturns(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Return requests retrieved successfully",
+		"data":    returns,
+	})
+}
+
+// GetReturns godoc
+// @Summary Get all return requests (staff/admin)
+// @Tags Returns
+// @Security BearerAuth
+// @Param page query int false "Page number"
+// @Param limit query int false "Limit per page"
+// @Param status query string false "Return status"
+// @Success 200 {object} ReturnsListResponse
+// @Router /api/admin/returns [get]
+func (h *Handler) GetReturns(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	resp, err := h.service.GetReturns(c.Request.Context(), page, limit, c.Query("status"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Return requests retrieved successfully",
+		"data":    resp,
+	})
+}
+
+// ApproveReturn godoc
+// @Summary Approve a return request and create a pending refund (staff/admin)
+// @Tags Returns
+// @Security BearerAuth
+// @Param id path string true "Return ID"
+// @Param request body ReviewReturnRequest false "Staff note"
+// @Success 200
+// @Router /api/admin/returns/{id}/approve [post]
+func (h *Handler) ApproveReturn(c *gin.Context) {
+	var req ReviewReturnRequest
+	_ = c.ShouldBindJSON(&req)
+
+	err := h.service.ApproveReturn(c.Request.Context(), c.GetString("userID"), c.Param("id"), req.Note)
+	h.respondAction(c, err, "Return request approved")
+}
+
+// RejectReturn godoc
+// @Summary Reject a return request (staff/admin)
+// @Tags Returns
+// @Security BearerAuth
+// @Param id path string true "Return ID"
+// @Param request body ReviewReturnRequest false "Staff note"
+// @Success 200
+// @Router /api/admin/returns/{id}/reject [post]
+func (h *Handler) RejectReturn(c *gin.Context) {
+	var req ReviewReturnRequest
+	_ = c.ShouldBindJSON(&req)
+
+	err := h.service.RejectReturn(c.Request.Context(), c.GetString("userID"), c.Param("id"), req.Note)
+	h.respondAction(c, err, "Return request rejected")
+}
+
+// ReceiveReturn godoc
+// @Summary Receive returned goods and restock or write them off (staff/admin)
+// @Tags Returns
+// @Security BearerAuth
+// @Param id path string true "Return ID"
+// @Param request body ReceiveReturnRequest true "Disposition"
+// @Success 200
+// @Router /api/admin/returns/{id}/receive [post]
+func (h *Handler) ReceiveReturn(c *gin.Context) {
+	var req ReceiveReturnRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid request data",
+			"data":    err.Error(),
+		})
+		return
+	}
+
+	err := h.service.ReceiveReturn(c.Request.Context(), c.GetString("userID"), c.Param("id"), &req)
+	h.respondAction(c, err, "Returned goods received")
+}
+
+func (h *Handler) respondAction(c *gin.Context, err error, message string) {
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, ErrReturnStateChanged) {
+			status = http.StatusConflict
+		}
+		c.JSON(status, gin.H{
+			"success": false,
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": message,
+		"data":    nil,
+	})
+}
@@ -0,0 +1,200 @@
+package returns
+
+import (
+	"context"
+	"time"
+
+	"phone-store-backend/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type Repository struct {
+	db *mongo.Database
+}
+
+func NewRepository(db *mongo.Database) *Repository {
+	return &Repository{db: db}
+}
+
+// WithTransaction runs fn inside a multi-document transaction
+func (r *Repository) WithTransaction(ctx context.Context, fn func(sessCtx mongo.SessionContext) error) error {
+	session, err := r.db.Client().StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sessCtx)
+	})
+	return err
+}
+
+// Return request methods
+func (r *Repository) CreateReturn(ctx context.Context, ret *models.ReturnRequest) error {
+	_, err := r.db.Collection("return_requests").InsertOne(ctx, ret)
+	return err
+}
+
+func (r *Repository) FindReturnByID(ctx context.Context, id primitive.ObjectID) (*models.ReturnRequest, error) {
+	var ret models.ReturnRequest
+	err := r.db.Collection("return_requests").FindOne(ctx, bson.M{"_id": id}).Decode(&ret)
+	if err != nil {
+		return nil, err
+	}
+	return &ret, nil
+}
+
+func (r *Repository) FindReturns(ctx context.Context, filter bson.M, opts *options.FindOptions) ([]*models.ReturnRequest, error) {
+	cursor, err := r.db.Collection("return_requests").Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var returns []*models.ReturnRequest
+	if err := cursor.All(ctx, &returns); err != nil {
+		return nil, err
+	}
+	return returns, nil
+}
+
+func (r *Repository) CountReturns(ctx context.Context, filter bson.M) (int64, error) {
+	return r.db.Collection("return_requests").CountDocuments(ctx, filter)
+}
+
+// SumReturnedQuantity returns how many units of an order item are already
+// covered by open or accepted return requests
+func (r *Repository) SumReturnedQuantity(ctx context.Context, orderItemID primitive.ObjectID) (int, error) {
+	cursor, err := r.db.Collection("return_requests").Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"orderItemId": orderItemID,
+			"status":      bson.M{"$ne": models.ReturnStatusRejected},
+		}}},
+		{{Key: "$group", Value: bson.M{"_id": nil, "quantity": bson.M{"$sum": "$quantity"}}}},
+	})
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var result []struct {
+		Quantity int `bson:"quantity"`
+	}
+	if err := cursor.All(ctx, &result); err != nil {
+		return 0, err
+	}
+	if len(result) == 0 {
+		return 0, nil
+	}
+	return result[0].Quantity, nil
+}
+
+// ReserveReturnQuantity adds quantity to the units of an order item under
+// return, returning false if that would be more than was bought. Items
+// ordered before the counter existed get it from their return requests
+// first.
+func (r *Repository) ReserveReturnQuantity(ctx context.Context, orderItemID primitive.ObjectID, quantity int) (bool, error) {
+	missing := bson.M{"_id": orderItemID, "returnedQuantity": bson.M{"$exists": false}}
+	count, err := r.db.Collection("order_items").CountDocuments(ctx, missing)
+	if err != nil {
+		return false, err
+	}
+	if count > 0 {
+		returned, err := r.SumReturnedQuantity(ctx, orderItemID)
+		if err != nil {
+			return false, err
+		}
+		if _, err := r.db.Collection("order_items").UpdateOne(ctx, missing, bson.M{"$set": bson.M{"returnedQuantity": returned}}); err != nil {
+			return false, err
+		}
+	}
+
+	result, err := r.db.Collection("order_items").UpdateOne(
+		ctx,
+		bson.M{
+			"_id": orderItemID,
+			"$expr": bson.M{"$lte": bson.A{
+				bson.M{"$add": bson.A{"$returnedQuantity", quantity}},
+				"$quantity",
+			}},
+		},
+		bson.M{
+			"$inc": bson.M{"returnedQuantity": quantity},
+			"$set": bson.M{"updatedAt": time.Now()},
+		},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
+// ReleaseReturnQuantity gives back units reserved by a rejected return
+func (r *Repository) ReleaseReturnQuantity(ctx context.Context, orderItemID primitive.ObjectID, quantity int) error {
+	_, err := r.db.Collection("order_items").UpdateOne(
+		ctx,
+		bson.M{"_id": orderItemID},
+		bson.M{
+			"$inc": bson.M{"returnedQuantity": -quantity},
+			"$set": bson.M{"updatedAt": time.Now()},
+		},
+	)
+	return err
+}
+
+// UpdateReturnStatus moves a return from one status to another, returning
+// false if it was no longer in the from status
+func (r *Repository) UpdateReturnStatus(ctx context.Context, id primitive.ObjectID, from models.ReturnStatus, update bson.M) (bool, error) {
+	update["updatedAt"] = time.Now()
+	result, err := r.db.Collection("return_requests").UpdateOne(
+		ctx,
+		bson.M{"_id": id, "status": from},
+		bson.M{"$set": update},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
+// Order lookups
+func (r *Repository) FindOrderByID(ctx context.Context, id primitive.ObjectID) (*models.Order, error) {
+	var order models.Order
+	err := r.db.Collection("orders").FindOne(ctx, bson.M{"_id": id}).Decode(&order)
+	if err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+func (r *Repository) FindOrderItemByID(ctx context.Context, id primitive.ObjectID) (*models.OrderItem, error) {
+	var item models.OrderItem
+	err := r.db.Collection("order_items").FindOne(ctx, bson.M{"_id": id}).Decode(&item)
+	if err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+func (r *Repository) CreateStatusHistory(ctx context.Context, history *models.OrderStatusHistory) error {
+	_, err := r.db.Collection("order_status_history").InsertOne(ctx, history)
+	return err
+}
+
+// Stock
+func (r *Repository) IncreaseStock(ctx context.Context, variantID primitive.ObjectID, quantity int) error {
+	_, err := r.db.Collection("product_variants").UpdateOne(
+		ctx,
+		bson.M{"_id": variantID},
+		bson.M{
+			"$inc": bson.M{"stock": quantity},
+			"$set": bson.M{"updatedAt": time.Now()},
+		},
+	)
+	return err
+}
@@ -0,0 +1,359 @@
+package returns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"phone-store-backend/internal/models"
+	"phone-store-backend/internal/modules/payments"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrReturnStateChanged is returned when a return was reviewed or received
+// by someone else in the meantime
+var ErrReturnStateChanged = errors.New("return request is not in the expected status")
+
+type Service struct {
+	repo    *Repository
+	refunds *payments.Service
+	window  time.Duration
+}
+
+func NewService(repo *Repository, refunds *payments.Service, window time.Duration) *Service {
+	return &Service{repo: repo, refunds: refunds, window: window}
+}
+
+// CreateReturn opens a return request for one order item
+func (s *Service) CreateReturn(ctx context.Context, userID string, req *CreateReturnRequest) (*ReturnResponse, error) {
+	uid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.New("invalid user ID")
+	}
+
+	orderID, err := primitive.ObjectIDFromHex(req.OrderID)
+	if err != nil {
+		return nil, errors.New("invalid order ID")
+	}
+
+	itemID, err := primitive.ObjectIDFromHex(req.OrderItemID)
+	if err != nil {
+		return nil, errors.New("invalid order item ID")
+	}
+
+	order, err := s.repo.FindOrderByID(ctx, orderID)
+	if err != nil || order.UserID != uid {
+		return nil, errors.New("order not found")
+	}
+
+	item, err := s.repo.FindOrderItemByID(ctx, itemID)
+	if err != nil || item.OrderID != order.ID {
+		return nil, errors.New("order item not found")
+	}
+
+	// Returns are only possible within the window after delivery
+	if order.Status != models.OrderStatusCompleted || order.DeliveredAt == nil {
+		return nil, errors.New("order has not been delivered")
+	}
+	if time.Since(*order.DeliveredAt) > s.window {
+		return nil, errors.New("return window has expired")
+	}
+
+	photos := req.Photos
+	if photos == nil {
+		photos = []string{}
+	}
+
+	ret := &models.ReturnRequest{
+		ID:           primitive.NewObjectID(),
+		OrderID:      order.ID,
+		OrderItemID:  item.ID,
+		VariantID:    item.VariantID,
+		UserID:       uid,
+		Quantity:     req.Quantity,
+		Reason:       req.Reason,
+		Photos:       photos,
+		Status:       models.ReturnStatusRequested,
+		RefundAmount: refundAmount(item, req.Quantity),
+		CreatedAt:    time.Now(),
+		UpdatedAt:    time.Now(),
+	}
+
+	// The units are reserved on the order item together with the request,
+	// so concurrent requests cannot return more than was bought
+	err = s.repo.WithTransaction(ctx, func(sessCtx mongo.SessionContext) error {
+		ok, err := s.repo.ReserveReturnQuantity(sessCtx, item.ID, req.Quantity)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			current, err := s.repo.FindOrderItemByID(sessCtx, item.ID)
+			if err != nil {
+				return err
+			}
+			return fmt.Errorf("only %d unit(s) of %s can still be returned", current.Quantity-current.Returned, item.SKU)
+		}
+
+		return s.repo.CreateReturn(sessCtx, ret)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.transformReturn(ret), nil
+}
+
+// GetMyReturns returns the caller's return requests, newest first
+func (s *Service) GetMyReturns(ctx context.Context, userID string) ([]ReturnResponse, error) {
+	uid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, errors.New("invalid user ID")
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}})
+	returns, err := s.repo.FindReturns(ctx, bson.M{"userId": uid}, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var response []ReturnResponse
+	for _, ret := range returns {
+		response = append(response, *s.transformReturn(ret))
+	}
+	return response, nil
+}
+
+// GetReturns returns all return requests (staff) with pagination
+func (s *Service) GetReturns(ctx context.Context, page, limit int, status string) (*ReturnsListResponse, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 20
+	}
+
+	filter := bson.M{}
+	if status != "" {
+		filter["status"] = status
+	}
+
+	opts := options.Find()
+	opts.SetSkip(int64((page - 1) * limit))
+	opts.SetLimit(int64(limit))
+	opts.SetSort(bson.D{{Key: "createdAt", Value: -1}})
+
+	returns, err := s.repo.FindReturns(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	total, err := s.repo.CountReturns(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	var data []ReturnResponse
+	for _, ret := range returns {
+		data = append(data, *s.transformReturn(ret))
+	}
+
+	return &ReturnsListResponse{
+		Data:       data,
+		Page:       page,
+		Limit:      limit,
+		Total:      total,
+		TotalPages: int(math.Ceil(float64(total) / float64(limit))),
+	}, nil
+}
+
+// ApproveReturn accepts a return and records a pending refund against the
+// order's payment
+func (s *Service) ApproveReturn(ctx context.Context, staffID, returnID, note string) error {
+	sid, ret, err := s.loadForReview(ctx, staffID, returnID)
+	if err != nil {
+		return err
+	}
+
+	return s.repo.WithTransaction(ctx, func(sessCtx mongo.SessionContext) error {
+		refund, err := s.refunds.RecordRefund(sessCtx, &payments.RefundInput{
+			OrderID:         ret.OrderID,
+			ReturnRequestID: &ret.ID,
+			Amount:          ret.RefundAmount,
+			Reason:          "Return: " + ret.Reason,
+			OperatorID:      sid,
+		})
+		if err != nil {
+			return err
+		}
+
+		ok, err := s.repo.UpdateReturnStatus(sessCtx, ret.ID, models.ReturnStatusRequested, bson.M{
+			"status":     models.ReturnStatusApproved,
+			"refundId":   refund.ID,
+			"staffNote":  note,
+			"reviewedBy": sid,
+			"reviewedAt": time.Now(),
+		})
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrReturnStateChanged
+		}
+
+		return s.logOrderHistory(sessCtx, ret, sid, fmt.Sprintf("Return approved: %d unit(s), refund %.0f", ret.Quantity, ret.RefundAmount))
+	})
+}
+
+// RejectReturn declines a return request and frees its units for another
+// request
+func (s *Service) RejectReturn(ctx context.Context, staffID, returnID, note string) error {
+	sid, ret, err := s.loadForReview(ctx, staffID, returnID)
+	if err != nil {
+		return err
+	}
+
+	return s.repo.WithTransaction(ctx, func(sessCtx mongo.SessionContext) error {
+		ok, err := s.repo.UpdateReturnStatus(sessCtx, ret.ID, models.ReturnStatusRequested, bson.M{
+			"status":     models.ReturnStatusRejected,
+			"staffNote":  note,
+			"reviewedBy": sid,
+			"reviewedAt": time.Now(),
+		})
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrReturnStateChanged
+		}
+
+		return s.repo.ReleaseReturnQuantity(sessCtx, ret.OrderItemID, ret.Quantity)
+	})
+}
+
+// ReceiveReturn records that the goods arrived back and either restocks
+// them or writes them off
+func (s *Service) ReceiveReturn(ctx context.Context, staffID, returnID string, req *ReceiveReturnRequest) error {
+	sid, err := primitive.ObjectIDFromHex(staffID)
+	if err != nil {
+		return errors.New("invalid user ID")
+	}
+
+	rid, err := primitive.ObjectIDFromHex(returnID)
+	if err != nil {
+		return errors.New("invalid return ID")
+	}
+
+	ret, err := s.repo.FindReturnByID(ctx, rid)
+	if err != nil {
+		return errors.New("return request not found")
+	}
+
+	disposition := models.ReturnDisposition(req.Disposition)
+
+	return s.repo.WithTransaction(ctx, func(sessCtx mongo.SessionContext) error {
+		update := bson.M{
+			"status":      models.ReturnStatusReceived,
+			"disposition": disposition,
+			"receivedAt":  time.Now(),
+		}
+		if req.Note != "" {
+			update["staffNote"] = req.Note
+		}
+
+		ok, err := s.repo.UpdateReturnStatus(sessCtx, ret.ID, models.ReturnStatusApproved, update)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrReturnStateChanged
+		}
+
+		if disposition == models.ReturnDispositionRestock {
+			if err := s.repo.IncreaseStock(sessCtx, ret.VariantID, ret.Quantity); err != nil {
+				return err
+			}
+		}
+
+		return s.logOrderHistory(sessCtx, ret, sid, fmt.Sprintf("Returned goods received: %d unit(s), %s", ret.Quantity, disposition))
+	})
+}
+
+func (s *Service) loadForReview(ctx context.Context, staffID, returnID string) (primitive.ObjectID, *models.ReturnRequest, error) {
+	sid, err := primitive.ObjectIDFromHex(staffID)
+	if err != nil {
+		return sid, nil, errors.New("invalid user ID")
+	}
+
+	rid, err := primitive.ObjectIDFromHex(returnID)
+	if err != nil {
+		return sid, nil, errors.New("invalid return ID")
+	}
+
+	ret, err := s.repo.FindReturnByID(ctx, rid)
+	if err != nil {
+		return sid, nil, errors.New("return request not found")
+	}
+
+	if ret.Status != models.ReturnStatusRequested {
+		return sid, nil, ErrReturnStateChanged
+	}
+
+	return sid, ret, nil
+}
+
+// logOrderHistory adds an entry to the order's status history without
+// changing the order status itself
+func (s *Service) logOrderHistory(sessCtx mongo.SessionContext, ret *models.ReturnRequest, staffID primitive.ObjectID, note string) error {
+	order, err := s.repo.FindOrderByID(sessCtx, ret.OrderID)
+	if err != nil {
+		return err
+	}
+
+	return s.repo.CreateStatusHistory(sessCtx, &models.OrderStatusHistory{
+		ID:         primitive.NewObjectID(),
+		OrderID:    order.ID,
+		FromStatus: string(order.Status),
+		Status:     string(order.Status),
+		Note:       note,
+		Actor:      "STAFF",
+		UpdatedBy:  staffID,
+		CreatedAt:  time.Now(),
+	})
+}
+
+func (s *Service) transformReturn(ret *models.ReturnRequest) *ReturnResponse {
+	resp := &ReturnResponse{
+		ID:           ret.ID.Hex(),
+		OrderID:      ret.OrderID.Hex(),
+		OrderItemID:  ret.OrderItemID.Hex(),
+		VariantID:    ret.VariantID.Hex(),
+		UserID:       ret.UserID.Hex(),
+		Quantity:     ret.Quantity,
+		Reason:       ret.Reason,
+		Photos:       ret.Photos,
+		Status:       string(ret.Status),
+		Disposition:  string(ret.Disposition),
+		RefundAmount: ret.RefundAmount,
+		StaffNote:    ret.StaffNote,
+		ReviewedAt:   ret.ReviewedAt,
+		ReceivedAt:   ret.ReceivedAt,
+		CreatedAt:    ret.CreatedAt,
+	}
+	if ret.RefundID != nil {
+		resp.RefundID = ret.RefundID.Hex()
+	}
+	return resp
+}
+
+// refundAmount is what quantity units of item were actually paid: the unit
+// price minus the line's pro-rated share of the order discount
+func refundAmount(item *models.OrderItem, quantity int) float64 {
+	paid := item.Price*float64(item.Quantity) - item.Discount
+	return math.Round(paid * float64(quantity) / float64(item.Quantity))
+}
@@ -0,0 +1,167 @@
+package returns
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"phone-store-backend/internal/models"
+	"phone-store-backend/internal/testdb"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// seedDeliveredOrder saves a completed order with one item of quantity units
+func seedDeliveredOrder(t *testing.T, database *mongo.Database, status models.OrderStatus, deliveredAt *time.Time, quantity int) (*models.Order, *models.OrderItem) {
+	t.Helper()
+	ctx := context.Background()
+	now := time.Now()
+
+	order := &models.Order{
+		ID:          primitive.NewObjectID(),
+		OrderNumber: "ORD-1",
+		UserID:      primitive.NewObjectID(),
+		Total:       40000000,
+		Status:      status,
+		DeliveredAt: deliveredAt,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	item := &models.OrderItem{
+		ID:        primitive.NewObjectID(),
+		OrderID:   order.ID,
+		VariantID: primitive.NewObjectID(),
+		SKU:       "IP15-128-BLK",
+		Price:     20000000,
+		Quantity:  quantity,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	if _, err := database.Collection("orders").InsertOne(ctx, order); err != nil {
+		t.Fatalf("seed order: %v", err)
+	}
+	if _, err := database.Collection("order_items").InsertOne(ctx, item); err != nil {
+		t.Fatalf("seed order item: %v", err)
+	}
+	return order, item
+}
+
+func newReturnRequest(order *models.Order, item *models.OrderItem, quantity int) *CreateReturnRequest {
+	return &CreateReturnRequest{
+		OrderID:     order.ID.Hex(),
+		OrderItemID: item.ID.Hex(),
+		Quantity:    quantity,
+		Reason:      "Screen has dead pixels",
+	}
+}
+
+func TestCreateReturnNeedsDeliveredOrder(t *testing.T) {
+	database := testdb.Open(t)
+	service := NewService(NewRepository(database), nil, 7*24*time.Hour)
+	ctx := context.Background()
+
+	order, item := seedDeliveredOrder(t, database, models.OrderStatusShipping, nil, 1)
+	if _, err := service.CreateReturn(ctx, order.UserID.Hex(), newReturnRequest(order, item, 1)); err == nil {
+		t.Error("return opened for an order that is still shipping")
+	}
+
+	longAgo := time.Now().AddDate(0, 0, -30)
+	order, item = seedDeliveredOrder(t, database, models.OrderStatusCompleted, &longAgo, 1)
+	if _, err := service.CreateReturn(ctx, order.UserID.Hex(), newReturnRequest(order, item, 1)); err == nil {
+		t.Error("return opened after the return window")
+	}
+}
+
+func TestCreateReturnConcurrentRequestsNeverExceedBoughtQuantity(t *testing.T) {
+	const bought, requests = 2, 10
+	database := testdb.Open(t)
+	service := NewService(NewRepository(database), nil, 7*24*time.Hour)
+	ctx := context.Background()
+
+	deliveredAt := time.Now().Add(-time.Hour)
+	order, item := seedDeliveredOrder(t, database, models.OrderStatusCompleted, &deliveredAt, bought)
+
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		opened int
+	)
+	start := make(chan struct{})
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			_, err := service.CreateReturn(ctx, order.UserID.Hex(), newReturnRequest(order, item, 1))
+			if err == nil {
+				mu.Lock()
+				opened++
+				mu.Unlock()
+			}
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	if opened != bought {
+		t.Errorf("opened %d returns, want %d", opened, bought)
+	}
+	saved, err := database.Collection("return_requests").CountDocuments(ctx, bson.M{"orderItemId": item.ID})
+	if err != nil {
+		t.Fatalf("count returns: %v", err)
+	}
+	if saved != bought {
+		t.Errorf("%d return requests saved, want %d", saved, bought)
+	}
+}
+
+func TestRejectReturnFreesItsUnits(t *testing.T) {
+	database := testdb.Open(t)
+	service := NewService(NewRepository(database), nil, 7*24*time.Hour)
+	ctx := context.Background()
+
+	deliveredAt := time.Now().Add(-time.Hour)
+	order, item := seedDeliveredOrder(t, database, models.OrderStatusCompleted, &deliveredAt, 1)
+
+	ret, err := service.CreateReturn(ctx, order.UserID.Hex(), newReturnRequest(order, item, 1))
+	if err != nil {
+		t.Fatalf("open return: %v", err)
+	}
+	if _, err := service.CreateReturn(ctx, order.UserID.Hex(), newReturnRequest(order, item, 1)); err == nil {
+		t.Fatal("second return opened for a single unit")
+	}
+
+	if err := service.RejectReturn(ctx, primitive.NewObjectID().Hex(), ret.ID, "No defect found"); err != nil {
+		t.Fatalf("reject return: %v", err)
+	}
+	if _, err := service.CreateReturn(ctx, order.UserID.Hex(), newReturnRequest(order, item, 1)); err != nil {
+		t.Errorf("return after a rejection: %v", err)
+	}
+}
+
+func TestRefundAmount(t *testing.T) {
+	tests := []struct {
+		name            string
+		price, discount float64
+		bought, returns int
+		want            float64
+	}{
+		{"whole line without discount", 10000000, 0, 2, 2, 20000000},
+		{"one unit without discount", 10000000, 0, 2, 1, 10000000},
+		{"whole line takes the whole discount", 10000000, 1500000, 2, 2, 18500000},
+		{"one unit takes its share", 10000000, 1500000, 2, 1, 9250000},
+		{"uneven share rounded to whole VND", 1000001, 100001, 3, 1, 966667},
+		{"whole line with an uneven discount", 1000001, 100001, 3, 3, 2900002},
+		{"free gift", 0, 0, 1, 1, 0},
+	}
+	for _, tt := range tests {
+		item := &models.OrderItem{Price: tt.price, Quantity: tt.bought, Discount: tt.discount}
+		if got := refundAmount(item, tt.returns); got != tt.want {
+			t.Errorf("%s: refunded %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
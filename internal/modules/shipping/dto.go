@@ -46,7 +46,7 @@ type ShippingMethodResponse struct {
 // Shipment DTOs
 type CreateShipmentRequest struct {
 	OrderID          string `json:"orderId" binding:"required"`
-	ShippingMethodID string `json:"shippingMethodId" binding:"required"`
+	ShippingMethodID string `json:"shippingMethodId"` // Defaults to the method chosen at checkout
 	TrackingNumber   string `json:"trackingNumber"`
 }
 
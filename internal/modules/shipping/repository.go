@@ -14,6 +14,7 @@ type Repository struct {
 	addressCollection  *mongo.Collection
 	methodCollection   *mongo.Collection
 	shipmentCollection *mongo.Collection
+	orderCollection    *mongo.Collection
 }
 
 func NewRepository(db *mongo.Database) *Repository {
@@ -21,6 +22,7 @@ func NewRepository(db *mongo.Database) *Repository {
 		addressCollection:  db.Collection("shipping_addresses"),
 		methodCollection:   db.Collection("shipping_methods"),
 		shipmentCollection: db.Collection("shipments"),
+		orderCollection:    db.Collection("orders"),
 	}
 }
 
@@ -49,6 +51,16 @@ func (r *Repository) FindAddressByID(ctx context.Context, id primitive.ObjectID)
 	return &address, nil
 }
 
+// FindDefaultAddress finds the address a user marked as default
+func (r *Repository) FindDefaultAddress(ctx context.Context, userID primitive.ObjectID) (*models.ShippingAddress, error) {
+	var address models.ShippingAddress
+	err := r.addressCollection.FindOne(ctx, bson.M{"userId": userID, "isDefault": true}).Decode(&address)
+	if err != nil {
+		return nil, err
+	}
+	return &address, nil
+}
+
 func (r *Repository) CreateAddress(ctx context.Context, address *models.ShippingAddress) error {
 	result, err := r.addressCollection.InsertOne(ctx, address)
 	if err != nil {
@@ -120,3 +132,13 @@ func (r *Repository) UpdateShipment(ctx context.Context, id primitive.ObjectID,
 	_, err := r.shipmentCollection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": update})
 	return err
 }
+
+// FindOrderByID finds the order a shipment is for
+func (r *Repository) FindOrderByID(ctx context.Context, id primitive.ObjectID) (*models.Order, error) {
+	var order models.Order
+	err := r.orderCollection.FindOne(ctx, bson.M{"_id": id}).Decode(&order)
+	if err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
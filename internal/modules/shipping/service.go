@@ -154,9 +154,22 @@ func (s *Service) CreateShipment(ctx context.Context, req *CreateShipmentRequest
 		return errors.New("invalid order ID")
 	}
 
-	shippingMethodID, err := primitive.ObjectIDFromHex(req.ShippingMethodID)
+	order, err := s.repo.FindOrderByID(ctx, orderID)
 	if err != nil {
-		return errors.New("invalid shipping method ID")
+		return errors.New("order not found")
+	}
+
+	// Ship with the method the customer chose unless staff picks another
+	var shippingMethodID primitive.ObjectID
+	if req.ShippingMethodID != "" {
+		shippingMethodID, err = primitive.ObjectIDFromHex(req.ShippingMethodID)
+		if err != nil {
+			return errors.New("invalid shipping method ID")
+		}
+	} else if order.ShippingMethod != nil {
+		shippingMethodID = order.ShippingMethod.ID
+	} else {
+		return errors.New("shipping method ID is required for orders placed without one")
 	}
 
 	shipment := &models.Shipment{
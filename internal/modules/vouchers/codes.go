@@ -0,0 +1,348 @@
+package vouchers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"strings"
+	"time"
+
+	"phone-store-backend/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// defaultCodeAlphabet leaves out 0/O and 1/I so codes survive being read
+	// aloud or typed from a printed card
+	defaultCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+	defaultCodeLength   = 8
+
+	codeBatchSize = 1000
+	// maxCodeRounds bounds the retries when many generated codes collide
+	maxCodeRounds = 20
+
+	// codeGenerationLease bounds how long a run that died keeps others
+	// from generating codes for the same voucher
+	codeGenerationLease = 10 * time.Minute
+)
+
+// ErrCodeGenerationInProgress is returned while another run is generating
+// codes for the same voucher
+var ErrCodeGenerationInProgress = errors.New("codes are already being generated for this voucher; try again when that run finishes")
+
+// GenerateCodes creates count single-use codes for a voucher, each made of
+// prefix and length random characters from alphabet. The voucher becomes a
+// template that can then only be redeemed through its generated codes.
+func (s *Service) GenerateCodes(ctx context.Context, id string, req *GenerateCodesRequest) (*GenerateCodesResponse, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errors.New("invalid voucher ID")
+	}
+
+	voucher, err := s.repo.FindVoucherByID(ctx, objectID)
+	if err != nil {
+		return nil, errors.New("voucher not found")
+	}
+
+	prefix := strings.ToUpper(strings.TrimSpace(req.Prefix))
+	if prefix != "" && !codePattern.MatchString(prefix) {
+		return nil, errors.New("prefix may only contain letters, digits and dashes")
+	}
+
+	alphabet, err := codeAlphabet(req.Alphabet)
+	if err != nil {
+		return nil, err
+	}
+
+	length := req.Length
+	if length == 0 {
+		length = defaultCodeLength
+	}
+
+	// Keep the code space far larger than the batch so that collisions stay
+	// rare and codes cannot be guessed from each other
+	if math.Pow(float64(len(alphabet)), float64(length)) < float64(req.Count)*1000 {
+		return nil, errors.New("too many codes for this alphabet and length; use a longer code or a larger alphabet")
+	}
+
+	// Runs for the same voucher take turns, so that the room left in the
+	// usage limit is counted and filled by one run at a time
+	claimed, err := s.repo.ClaimCodeGeneration(ctx, objectID, time.Now().Add(codeGenerationLease))
+	if err != nil {
+		return nil, err
+	}
+	if !claimed {
+		return nil, ErrCodeGenerationInProgress
+	}
+	defer func() {
+		if err := s.repo.ReleaseCodeGeneration(context.Background(), objectID); err != nil {
+			log.Printf("Failed to release code generation of voucher %s: %v", id, err)
+		}
+	}()
+
+	// Reloaded under the claim: redemptions may have changed since
+	voucher, err = s.repo.FindVoucherByID(ctx, objectID)
+	if err != nil {
+		return nil, errors.New("voucher not found")
+	}
+
+	// Every code shares the template's usage limit, so codes beyond what is
+	// left of it could never be redeemed
+	if voucher.UsageLimit > 0 {
+		available, err := s.repo.CountVoucherCodes(ctx, bson.M{
+			"voucherId": voucher.ID,
+			"status":    models.VoucherCodeStatusAvailable,
+		})
+		if err != nil {
+			return nil, err
+		}
+		remaining := voucher.UsageLimit - voucher.UsedCount - int(available)
+		if req.Count > remaining {
+			return nil, fmt.Errorf("the voucher's usage limit leaves room for %d more code(s); raise the limit first", max(remaining, 0))
+		}
+	}
+
+	if !voucher.CodesOnly {
+		if err := s.repo.UpdateVoucher(ctx, objectID, bson.M{
+			"codesOnly": true,
+			"updatedAt": time.Now(),
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	generated := 0
+	for round := 0; generated < req.Count; round++ {
+		if round == maxCodeRounds {
+			return nil, fmt.Errorf("generated %d of %d codes; too many collisions, try a longer code", generated, req.Count)
+		}
+
+		batch, err := s.newCodeBatch(ctx, voucher.ID, prefix, alphabet, length, min(req.Count-generated, codeBatchSize))
+		if err != nil {
+			return nil, err
+		}
+		if len(batch) == 0 {
+			continue
+		}
+
+		inserted, err := s.repo.InsertVoucherCodes(ctx, batch)
+		if err != nil {
+			return nil, err
+		}
+		generated += inserted
+	}
+
+	return &GenerateCodesResponse{
+		VoucherID: voucher.ID.Hex(),
+		Generated: generated,
+	}, nil
+}
+
+// newCodeBatch draws n distinct codes, leaving out any that are already a
+// voucher's own code. Collisions with generated codes are caught by the
+// unique index on insert.
+func (s *Service) newCodeBatch(ctx context.Context, voucherID primitive.ObjectID, prefix, alphabet string, length, n int) ([]*models.VoucherCode, error) {
+	seen := make(map[string]bool, n)
+	codes := make([]string, 0, n)
+	for len(codes) < n {
+		code, err := randomCode(prefix, alphabet, length)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[code] {
+			seen[code] = true
+			codes = append(codes, code)
+		}
+	}
+
+	taken, err := s.repo.FindTakenVoucherCodes(ctx, codes)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	batch := make([]*models.VoucherCode, 0, len(codes))
+	for _, code := range codes {
+		if taken[code] {
+			continue
+		}
+		batch = append(batch, &models.VoucherCode{
+			ID:        primitive.NewObjectID(),
+			VoucherID: voucherID,
+			Code:      code,
+			Status:    models.VoucherCodeStatusAvailable,
+			CreatedAt: now,
+		})
+	}
+	return batch, nil
+}
+
+// GetCodes returns the generated codes of a voucher. status filters by
+// AVAILABLE or REDEEMED when set.
+func (s *Service) GetCodes(ctx context.Context, id, status string, page, limit int) (*VoucherCodesListResponse, error) {
+	filter, err := codesFilter(id, status)
+	if err != nil {
+		return nil, err
+	}
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 20
+	}
+
+	opts := options.Find()
+	opts.SetSkip(int64((page - 1) * limit))
+	opts.SetLimit(int64(limit))
+	opts.SetSort(bson.D{{Key: "_id", Value: 1}})
+
+	codes, err := s.repo.FindVoucherCodes(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	total, err := s.repo.CountVoucherCodes(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	codeResponses := []VoucherCodeResponse{}
+	for _, code := range codes {
+		codeResponses = append(codeResponses, transformVoucherCode(code))
+	}
+
+	totalPages := int(math.Ceil(float64(total) / float64(limit)))
+
+	return &VoucherCodesListResponse{
+		Data:       codeResponses,
+		Page:       page,
+		Limit:      limit,
+		Total:      total,
+		TotalPages: totalPages,
+	}, nil
+}
+
+// ExportCodes writes the generated codes of a voucher to w as CSV
+func (s *Service) ExportCodes(ctx context.Context, id, status string, w io.Writer) error {
+	filter, err := codesFilter(id, status)
+	if err != nil {
+		return err
+	}
+
+	out := csv.NewWriter(w)
+	if err := out.Write([]string{"code", "status", "userId", "orderId", "redeemedAt", "createdAt"}); err != nil {
+		return err
+	}
+
+	err = s.repo.EachVoucherCode(ctx, filter, func(code *models.VoucherCode) error {
+		resp := transformVoucherCode(code)
+		redeemedAt := ""
+		if resp.RedeemedAt != nil {
+			redeemedAt = resp.RedeemedAt.Format(time.RFC3339)
+		}
+		return out.Write([]string{
+			resp.Code,
+			resp.Status,
+			resp.UserID,
+			resp.OrderID,
+			redeemedAt,
+			resp.CreatedAt.Format(time.RFC3339),
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	out.Flush()
+	return out.Error()
+}
+
+func codesFilter(id, status string) (bson.M, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errors.New("invalid voucher ID")
+	}
+
+	filter := bson.M{"voucherId": objectID}
+	switch models.VoucherCodeStatus(status) {
+	case "":
+	case models.VoucherCodeStatusAvailable, models.VoucherCodeStatusRedeemed:
+		filter["status"] = status
+	default:
+		return nil, errors.New("invalid code status")
+	}
+	return filter, nil
+}
+
+// codeAlphabet validates a custom alphabet and removes repeated characters
+func codeAlphabet(alphabet string) (string, error) {
+	alphabet = strings.ToUpper(strings.TrimSpace(alphabet))
+	if alphabet == "" {
+		return defaultCodeAlphabet, nil
+	}
+
+	var unique strings.Builder
+	for _, r := range alphabet {
+		if !(r >= 'A' && r <= 'Z' || r >= '0' && r <= '9') {
+			return "", errors.New("alphabet may only contain letters and digits")
+		}
+		if !strings.ContainsRune(unique.String(), r) {
+			unique.WriteRune(r)
+		}
+	}
+	if unique.Len() < 2 {
+		return "", errors.New("alphabet needs at least 2 different characters")
+	}
+	return unique.String(), nil
+}
+
+// randomCode draws length characters from alphabet with crypto/rand.
+// Bytes that would bias the draw towards the start of the alphabet are
+// rejected.
+func randomCode(prefix, alphabet string, length int) (string, error) {
+	limit := 256 - 256%len(alphabet)
+	code := make([]byte, 0, len(prefix)+length)
+	code = append(code, prefix...)
+
+	buf := make([]byte, length*2)
+	for len(code) < len(prefix)+length {
+		if _, err := rand.Read(buf); err != nil {
+			return "", err
+		}
+		for _, b := range buf {
+			if int(b) >= limit {
+				continue
+			}
+			code = append(code, alphabet[int(b)%len(alphabet)])
+			if len(code) == len(prefix)+length {
+				break
+			}
+		}
+	}
+	return string(code), nil
+}
+
+func transformVoucherCode(code *models.VoucherCode) VoucherCodeResponse {
+	resp := VoucherCodeResponse{
+		ID:         code.ID.Hex(),
+		Code:       code.Code,
+		Status:     string(code.Status),
+		RedeemedAt: code.RedeemedAt,
+		CreatedAt:  code.CreatedAt,
+	}
+	if code.UserID != nil {
+		resp.UserID = code.UserID.Hex()
+	}
+	if code.OrderID != nil {
+		resp.OrderID = code.OrderID.Hex()
+	}
+	return resp
+}
@@ -0,0 +1,134 @@
+package vouchers
+
+import "time"
+
+// CreateVoucherRequest DTO for creating a voucher
+type CreateVoucherRequest struct {
+	Code            string     `json:"code" binding:"required,min=3,max=32"`
+	Description     string     `json:"description"`
+	DiscountType    string     `json:"discountType" binding:"omitempty,oneof=PERCENT FIXED FREE_SHIPPING"`
+	DiscountPercent float64    `json:"discountPercent" binding:"gte=0,lte=100"`
+	DiscountAmount  float64    `json:"discountAmount" binding:"gte=0"`
+	MaxDiscount     float64    `json:"maxDiscount" binding:"gte=0"`
+	ProductIDs      []string   `json:"productIds"`
+	VariantIDs      []string   `json:"variantIds"`
+	BrandIDs        []string   `json:"brandIds"`
+	CategoryIDs     []string   `json:"categoryIds"`
+	MinOrderValue   float64    `json:"minOrderValue" binding:"gte=0"`
+	StartsAt        *time.Time `json:"startsAt"`
+	ExpiredAt       time.Time  `json:"expiredAt" binding:"required"`
+	UsageLimit      int        `json:"usageLimit" binding:"gte=0"`
+	PerUserLimit    int        `json:"perUserLimit" binding:"gte=0"`
+	FirstOrderOnly  bool       `json:"firstOrderOnly"`
+	IsActive        *bool      `json:"isActive"`
+}
+
+// UpdateVoucherRequest DTO for updating a voucher. The code cannot change
+// once orders may reference it.
+type UpdateVoucherRequest struct {
+	Description     *string    `json:"description"`
+	DiscountType    *string    `json:"discountType" binding:"omitempty,oneof=PERCENT FIXED FREE_SHIPPING"`
+	DiscountPercent *float64   `json:"discountPercent" binding:"omitempty,gte=0,lte=100"`
+	DiscountAmount  *float64   `json:"discountAmount" binding:"omitempty,gte=0"`
+	MaxDiscount     *float64   `json:"maxDiscount" binding:"omitempty,gte=0"`
+	ProductIDs      *[]string  `json:"productIds"`
+	VariantIDs      *[]string  `json:"variantIds"`
+	BrandIDs        *[]string  `json:"brandIds"`
+	CategoryIDs     *[]string  `json:"categoryIds"`
+	MinOrderValue   *float64   `json:"minOrderValue" binding:"omitempty,gte=0"`
+	StartsAt        *time.Time `json:"startsAt"`
+	ExpiredAt       *time.Time `json:"expiredAt"`
+	UsageLimit      *int       `json:"usageLimit" binding:"omitempty,gte=0"`
+	PerUserLimit    *int       `json:"perUserLimit" binding:"omitempty,gte=0"`
+	FirstOrderOnly  *bool      `json:"firstOrderOnly"`
+	IsActive        *bool      `json:"isActive"`
+}
+
+// VoucherResponse DTO for voucher response
+type VoucherResponse struct {
+	ID              string     `json:"id"`
+	Code            string     `json:"code"`
+	Description     string     `json:"description"`
+	DiscountType    string     `json:"discountType"`
+	DiscountPercent float64    `json:"discountPercent"`
+	DiscountAmount  float64    `json:"discountAmount"`
+	MaxDiscount     float64    `json:"maxDiscount"`
+	ProductIDs      []string   `json:"productIds"`
+	VariantIDs      []string   `json:"variantIds"`
+	BrandIDs        []string   `json:"brandIds"`
+	CategoryIDs     []string   `json:"categoryIds"`
+	MinOrderValue   float64    `json:"minOrderValue"`
+	StartsAt        *time.Time `json:"startsAt,omitempty"`
+	ExpiredAt       time.Time  `json:"expiredAt"`
+	UsageLimit      int        `json:"usageLimit"`
+	PerUserLimit    int        `json:"perUserLimit"`
+	FirstOrderOnly  bool       `json:"firstOrderOnly"`
+	CodesOnly       bool       `json:"codesOnly"` // Redeemed only through generated codes
+	IsActive        bool       `json:"isActive"`
+	UsageCount      int        `json:"usageCount"` // Redemptions by orders that were not canceled
+	CreatedAt       time.Time  `json:"createdAt"`
+	UpdatedAt       time.Time  `json:"updatedAt"`
+}
+
+// VouchersListResponse DTO for paginated vouchers list
+type VouchersListResponse struct {
+	Data       []VoucherResponse `json:"data"`
+	Page       int               `json:"page"`
+	Limit      int               `json:"limit"`
+	Total      int64             `json:"total"`
+	TotalPages int               `json:"totalPages"`
+}
+
+// RedemptionResponse DTO for one use of a voucher
+type RedemptionResponse struct {
+	ID         string     `json:"id"`
+	UserID     string     `json:"userId"`
+	OrderID    string     `json:"orderId"`
+	Discount   float64    `json:"discount"`
+	Status     string     `json:"status"`
+	ReleasedAt *time.Time `json:"releasedAt,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
+
+// RedemptionsListResponse DTO for paginated redemptions list
+type RedemptionsListResponse struct {
+	Data       []RedemptionResponse `json:"data"`
+	Page       int                  `json:"page"`
+	Limit      int                  `json:"limit"`
+	Total      int64                `json:"total"`
+	TotalPages int                  `json:"totalPages"`
+}
+
+// GenerateCodesRequest DTO for generating single-use codes for a voucher
+type GenerateCodesRequest struct {
+	Count    int    `json:"count" binding:"required,min=1,max=100000"`
+	Prefix   string `json:"prefix" binding:"max=16"`
+	Alphabet string `json:"alphabet" binding:"max=36"` // Defaults to letters and digits without look-alikes
+	Length   int    `json:"length" binding:"omitempty,min=4,max=24"`
+}
+
+// GenerateCodesResponse DTO for a code generation run
+type GenerateCodesResponse struct {
+	VoucherID string `json:"voucherId"`
+	Generated int    `json:"generated"`
+}
+
+// VoucherCodeResponse DTO for a generated code
+type VoucherCodeResponse struct {
+	ID         string     `json:"id"`
+	Code       string     `json:"code"`
+	Status     string     `json:"status"`
+	UserID     string     `json:"userId,omitempty"`
+	OrderID    string     `json:"orderId,omitempty"`
+	RedeemedAt *time.Time `json:"redeemedAt,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
+
+// VoucherCodesListResponse DTO for paginated generated codes list
+type VoucherCodesListResponse struct {
+	Data       []VoucherCodeResponse `json:"data"`
+	Page       int                   `json:"page"`
+	Limit      int                   `json:"limit"`
+	Total      int64                 `json:"total"`
+	TotalPages int                   `json:"totalPages"`
+}
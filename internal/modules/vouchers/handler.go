@@ -0,0 +1,319 @@
+package vouchers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// GetVouchers godoc
+// @Summary Get vouchers (admin only)
+// @Tags Vouchers
+// @Security BearerAuth
+// @Param q query string false "Search code or description"
+// @Param isActive query bool false "Filter by active state"
+// @Param page query int false "Page number"
+// @Param limit query int false "Limit per page"
+// @Success 200 {object} VouchersListResponse
+// @Router /api/admin/vouchers [get]
+func (h *Handler) GetVouchers(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	var isActive *bool
+	if value, err := strconv.ParseBool(c.Query("isActive")); err == nil {
+		isActive = &value
+	}
+
+	resp, err := h.service.GetVouchers(c.Request.Context(), c.Query("q"), isActive, page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Vouchers retrieved successfully",
+		"data":    resp,
+	})
+}
+
+// GetVoucherByID godoc
+// @Summary Get voucher by ID (admin only)
+// @Tags Vouchers
+// @Security BearerAuth
+// @Param id path string true "Voucher ID"
+// @Success 200 {object} VoucherResponse
+// @Router /api/admin/vouchers/{id} [get]
+func (h *Handler) GetVoucherByID(c *gin.Context) {
+	resp, err := h.service.GetVoucherByID(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Voucher retrieved successfully",
+		"data":    resp,
+	})
+}
+
+// CreateVoucher godoc
+// @Summary Create a voucher (admin only)
+// @Tags Vouchers
+// @Security BearerAuth
+// @Param request body CreateVoucherRequest true "Voucher data"
+// @Success 201 {object} VoucherResponse
+// @Failure 409 "Voucher code already exists"
+// @Router /api/admin/vouchers [post]
+func (h *Handler) CreateVoucher(c *gin.Context) {
+	var req CreateVoucherRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid request data",
+			"data":    err.Error(),
+		})
+		return
+	}
+
+	resp, err := h.service.CreateVoucher(c.Request.Context(), &req)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, ErrDuplicateCode) {
+			status = http.StatusConflict
+		}
+		c.JSON(status, gin.H{
+			"success": false,
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Voucher created successfully",
+		"data":    resp,
+	})
+}
+
+// UpdateVoucher godoc
+// @Summary Update a voucher (admin only)
+// @Tags Vouchers
+// @Security BearerAuth
+// @Param id path string true "Voucher ID"
+// @Param request body UpdateVoucherRequest true "Voucher data"
+// @Success 200
+// @Router /api/admin/vouchers/{id} [put]
+func (h *Handler) UpdateVoucher(c *gin.Context) {
+	var req UpdateVoucherRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid request data",
+			"data":    err.Error(),
+		})
+		return
+	}
+
+	if err := h.service.UpdateVoucher(c.Request.Context(), c.Param("id"), &req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Voucher updated successfully",
+		"data":    nil,
+	})
+}
+
+// DeactivateVoucher godoc
+// @Summary Deactivate a voucher (admin only)
+// @Tags Vouchers
+// @Security BearerAuth
+// @Param id path string true "Voucher ID"
+// @Success 200
+// @Router /api/admin/vouchers/{id} [delete]
+func (h *Handler) DeactivateVoucher(c *gin.Context) {
+	if err := h.service.DeactivateVoucher(c.Request.Context(), c.Param("id")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Voucher deactivated successfully",
+		"data":    nil,
+	})
+}
+
+// GetRedemptions godoc
+// @Summary Get the uses of a voucher (admin only)
+// @Tags Vouchers
+// @Security BearerAuth
+// @Param id path string true "Voucher ID"
+// @Param page query int false "Page number"
+// @Param limit query int false "Limit per page"
+// @Success 200 {object} RedemptionsListResponse
+// @Router /api/admin/vouchers/{id}/redemptions [get]
+func (h *Handler) GetRedemptions(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	resp, err := h.service.GetRedemptions(c.Request.Context(), c.Param("id"), page, limit)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Redemptions retrieved successfully",
+		"data":    resp,
+	})
+}
+
+// GenerateCodes godoc
+// @Summary Generate single-use codes for a voucher (admin only)
+// @Description The voucher becomes a template: it can then only be redeemed through its generated codes.
+// @Tags Vouchers
+// @Security BearerAuth
+// @Param id path string true "Voucher ID"
+// @Param request body GenerateCodesRequest true "How many codes and their format"
+// @Success 201 {object} GenerateCodesResponse
+// @Router /api/admin/vouchers/{id}/codes [post]
+func (h *Handler) GenerateCodes(c *gin.Context) {
+	var req GenerateCodesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Invalid request data",
+			"data":    err.Error(),
+		})
+		return
+	}
+
+	resp, err := h.service.GenerateCodes(c.Request.Context(), c.Param("id"), &req)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, ErrCodeGenerationInProgress) {
+			status = http.StatusConflict
+		}
+		c.JSON(status, gin.H{
+			"success": false,
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Codes generated successfully",
+		"data":    resp,
+	})
+}
+
+// GetCodes godoc
+// @Summary Get the generated codes of a voucher (admin only)
+// @Tags Vouchers
+// @Security BearerAuth
+// @Param id path string true "Voucher ID"
+// @Param status query string false "AVAILABLE or REDEEMED"
+// @Param page query int false "Page number"
+// @Param limit query int false "Limit per page"
+// @Success 200 {object} VoucherCodesListResponse
+// @Router /api/admin/vouchers/{id}/codes [get]
+func (h *Handler) GetCodes(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	resp, err := h.service.GetCodes(c.Request.Context(), c.Param("id"), c.Query("status"), page, limit)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Codes retrieved successfully",
+		"data":    resp,
+	})
+}
+
+// ExportCodes godoc
+// @Summary Export the generated codes of a voucher as CSV (admin only)
+// @Tags Vouchers
+// @Security BearerAuth
+// @Produce text/csv
+// @Param id path string true "Voucher ID"
+// @Param status query string false "AVAILABLE or REDEEMED"
+// @Success 200 {file} file
+// @Router /api/admin/vouchers/{id}/codes/export [get]
+func (h *Handler) ExportCodes(c *gin.Context) {
+	voucher, err := h.service.GetVoucherByID(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": err.Error(),
+			"data":    nil,
+		})
+		return
+	}
+
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-codes.csv"`, voucher.Code))
+
+	// The CSV is streamed, so a failure halfway can only cut it short
+	if err := h.service.ExportCodes(c.Request.Context(), voucher.ID, c.Query("status"), c.Writer); err != nil {
+		if c.Writer.Written() {
+			_ = c.Error(err)
+			return
+		}
+		c.Header("Content-Type", "")
+		c.Header("Content-Disposition", "")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": err.Error(),
+			"data":    nil,
+		})
+	}
+}
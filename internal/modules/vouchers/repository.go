@@ -0,0 +1,209 @@
+package vouchers
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"phone-store-backend/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type Repository struct {
+	voucherCollection    *mongo.Collection
+	redemptionCollection *mongo.Collection
+	codeCollection       *mongo.Collection
+}
+
+func NewRepository(db *mongo.Database) *Repository {
+	return &Repository{
+		voucherCollection:    db.Collection("vouchers"),
+		redemptionCollection: db.Collection("voucher_redemptions"),
+		codeCollection:       db.Collection("voucher_codes"),
+	}
+}
+
+// FindVouchers returns vouchers matching filter with pagination
+func (r *Repository) FindVouchers(ctx context.Context, filter bson.M, opts *options.FindOptions) ([]*models.Voucher, error) {
+	cursor, err := r.voucherCollection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var vouchers []*models.Voucher
+	if err := cursor.All(ctx, &vouchers); err != nil {
+		return nil, err
+	}
+
+	return vouchers, nil
+}
+
+// CountVouchers counts vouchers matching filter
+func (r *Repository) CountVouchers(ctx context.Context, filter bson.M) (int64, error) {
+	return r.voucherCollection.CountDocuments(ctx, filter)
+}
+
+// FindVoucherByID finds a voucher by ID
+func (r *Repository) FindVoucherByID(ctx context.Context, id primitive.ObjectID) (*models.Voucher, error) {
+	var voucher models.Voucher
+	err := r.voucherCollection.FindOne(ctx, bson.M{"_id": id}).Decode(&voucher)
+	if err != nil {
+		return nil, err
+	}
+	return &voucher, nil
+}
+
+// CreateVoucher creates a new voucher
+func (r *Repository) CreateVoucher(ctx context.Context, voucher *models.Voucher) error {
+	result, err := r.voucherCollection.InsertOne(ctx, voucher)
+	if err != nil {
+		return err
+	}
+	voucher.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// UpdateVoucher updates a voucher
+func (r *Repository) UpdateVoucher(ctx context.Context, id primitive.ObjectID, update bson.M) error {
+	_, err := r.voucherCollection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": update})
+	return err
+}
+
+// ClaimCodeGeneration marks a voucher as having codes generated until the
+// given time, returning false while another run holds it
+func (r *Repository) ClaimCodeGeneration(ctx context.Context, id primitive.ObjectID, until time.Time) (bool, error) {
+	result, err := r.voucherCollection.UpdateOne(
+		ctx,
+		bson.M{"_id": id, "$or": bson.A{
+			bson.M{"generatingUntil": bson.M{"$exists": false}},
+			bson.M{"generatingUntil": bson.M{"$lt": time.Now()}},
+		}},
+		bson.M{"$set": bson.M{"generatingUntil": until}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount > 0, nil
+}
+
+// ReleaseCodeGeneration ends a claim made by ClaimCodeGeneration
+func (r *Repository) ReleaseCodeGeneration(ctx context.Context, id primitive.ObjectID) error {
+	_, err := r.voucherCollection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$unset": bson.M{"generatingUntil": ""}})
+	return err
+}
+
+// FindRedemptions returns redemptions matching filter with pagination
+func (r *Repository) FindRedemptions(ctx context.Context, filter bson.M, opts *options.FindOptions) ([]*models.VoucherRedemption, error) {
+	cursor, err := r.redemptionCollection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var redemptions []*models.VoucherRedemption
+	if err := cursor.All(ctx, &redemptions); err != nil {
+		return nil, err
+	}
+
+	return redemptions, nil
+}
+
+// CountRedemptions counts redemptions matching filter
+func (r *Repository) CountRedemptions(ctx context.Context, filter bson.M) (int64, error) {
+	return r.redemptionCollection.CountDocuments(ctx, filter)
+}
+
+// FindTakenVoucherCodes returns which of codes are already vouchers' own
+// codes
+func (r *Repository) FindTakenVoucherCodes(ctx context.Context, codes []string) (map[string]bool, error) {
+	values, err := r.voucherCollection.Distinct(ctx, "code", bson.M{"code": bson.M{"$in": codes}})
+	if err != nil {
+		return nil, err
+	}
+
+	taken := make(map[string]bool, len(values))
+	for _, value := range values {
+		if code, ok := value.(string); ok {
+			taken[code] = true
+		}
+	}
+	return taken, nil
+}
+
+// InsertVoucherCodes inserts generated codes, skipping the ones that
+// collide with existing codes. It returns how many were inserted.
+func (r *Repository) InsertVoucherCodes(ctx context.Context, codes []*models.VoucherCode) (int, error) {
+	docs := make([]interface{}, 0, len(codes))
+	for _, code := range codes {
+		docs = append(docs, code)
+	}
+
+	_, err := r.codeCollection.InsertMany(ctx, docs, options.InsertMany().SetOrdered(false))
+	if err == nil {
+		return len(docs), nil
+	}
+
+	var bulkErr mongo.BulkWriteException
+	if !errors.As(err, &bulkErr) || bulkErr.WriteConcernError != nil {
+		return 0, err
+	}
+	for _, writeErr := range bulkErr.WriteErrors {
+		if !mongo.IsDuplicateKeyError(writeErr) {
+			return 0, err
+		}
+	}
+	return len(docs) - len(bulkErr.WriteErrors), nil
+}
+
+// FindVoucherCodes returns generated codes matching filter with pagination
+func (r *Repository) FindVoucherCodes(ctx context.Context, filter bson.M, opts *options.FindOptions) ([]*models.VoucherCode, error) {
+	cursor, err := r.codeCollection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var codes []*models.VoucherCode
+	if err := cursor.All(ctx, &codes); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// CountVoucherCodes counts generated codes matching filter
+func (r *Repository) CountVoucherCodes(ctx context.Context, filter bson.M) (int64, error) {
+	return r.codeCollection.CountDocuments(ctx, filter)
+}
+
+// EachVoucherCode calls fn for every generated code matching filter, in
+// creation order, without loading them all at once
+func (r *Repository) EachVoucherCode(ctx context.Context, filter bson.M, fn func(*models.VoucherCode) error) error {
+	cursor, err := r.codeCollection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var code models.VoucherCode
+		if err := cursor.Decode(&code); err != nil {
+			return err
+		}
+		if err := fn(&code); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+// VoucherCodeExists reports whether code is taken by a generated code
+func (r *Repository) VoucherCodeExists(ctx context.Context, code string) (bool, error) {
+	count, err := r.codeCollection.CountDocuments(ctx, bson.M{"code": code})
+	return count > 0, err
+}
@@ -0,0 +1,422 @@
+package vouchers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+
+	"phone-store-backend/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrDuplicateCode is returned when a voucher code is already taken
+var ErrDuplicateCode = errors.New("voucher code already exists")
+
+// codePattern is what customers can reliably type: letters, digits, dash
+var codePattern = regexp.MustCompile(`^[A-Z0-9-]+$`)
+
+type Service struct {
+	repo *Repository
+}
+
+func NewService(repo *Repository) *Service {
+	return &Service{repo: repo}
+}
+
+// GetVouchers returns a paginated list of vouchers. q searches code and
+// description; isActive filters by state when set.
+func (s *Service) GetVouchers(ctx context.Context, q string, isActive *bool, page, limit int) (*VouchersListResponse, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 20
+	}
+
+	filter := bson.M{}
+	if q = strings.TrimSpace(q); q != "" {
+		pattern := primitive.Regex{Pattern: regexp.QuoteMeta(q), Options: "i"}
+		filter["$or"] = bson.A{
+			bson.M{"code": pattern},
+			bson.M{"description": pattern},
+		}
+	}
+	if isActive != nil {
+		filter["isActive"] = *isActive
+	}
+
+	opts := options.Find()
+	opts.SetSkip(int64((page - 1) * limit))
+	opts.SetLimit(int64(limit))
+	opts.SetSort(bson.D{{Key: "createdAt", Value: -1}})
+
+	vouchers, err := s.repo.FindVouchers(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	total, err := s.repo.CountVouchers(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	voucherResponses := []VoucherResponse{}
+	for _, voucher := range vouchers {
+		voucherResponses = append(voucherResponses, *transformVoucher(voucher))
+	}
+
+	totalPages := int(math.Ceil(float64(total) / float64(limit)))
+
+	return &VouchersListResponse{
+		Data:       voucherResponses,
+		Page:       page,
+		Limit:      limit,
+		Total:      total,
+		TotalPages: totalPages,
+	}, nil
+}
+
+// GetVoucherByID returns a voucher
+func (s *Service) GetVoucherByID(ctx context.Context, id string) (*VoucherResponse, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errors.New("invalid voucher ID")
+	}
+
+	voucher, err := s.repo.FindVoucherByID(ctx, objectID)
+	if err != nil {
+		return nil, errors.New("voucher not found")
+	}
+
+	return transformVoucher(voucher), nil
+}
+
+// CreateVoucher creates a new voucher. Codes are stored upper-case.
+func (s *Service) CreateVoucher(ctx context.Context, req *CreateVoucherRequest) (*VoucherResponse, error) {
+	code := strings.ToUpper(strings.TrimSpace(req.Code))
+	if !codePattern.MatchString(code) {
+		return nil, errors.New("voucher code may only contain letters, digits and dashes")
+	}
+	if !req.ExpiredAt.After(time.Now()) {
+		return nil, errors.New("expiry date must be in the future")
+	}
+	if req.StartsAt != nil && !req.StartsAt.Before(req.ExpiredAt) {
+		return nil, errors.New("start date must be before the expiry date")
+	}
+
+	discountType := models.DiscountType(req.DiscountType)
+	if discountType == "" {
+		discountType = models.DiscountTypePercent
+	}
+	if err := validateDiscount(discountType, req.DiscountPercent, req.DiscountAmount); err != nil {
+		return nil, err
+	}
+
+	productIDs, err := parseIDs(req.ProductIDs)
+	if err != nil {
+		return nil, err
+	}
+	variantIDs, err := parseIDs(req.VariantIDs)
+	if err != nil {
+		return nil, err
+	}
+	brandIDs, err := parseIDs(req.BrandIDs)
+	if err != nil {
+		return nil, err
+	}
+	categoryIDs, err := parseIDs(req.CategoryIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	isActive := true
+	if req.IsActive != nil {
+		isActive = *req.IsActive
+	}
+
+	voucher := &models.Voucher{
+		Code:            code,
+		Description:     req.Description,
+		DiscountType:    discountType,
+		DiscountPercent: req.DiscountPercent,
+		DiscountAmount:  req.DiscountAmount,
+		MaxDiscount:     req.MaxDiscount,
+		ProductIDs:      productIDs,
+		VariantIDs:      variantIDs,
+		BrandIDs:        brandIDs,
+		CategoryIDs:     categoryIDs,
+		MinOrderValue:   req.MinOrderValue,
+		StartsAt:        req.StartsAt,
+		ExpiredAt:       req.ExpiredAt,
+		UsageLimit:      req.UsageLimit,
+		PerUserLimit:    req.PerUserLimit,
+		FirstOrderOnly:  req.FirstOrderOnly,
+		IsActive:        isActive,
+		CreatedAt:       time.Now(),
+		UpdatedAt:       time.Now(),
+	}
+
+	// Generated codes share the namespace customers type codes into
+	taken, err := s.repo.VoucherCodeExists(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	if taken {
+		return nil, ErrDuplicateCode
+	}
+
+	if err := s.repo.CreateVoucher(ctx, voucher); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, ErrDuplicateCode
+		}
+		return nil, err
+	}
+
+	return transformVoucher(voucher), nil
+}
+
+// UpdateVoucher updates a voucher
+func (s *Service) UpdateVoucher(ctx context.Context, id string, req *UpdateVoucherRequest) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return errors.New("invalid voucher ID")
+	}
+
+	voucher, err := s.repo.FindVoucherByID(ctx, objectID)
+	if err != nil {
+		return errors.New("voucher not found")
+	}
+
+	update := bson.M{
+		"updatedAt": time.Now(),
+	}
+
+	if req.Description != nil {
+		update["description"] = *req.Description
+	}
+	// The discount fields are validated together against the resulting type
+	discountType := voucher.DiscountType
+	if discountType == "" {
+		discountType = models.DiscountTypePercent
+	}
+	if req.DiscountType != nil {
+		discountType = models.DiscountType(*req.DiscountType)
+		update["discountType"] = discountType
+	}
+	if req.DiscountPercent != nil {
+		voucher.DiscountPercent = *req.DiscountPercent
+		update["discountPercent"] = *req.DiscountPercent
+	}
+	if req.DiscountAmount != nil {
+		voucher.DiscountAmount = *req.DiscountAmount
+		update["discountAmount"] = *req.DiscountAmount
+	}
+	if err := validateDiscount(discountType, voucher.DiscountPercent, voucher.DiscountAmount); err != nil {
+		return err
+	}
+
+	scopeFields := []struct {
+		field string
+		ids   *[]string
+	}{
+		{"productIds", req.ProductIDs},
+		{"variantIds", req.VariantIDs},
+		{"brandIds", req.BrandIDs},
+		{"categoryIds", req.CategoryIDs},
+	}
+	for _, f := range scopeFields {
+		if f.ids == nil {
+			continue
+		}
+		ids, err := parseIDs(*f.ids)
+		if err != nil {
+			return err
+		}
+		update[f.field] = ids
+	}
+
+	if req.MaxDiscount != nil {
+		update["maxDiscount"] = *req.MaxDiscount
+	}
+	if req.MinOrderValue != nil {
+		update["minOrderValue"] = *req.MinOrderValue
+	}
+	// The dates are merged with the stored ones before they are checked, so
+	// changing only one of them cannot put the expiry before the start
+	if req.ExpiredAt != nil {
+		if !req.ExpiredAt.After(time.Now()) {
+			return errors.New("expiry date must be in the future")
+		}
+		update["expiredAt"] = *req.ExpiredAt
+		voucher.ExpiredAt = *req.ExpiredAt
+	}
+	if req.StartsAt != nil {
+		update["startsAt"] = *req.StartsAt
+		voucher.StartsAt = req.StartsAt
+	}
+	if voucher.StartsAt != nil && !voucher.StartsAt.Before(voucher.ExpiredAt) {
+		return errors.New("start date must be before the expiry date")
+	}
+	if req.UsageLimit != nil {
+		update["usageLimit"] = *req.UsageLimit
+	}
+	if req.PerUserLimit != nil {
+		update["perUserLimit"] = *req.PerUserLimit
+	}
+	if req.FirstOrderOnly != nil {
+		update["firstOrderOnly"] = *req.FirstOrderOnly
+	}
+	if req.IsActive != nil {
+		update["isActive"] = *req.IsActive
+	}
+
+	return s.repo.UpdateVoucher(ctx, objectID, update)
+}
+
+// DeactivateVoucher soft deletes a voucher. Orders keep referencing its code.
+func (s *Service) DeactivateVoucher(ctx context.Context, id string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return errors.New("invalid voucher ID")
+	}
+
+	if _, err := s.repo.FindVoucherByID(ctx, objectID); err != nil {
+		return errors.New("voucher not found")
+	}
+
+	return s.repo.UpdateVoucher(ctx, objectID, bson.M{
+		"isActive":  false,
+		"updatedAt": time.Now(),
+	})
+}
+
+// GetRedemptions returns the uses of a voucher, newest first
+func (s *Service) GetRedemptions(ctx context.Context, id string, page, limit int) (*RedemptionsListResponse, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errors.New("invalid voucher ID")
+	}
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 20
+	}
+
+	filter := bson.M{"voucherId": objectID}
+
+	opts := options.Find()
+	opts.SetSkip(int64((page - 1) * limit))
+	opts.SetLimit(int64(limit))
+	opts.SetSort(bson.D{{Key: "createdAt", Value: -1}})
+
+	redemptions, err := s.repo.FindRedemptions(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	total, err := s.repo.CountRedemptions(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	redemptionResponses := []RedemptionResponse{}
+	for _, redemption := range redemptions {
+		redemptionResponses = append(redemptionResponses, RedemptionResponse{
+			ID:         redemption.ID.Hex(),
+			UserID:     redemption.UserID.Hex(),
+			OrderID:    redemption.OrderID.Hex(),
+			Discount:   redemption.Discount,
+			Status:     string(redemption.Status),
+			ReleasedAt: redemption.ReleasedAt,
+			CreatedAt:  redemption.CreatedAt,
+		})
+	}
+
+	totalPages := int(math.Ceil(float64(total) / float64(limit)))
+
+	return &RedemptionsListResponse{
+		Data:       redemptionResponses,
+		Page:       page,
+		Limit:      limit,
+		Total:      total,
+		TotalPages: totalPages,
+	}, nil
+}
+
+func validateDiscount(discountType models.DiscountType, percent, amount float64) error {
+	switch discountType {
+	case models.DiscountTypePercent:
+		if percent <= 0 || percent > 100 {
+			return errors.New("discount percent must be between 0 and 100")
+		}
+	case models.DiscountTypeFixed:
+		if amount <= 0 {
+			return errors.New("discount amount must be greater than 0")
+		}
+	case models.DiscountTypeFreeShipping:
+	default:
+		return errors.New("invalid discount type")
+	}
+	return nil
+}
+
+func parseIDs(hexes []string) ([]primitive.ObjectID, error) {
+	ids := make([]primitive.ObjectID, 0, len(hexes))
+	for _, hex := range hexes {
+		id, err := primitive.ObjectIDFromHex(hex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ID in voucher scope: %s", hex)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func hexIDs(ids []primitive.ObjectID) []string {
+	hexes := make([]string, 0, len(ids))
+	for _, id := range ids {
+		hexes = append(hexes, id.Hex())
+	}
+	return hexes
+}
+
+func transformVoucher(voucher *models.Voucher) *VoucherResponse {
+	discountType := voucher.DiscountType
+	if discountType == "" {
+		discountType = models.DiscountTypePercent
+	}
+
+	return &VoucherResponse{
+		ID:              voucher.ID.Hex(),
+		Code:            voucher.Code,
+		Description:     voucher.Description,
+		DiscountType:    string(discountType),
+		DiscountPercent: voucher.DiscountPercent,
+		DiscountAmount:  voucher.DiscountAmount,
+		MaxDiscount:     voucher.MaxDiscount,
+		ProductIDs:      hexIDs(voucher.ProductIDs),
+		VariantIDs:      hexIDs(voucher.VariantIDs),
+		BrandIDs:        hexIDs(voucher.BrandIDs),
+		CategoryIDs:     hexIDs(voucher.CategoryIDs),
+		MinOrderValue:   voucher.MinOrderValue,
+		StartsAt:        voucher.StartsAt,
+		ExpiredAt:       voucher.ExpiredAt,
+		UsageLimit:      voucher.UsageLimit,
+		PerUserLimit:    voucher.PerUserLimit,
+		FirstOrderOnly:  voucher.FirstOrderOnly,
+		CodesOnly:       voucher.CodesOnly,
+		IsActive:        voucher.IsActive,
+		UsageCount:      voucher.UsedCount,
+		CreatedAt:       voucher.CreatedAt,
+		UpdatedAt:       voucher.UpdatedAt,
+	}
+}
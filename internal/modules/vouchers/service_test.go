@@ -0,0 +1,106 @@
+package vouchers
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"phone-store-backend/internal/testdb"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestCreateVoucherConcurrentSameCodeCreatesOne(t *testing.T) {
+	const admins = 10
+	database := testdb.Open(t)
+	service := NewService(NewRepository(database))
+	ctx := context.Background()
+
+	var (
+		wg         sync.WaitGroup
+		mu         sync.Mutex
+		created    int
+		unexpected []error
+	)
+	start := make(chan struct{})
+	for i := 0; i < admins; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			_, err := service.CreateVoucher(ctx, &CreateVoucherRequest{
+				Code:           "TET2025",
+				DiscountType:   "FIXED",
+				DiscountAmount: 100000,
+				ExpiredAt:      time.Now().AddDate(0, 1, 0),
+			})
+
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				created++
+			case !errors.Is(err, ErrDuplicateCode):
+				unexpected = append(unexpected, err)
+			}
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	for _, err := range unexpected {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if created != 1 {
+		t.Errorf("created %d vouchers with the same code, want 1", created)
+	}
+}
+
+func TestGenerateCodesConcurrentRunsStayWithinUsageLimit(t *testing.T) {
+	const limit, runs, perRun = 100, 5, 60
+	database := testdb.Open(t)
+	service := NewService(NewRepository(database))
+	ctx := context.Background()
+
+	voucher, err := service.CreateVoucher(ctx, &CreateVoucherRequest{
+		Code:           "CAMPAIGN",
+		DiscountType:   "FIXED",
+		DiscountAmount: 100000,
+		ExpiredAt:      time.Now().AddDate(0, 1, 0),
+		UsageLimit:     limit,
+	})
+	if err != nil {
+		t.Fatalf("create voucher: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	for i := 0; i < runs; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			service.GenerateCodes(ctx, voucher.ID, &GenerateCodesRequest{Count: perRun})
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	codes, err := database.Collection("voucher_codes").CountDocuments(ctx, bson.M{})
+	if err != nil {
+		t.Fatalf("count codes: %v", err)
+	}
+	if codes > limit {
+		t.Errorf("%d codes generated for a usage limit of %d", codes, limit)
+	}
+	if codes != perRun {
+		t.Errorf("%d codes generated, want one run of %d", codes, perRun)
+	}
+
+	// The claim is released once a run finishes
+	if _, err := service.GenerateCodes(ctx, voucher.ID, &GenerateCodesRequest{Count: limit - perRun}); err != nil {
+		t.Errorf("generate the rest of the limit: %v", err)
+	}
+}
@@ -0,0 +1,170 @@
+// Package pricing computes order totals and allocates discounts to lines.
+// The cart preview and checkout both price through it so they always agree.
+package pricing
+
+import (
+	"math"
+
+	"phone-store-backend/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Line is one priced cart or order line
+type Line struct {
+	VariantID  primitive.ObjectID
+	ProductID  primitive.ObjectID
+	BrandID    primitive.ObjectID
+	CategoryID primitive.ObjectID
+	UnitPrice  float64
+	Quantity   int
+	Discount   float64 // Share of item discounts for the whole line
+}
+
+// Subtotal is the line price before discounts
+func (l *Line) Subtotal() float64 {
+	return l.UnitPrice * float64(l.Quantity)
+}
+
+// Quote is the price breakdown of a cart
+type Quote struct {
+	Lines            []*Line
+	SubTotal         float64
+	ShippingFee      float64
+	ItemDiscount     float64
+	ShippingDiscount float64
+	Promotions       []models.AppliedPromotion
+
+	// Set when an applied promotion cannot be combined with vouchers
+	voucherBlocked bool
+}
+
+// NewQuote prices lines with the given shipping fee and no discounts
+func NewQuote(lines []*Line, shippingFee float64) *Quote {
+	q := &Quote{Lines: lines, ShippingFee: shippingFee}
+	for _, line := range lines {
+		q.SubTotal += line.Subtotal()
+	}
+	return q
+}
+
+// Discount is the total taken off items and shipping
+func (q *Quote) Discount() float64 {
+	return q.ItemDiscount + q.ShippingDiscount
+}
+
+// Total is what the customer pays
+func (q *Quote) Total() float64 {
+	return q.SubTotal + q.ShippingFee - q.Discount()
+}
+
+// ApplyVoucher takes the voucher discount off the quote and allocates item
+// discounts to the eligible lines. It returns the discount applied; zero
+// when nothing in the cart is eligible.
+func ApplyVoucher(q *Quote, v *models.Voucher) float64 {
+	if v.DiscountType == models.DiscountTypeFreeShipping {
+		discount := capped(q.ShippingFee-q.ShippingDiscount, v.MaxDiscount)
+		q.ShippingDiscount += discount
+		return discount
+	}
+
+	var eligible []*Line
+	for _, line := range q.Lines {
+		if InScope(v, line) {
+			eligible = append(eligible, line)
+		}
+	}
+	return applyDiscount(q, eligible, v.DiscountType, v.DiscountPercent, v.DiscountAmount, v.MaxDiscount)
+}
+
+// InScope reports whether a line is covered by the voucher's scope
+func InScope(v *models.Voucher, line *Line) bool {
+	if len(v.ProductIDs) == 0 && len(v.VariantIDs) == 0 && len(v.BrandIDs) == 0 && len(v.CategoryIDs) == 0 {
+		return true
+	}
+	return contains(v.VariantIDs, line.VariantID) ||
+		contains(v.ProductIDs, line.ProductID) ||
+		contains(v.BrandIDs, line.BrandID) ||
+		contains(v.CategoryIDs, line.CategoryID)
+}
+
+// matchesScope reports whether a line matches any of the ID lists. Empty
+// lists everywhere match every line.
+func matchesScope(line *Line, productIDs, brandIDs, categoryIDs []primitive.ObjectID) bool {
+	if len(productIDs) == 0 && len(brandIDs) == 0 && len(categoryIDs) == 0 {
+		return true
+	}
+	return contains(productIDs, line.ProductID) ||
+		contains(brandIDs, line.BrandID) ||
+		contains(categoryIDs, line.CategoryID)
+}
+
+// applyDiscount takes a percent or fixed discount off what is left to pay on
+// the eligible lines and allocates it to them. It returns the discount
+// applied.
+func applyDiscount(q *Quote, eligible []*Line, discountType models.DiscountType, percent, amount, maxDiscount float64) float64 {
+	var base float64
+	for _, line := range eligible {
+		base += line.Subtotal() - line.Discount
+	}
+	if base <= 0 {
+		return 0
+	}
+
+	var discount float64
+	switch discountType {
+	case models.DiscountTypeFixed:
+		discount = amount
+	default:
+		discount = base * percent / 100
+	}
+	discount = math.Min(capped(discount, maxDiscount), base)
+
+	discount = allocate(eligible, discount)
+	q.ItemDiscount += discount
+	return discount
+}
+
+// allocate spreads a discount over lines in proportion to what is left to
+// pay on each, in whole VND. Each line takes its share of what the lines
+// before it left over, so rounding never pushes a share below zero or past
+// the line and the shares always add up. It returns the amount actually
+// allocated.
+func allocate(lines []*Line, discount float64) float64 {
+	discount = math.Round(discount)
+
+	var base float64
+	for _, line := range lines {
+		base += line.Subtotal() - line.Discount
+	}
+
+	remaining := discount
+	for _, line := range lines {
+		room := line.Subtotal() - line.Discount
+		if room <= 0 {
+			continue
+		}
+		share := math.Min(math.Round(remaining*room/base), room)
+		line.Discount += share
+		remaining -= share
+		base -= room
+	}
+	return discount - remaining
+}
+
+// capped limits amount to max; a max of zero means no cap
+func capped(amount, max float64) float64 {
+	if max > 0 && amount > max {
+		return max
+	}
+	return math.Max(amount, 0)
+}
+
+func contains(ids []primitive.ObjectID, id primitive.ObjectID) bool {
+	for _, candidate := range ids {
+		if candidate == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,187 @@
+package pricing
+
+import (
+	"math"
+	"testing"
+
+	"phone-store-backend/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// newLines builds one line per unit price, each of quantity 1 and all under
+// the same brand
+func newLines(brandID primitive.ObjectID, prices ...float64) []*Line {
+	lines := make([]*Line, 0, len(prices))
+	for _, price := range prices {
+		lines = append(lines, &Line{
+			VariantID: primitive.NewObjectID(),
+			ProductID: primitive.NewObjectID(),
+			BrandID:   brandID,
+			UnitPrice: price,
+			Quantity:  1,
+		})
+	}
+	return lines
+}
+
+// checkLineDiscounts fails unless every line discount is a whole amount
+// between zero and the line subtotal, and they add up to the quote's item
+// discount
+func checkLineDiscounts(t *testing.T, q *Quote) {
+	t.Helper()
+	var sum float64
+	for i, line := range q.Lines {
+		if line.Discount < 0 || line.Discount > line.Subtotal() || line.Discount != math.Round(line.Discount) {
+			t.Errorf("line %d (%.0f) got discount %v", i, line.Subtotal(), line.Discount)
+		}
+		sum += line.Discount
+	}
+	if sum != q.ItemDiscount {
+		t.Errorf("line discounts add up to %v, want the item discount %v", sum, q.ItemDiscount)
+	}
+}
+
+func TestApplyVoucherAllocatesToLines(t *testing.T) {
+	brand, otherBrand := primitive.NewObjectID(), primitive.NewObjectID()
+
+	tests := []struct {
+		name    string
+		lines   []*Line
+		voucher models.Voucher
+		// want is the discount applied; lineWant, when set, is each line's share
+		want     float64
+		lineWant []float64
+	}{
+		{
+			name:     "percent over uneven lines",
+			lines:    newLines(brand, 333333, 333334, 333333),
+			voucher:  models.Voucher{DiscountType: models.DiscountTypePercent, DiscountPercent: 10},
+			want:     100000,
+			lineWant: []float64{33333, 33334, 33333},
+		},
+		{
+			name:     "fixed amount that does not split evenly",
+			lines:    newLines(brand, 1000000, 1000000, 1000000),
+			voucher:  models.Voucher{DiscountType: models.DiscountTypeFixed, DiscountAmount: 100001},
+			want:     100001,
+			lineWant: []float64{33334, 33334, 33333},
+		},
+		{
+			name:     "zero-price last line",
+			lines:    newLines(brand, 100001, 100001, 0),
+			voucher:  models.Voucher{DiscountType: models.DiscountTypeFixed, DiscountAmount: 100001},
+			want:     100001,
+			lineWant: []float64{50001, 50000, 0},
+		},
+		{
+			name:     "zero-price first line",
+			lines:    newLines(brand, 0, 2000000, 1000000),
+			voucher:  models.Voucher{DiscountType: models.DiscountTypePercent, DiscountPercent: 15},
+			want:     450000,
+			lineWant: []float64{0, 300000, 150000},
+		},
+		{
+			name:    "only zero-price lines",
+			lines:   newLines(brand, 0, 0),
+			voucher: models.Voucher{DiscountType: models.DiscountTypeFixed, DiscountAmount: 50000},
+			want:    0,
+		},
+		{
+			name:     "fixed amount above the cart",
+			lines:    newLines(brand, 300000, 200000),
+			voucher:  models.Voucher{DiscountType: models.DiscountTypeFixed, DiscountAmount: 1000000},
+			want:     500000,
+			lineWant: []float64{300000, 200000},
+		},
+		{
+			name:     "percent capped",
+			lines:    newLines(brand, 7000000, 3000000),
+			voucher:  models.Voucher{DiscountType: models.DiscountTypePercent, DiscountPercent: 20, MaxDiscount: 500000},
+			want:     500000,
+			lineWant: []float64{350000, 150000},
+		},
+		{
+			name:     "scoped to a brand",
+			lines:    append(newLines(brand, 1000000), newLines(otherBrand, 2000000)...),
+			voucher:  models.Voucher{DiscountType: models.DiscountTypePercent, DiscountPercent: 10, BrandIDs: []primitive.ObjectID{brand}},
+			want:     100000,
+			lineWant: []float64{100000, 0},
+		},
+		{
+			name:    "scope matching nothing",
+			lines:   newLines(otherBrand, 1000000),
+			voucher: models.Voucher{DiscountType: models.DiscountTypeFixed, DiscountAmount: 100000, BrandIDs: []primitive.ObjectID{brand}},
+			want:    0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := NewQuote(tt.lines, 30000)
+			got := ApplyVoucher(q, &tt.voucher)
+			if got != tt.want || q.ItemDiscount != tt.want {
+				t.Errorf("applied %v with item discount %v, want %v", got, q.ItemDiscount, tt.want)
+			}
+			if q.ShippingDiscount != 0 {
+				t.Errorf("took %v off shipping", q.ShippingDiscount)
+			}
+			checkLineDiscounts(t, q)
+			for i, want := range tt.lineWant {
+				if q.Lines[i].Discount != want {
+					t.Errorf("line %d got %v, want %v", i, q.Lines[i].Discount, want)
+				}
+			}
+		})
+	}
+}
+
+func TestApplyVoucherFreeShipping(t *testing.T) {
+	tests := []struct {
+		name        string
+		maxDiscount float64
+		want        float64
+	}{
+		{"uncapped", 0, 30000},
+		{"capped", 20000, 20000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := NewQuote(newLines(primitive.NewObjectID(), 1000000), 30000)
+			got := ApplyVoucher(q, &models.Voucher{DiscountType: models.DiscountTypeFreeShipping, MaxDiscount: tt.maxDiscount})
+			if got != tt.want || q.ShippingDiscount != tt.want || q.ItemDiscount != 0 {
+				t.Errorf("applied %v (shipping %v, items %v), want %v off shipping", got, q.ShippingDiscount, q.ItemDiscount, tt.want)
+			}
+			if q.Total() != 1030000-tt.want {
+				t.Errorf("total %v, want %v", q.Total(), 1030000-tt.want)
+			}
+		})
+	}
+}
+
+// Shares that do not divide evenly must still add up exactly, whatever the
+// number of lines
+func TestAllocateAddsUpExactly(t *testing.T) {
+	for n := 1; n <= 12; n++ {
+		for _, discount := range []float64{1, 7, 99999, 123457} {
+			prices := make([]float64, n)
+			for i := range prices {
+				prices[i] = float64(100000*(i+1) + 1)
+			}
+			if n > 2 {
+				prices[n/2] = 0
+			}
+			lines := newLines(primitive.NewObjectID(), prices...)
+			q := NewQuote(lines, 0)
+
+			discount = math.Min(discount, q.SubTotal)
+			got := allocate(lines, discount)
+			q.ItemDiscount = got
+			if got != discount {
+				t.Errorf("%d lines: allocated %v of %v", n, got, discount)
+			}
+			checkLineDiscounts(t, q)
+		}
+	}
+}
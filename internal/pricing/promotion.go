@@ -0,0 +1,101 @@
+package pricing
+
+import (
+	"time"
+
+	"phone-store-backend/internal/models"
+)
+
+// PromotionRunning reports whether a promotion is live at time now
+func PromotionRunning(p *models.Promotion, now time.Time) bool {
+	return p.IsActive && !now.Before(p.StartsAt) && now.Before(p.EndsAt)
+}
+
+// ApplyPromotions applies the running promotions whose conditions the quote
+// meets, in the order given (highest priority first), and records them on
+// the quote. An exclusive promotion stops the ones after it.
+func ApplyPromotions(q *Quote, promotions []*models.Promotion, now time.Time) {
+	for _, p := range promotions {
+		if !PromotionRunning(p, now) {
+			continue
+		}
+
+		discount := applyPromotion(q, p)
+		if discount <= 0 {
+			continue
+		}
+
+		q.Promotions = append(q.Promotions, models.AppliedPromotion{
+			PromotionID: p.ID,
+			Name:        p.Name,
+			Discount:    discount,
+		})
+		if !p.CombinableWithVoucher {
+			q.voucherBlocked = true
+		}
+		if p.Exclusive {
+			return
+		}
+	}
+}
+
+// applyPromotion applies one promotion if the quote meets its conditions and
+// returns the discount applied
+func applyPromotion(q *Quote, p *models.Promotion) float64 {
+	var eligible []*Line
+	var quantity int
+	var subTotal float64
+	for _, line := range q.Lines {
+		if matchesScope(line, p.ProductIDs, p.BrandIDs, p.CategoryIDs) {
+			eligible = append(eligible, line)
+			quantity += line.Quantity
+			subTotal += line.Subtotal()
+		}
+	}
+	if len(eligible) == 0 || quantity < p.MinQuantity || subTotal < p.MinSubTotal {
+		return 0
+	}
+
+	for _, id := range p.RequiredProductIDs {
+		if !hasLine(q.Lines, func(line *Line) bool { return line.ProductID == id }) {
+			return 0
+		}
+	}
+	for _, id := range p.RequiredCategoryIDs {
+		if !hasLine(q.Lines, func(line *Line) bool { return line.CategoryID == id }) {
+			return 0
+		}
+	}
+
+	percent, amount := p.DiscountPercent, p.DiscountAmount
+	if len(p.Tiers) > 0 {
+		tier := highestTier(p.Tiers, subTotal)
+		if tier == nil {
+			return 0
+		}
+		percent, amount = tier.DiscountPercent, tier.DiscountAmount
+	}
+
+	return applyDiscount(q, eligible, p.DiscountType, percent, amount, p.MaxDiscount)
+}
+
+// highestTier returns the highest tier reached by subTotal, or nil
+func highestTier(tiers []models.PromotionTier, subTotal float64) *models.PromotionTier {
+	var best *models.PromotionTier
+	for i := range tiers {
+		tier := &tiers[i]
+		if subTotal >= tier.MinSubTotal && (best == nil || tier.MinSubTotal > best.MinSubTotal) {
+			best = tier
+		}
+	}
+	return best
+}
+
+func hasLine(lines []*Line, match func(*Line) bool) bool {
+	for _, line := range lines {
+		if match(line) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,183 @@
+package pricing
+
+import (
+	"testing"
+	"time"
+
+	"phone-store-backend/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestApplyPromotions(t *testing.T) {
+	now := time.Now()
+	brand, otherBrand := primitive.NewObjectID(), primitive.NewObjectID()
+	// running makes p live at now; only exclusive promotions block vouchers
+	running := func(p models.Promotion) *models.Promotion {
+		p.ID = primitive.NewObjectID()
+		p.IsActive = true
+		p.StartsAt = now.Add(-time.Hour)
+		p.EndsAt = now.Add(time.Hour)
+		p.CombinableWithVoucher = !p.Exclusive
+		return &p
+	}
+	percent := func(name string, pct float64) models.Promotion {
+		return models.Promotion{Name: name, DiscountType: models.DiscountTypePercent, DiscountPercent: pct}
+	}
+	fixed := func(name string, amount float64) models.Promotion {
+		return models.Promotion{Name: name, DiscountType: models.DiscountTypeFixed, DiscountAmount: amount}
+	}
+
+	tests := []struct {
+		name       string
+		lines      []*Line
+		promotions []*models.Promotion
+		// want is the discount of each applied promotion, by name
+		want           map[string]float64
+		voucherBlocked bool
+	}{
+		{
+			name:       "stacked on what is left",
+			lines:      newLines(brand, 6000000, 4000000),
+			promotions: []*models.Promotion{running(percent("10%", 10)), running(fixed("100k", 100000))},
+			want:       map[string]float64{"10%": 1000000, "100k": 100000},
+		},
+		{
+			name:       "higher priority first",
+			lines:      newLines(brand, 6000000, 4000000),
+			promotions: []*models.Promotion{running(fixed("100k", 100000)), running(percent("10%", 10))},
+			want:       map[string]float64{"100k": 100000, "10%": 990000},
+		},
+		{
+			name:  "exclusive stops lower priorities",
+			lines: newLines(brand, 10000000),
+			promotions: []*models.Promotion{
+				running(models.Promotion{Name: "flash", DiscountType: models.DiscountTypePercent, DiscountPercent: 5, Exclusive: true}),
+				running(fixed("100k", 100000)),
+			},
+			want:           map[string]float64{"flash": 500000},
+			voucherBlocked: true,
+		},
+		{
+			name:  "exclusive that does not apply stops nothing",
+			lines: newLines(brand, 1000000),
+			promotions: []*models.Promotion{
+				running(models.Promotion{Name: "big spender", DiscountType: models.DiscountTypePercent, DiscountPercent: 5, Exclusive: true, MinSubTotal: 5000000}),
+				running(fixed("100k", 100000)),
+			},
+			want: map[string]float64{"100k": 100000},
+		},
+		{
+			name:       "below the minimum subtotal",
+			lines:      newLines(brand, 2000000, 2999999),
+			promotions: []*models.Promotion{running(models.Promotion{Name: "5M", DiscountType: models.DiscountTypeFixed, DiscountAmount: 200000, MinSubTotal: 5000000})},
+			want:       map[string]float64{},
+		},
+		{
+			name:       "at the minimum subtotal",
+			lines:      newLines(brand, 2000000, 3000000),
+			promotions: []*models.Promotion{running(models.Promotion{Name: "5M", DiscountType: models.DiscountTypeFixed, DiscountAmount: 200000, MinSubTotal: 5000000})},
+			want:       map[string]float64{"5M": 200000},
+		},
+		{
+			name:  "minimum counts eligible lines only",
+			lines: append(newLines(brand, 3000000), newLines(otherBrand, 3000000)...),
+			promotions: []*models.Promotion{running(models.Promotion{
+				Name: "brand 5M", DiscountType: models.DiscountTypeFixed, DiscountAmount: 200000, MinSubTotal: 5000000, BrandIDs: []primitive.ObjectID{brand},
+			})},
+			want: map[string]float64{},
+		},
+		{
+			name:       "minimum quantity",
+			lines:      newLines(brand, 1000000, 1000000),
+			promotions: []*models.Promotion{running(models.Promotion{Name: "buy 3", DiscountType: models.DiscountTypePercent, DiscountPercent: 10, MinQuantity: 3})},
+			want:       map[string]float64{},
+		},
+		{
+			name:       "percentage capped",
+			lines:      newLines(brand, 20000000, 10000000),
+			promotions: []*models.Promotion{running(models.Promotion{Name: "10% up to 1M", DiscountType: models.DiscountTypePercent, DiscountPercent: 10, MaxDiscount: 1000000})},
+			want:       map[string]float64{"10% up to 1M": 1000000},
+		},
+		{
+			name:       "percentage under its cap",
+			lines:      newLines(brand, 5000000),
+			promotions: []*models.Promotion{running(models.Promotion{Name: "10% up to 1M", DiscountType: models.DiscountTypePercent, DiscountPercent: 10, MaxDiscount: 1000000})},
+			want:       map[string]float64{"10% up to 1M": 500000},
+		},
+		{
+			name:  "highest tier reached",
+			lines: newLines(brand, 12000000),
+			promotions: []*models.Promotion{running(models.Promotion{Name: "tiers", DiscountType: models.DiscountTypePercent, Tiers: []models.PromotionTier{
+				{MinSubTotal: 5000000, DiscountPercent: 3},
+				{MinSubTotal: 20000000, DiscountPercent: 10},
+				{MinSubTotal: 10000000, DiscountPercent: 5},
+			}})},
+			want: map[string]float64{"tiers": 600000},
+		},
+		{
+			name:       "nothing left after an earlier promotion",
+			lines:      newLines(brand, 300000),
+			promotions: []*models.Promotion{running(fixed("500k", 500000)), running(fixed("100k", 100000))},
+			want:       map[string]float64{"500k": 300000},
+		},
+		{
+			name:  "not running",
+			lines: newLines(brand, 1000000),
+			promotions: []*models.Promotion{
+				{Name: "inactive", DiscountType: models.DiscountTypeFixed, DiscountAmount: 1, StartsAt: now.Add(-time.Hour), EndsAt: now.Add(time.Hour)},
+				{Name: "upcoming", DiscountType: models.DiscountTypeFixed, DiscountAmount: 1, IsActive: true, StartsAt: now.Add(time.Hour), EndsAt: now.Add(2 * time.Hour)},
+				{Name: "ended", DiscountType: models.DiscountTypeFixed, DiscountAmount: 1, IsActive: true, StartsAt: now.Add(-2 * time.Hour), EndsAt: now},
+			},
+			want: map[string]float64{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := NewQuote(tt.lines, 0)
+			ApplyPromotions(q, tt.promotions, now)
+
+			var total float64
+			got := map[string]float64{}
+			for _, applied := range q.Promotions {
+				got[applied.Name] = applied.Discount
+				total += applied.Discount
+			}
+			if len(got) != len(tt.want) {
+				t.Errorf("applied %v, want %v", got, tt.want)
+			}
+			for name, want := range tt.want {
+				if got[name] != want {
+					t.Errorf("%s took %v, want %v", name, got[name], want)
+				}
+			}
+			if q.ItemDiscount != total {
+				t.Errorf("item discount %v, want the promotions' %v", q.ItemDiscount, total)
+			}
+			if q.voucherBlocked != tt.voucherBlocked {
+				t.Errorf("voucher blocked %v, want %v", q.voucherBlocked, tt.voucherBlocked)
+			}
+			checkLineDiscounts(t, q)
+		})
+	}
+}
+
+// A voucher runs after the promotions, on what they left to pay
+func TestApplyVoucherAfterPromotions(t *testing.T) {
+	now := time.Now()
+	q := NewQuote(newLines(primitive.NewObjectID(), 7000000, 3000000), 30000)
+	ApplyPromotions(q, []*models.Promotion{{
+		Name: "10%", DiscountType: models.DiscountTypePercent, DiscountPercent: 10,
+		IsActive: true, StartsAt: now.Add(-time.Hour), EndsAt: now.Add(time.Hour), CombinableWithVoucher: true,
+	}}, now)
+
+	got := ApplyVoucher(q, &models.Voucher{DiscountType: models.DiscountTypePercent, DiscountPercent: 10})
+	if got != 900000 {
+		t.Errorf("voucher took %v, want 900000", got)
+	}
+	if q.ItemDiscount != 1900000 || q.Total() != 8130000 {
+		t.Errorf("item discount %v and total %v, want 1900000 and 8130000", q.ItemDiscount, q.Total())
+	}
+	checkLineDiscounts(t, q)
+}
@@ -0,0 +1,126 @@
+package pricing
+
+import (
+	"context"
+	"time"
+
+	"phone-store-backend/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type Repository struct {
+	db *mongo.Database
+}
+
+func NewRepository(db *mongo.Database) *Repository {
+	return &Repository{db: db}
+}
+
+// FindVariantsByIDs loads the active variants among ids, keyed by ID
+func (r *Repository) FindVariantsByIDs(ctx context.Context, ids []primitive.ObjectID) (map[primitive.ObjectID]*models.ProductVariant, error) {
+	cursor, err := r.db.Collection("product_variants").Find(ctx, bson.M{"_id": bson.M{"$in": ids}, "isActive": true})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var variants []*models.ProductVariant
+	if err := cursor.All(ctx, &variants); err != nil {
+		return nil, err
+	}
+
+	byID := make(map[primitive.ObjectID]*models.ProductVariant, len(variants))
+	for _, variant := range variants {
+		byID[variant.ID] = variant
+	}
+	return byID, nil
+}
+
+// FindProductsByIDs loads the active products among ids, keyed by ID
+func (r *Repository) FindProductsByIDs(ctx context.Context, ids []primitive.ObjectID) (map[primitive.ObjectID]*models.Product, error) {
+	cursor, err := r.db.Collection("products").Find(ctx, bson.M{"_id": bson.M{"$in": ids}, "isActive": true})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var products []*models.Product
+	if err := cursor.All(ctx, &products); err != nil {
+		return nil, err
+	}
+
+	byID := make(map[primitive.ObjectID]*models.Product, len(products))
+	for _, product := range products {
+		byID[product.ID] = product
+	}
+	return byID, nil
+}
+
+func (r *Repository) FindShippingMethodByID(ctx context.Context, id primitive.ObjectID) (*models.ShippingMethod, error) {
+	var method models.ShippingMethod
+	err := r.db.Collection("shipping_methods").FindOne(ctx, bson.M{"_id": id, "isActive": true}).Decode(&method)
+	return &method, err
+}
+
+// FindVoucherByCode also returns inactive vouchers so callers can tell the
+// customer why a code was rejected
+func (r *Repository) FindVoucherByCode(ctx context.Context, code string) (*models.Voucher, error) {
+	var voucher models.Voucher
+	err := r.db.Collection("vouchers").FindOne(ctx, bson.M{"code": code}).Decode(&voucher)
+	return &voucher, err
+}
+
+func (r *Repository) FindVoucherByID(ctx context.Context, id primitive.ObjectID) (*models.Voucher, error) {
+	var voucher models.Voucher
+	err := r.db.Collection("vouchers").FindOne(ctx, bson.M{"_id": id}).Decode(&voucher)
+	return &voucher, err
+}
+
+// FindVoucherCode finds a generated single-use code
+func (r *Repository) FindVoucherCode(ctx context.Context, code string) (*models.VoucherCode, error) {
+	var voucherCode models.VoucherCode
+	err := r.db.Collection("voucher_codes").FindOne(ctx, bson.M{"code": code}).Decode(&voucherCode)
+	return &voucherCode, err
+}
+
+// CountUserRedemptions counts the active redemptions of a voucher by a user
+func (r *Repository) CountUserRedemptions(ctx context.Context, voucherID, userID primitive.ObjectID) (int64, error) {
+	return r.db.Collection("voucher_redemptions").CountDocuments(ctx, bson.M{
+		"voucherId": voucherID,
+		"userId":    userID,
+		"status":    models.RedemptionStatusActive,
+	})
+}
+
+// CountPlacedOrders counts a user's orders that were not canceled
+func (r *Repository) CountPlacedOrders(ctx context.Context, userID primitive.ObjectID) (int64, error) {
+	return r.db.Collection("orders").CountDocuments(ctx, bson.M{
+		"userId": userID,
+		"status": bson.M{"$ne": models.OrderStatusCanceled},
+	})
+}
+
+// FindRunningPromotions returns the active promotions live at time now,
+// highest priority first
+func (r *Repository) FindRunningPromotions(ctx context.Context, now time.Time) ([]*models.Promotion, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "priority", Value: -1}, {Key: "createdAt", Value: 1}})
+	cursor, err := r.db.Collection("promotions").Find(ctx, bson.M{
+		"isActive": true,
+		"startsAt": bson.M{"$lte": now},
+		"endsAt":   bson.M{"$gt": now},
+	}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var promotions []*models.Promotion
+	if err := cursor.All(ctx, &promotions); err != nil {
+		return nil, err
+	}
+	return promotions, nil
+}
@@ -0,0 +1,230 @@
+package pricing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"phone-store-backend/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ErrShippingMethodNotFound is returned for unknown or disabled methods
+var ErrShippingMethodNotFound = errors.New("shipping method not found")
+
+type Service struct {
+	repo *Repository
+}
+
+func NewService(repo *Repository) *Service {
+	return &Service{repo: repo}
+}
+
+// Request describes what to price
+type Request struct {
+	UserID           primitive.ObjectID
+	Items            []models.CartItem
+	ShippingMethodID string // Optional; no shipping fee when empty
+	VoucherCode      string // Optional
+}
+
+// Item is a priced line with the catalog documents it was priced from
+type Item struct {
+	Variant *models.ProductVariant
+	Product *models.Product
+	Line    *Line
+}
+
+// Result is a priced cart
+type Result struct {
+	*Quote
+	Items           []*Item
+	ShippingMethod  *models.ShippingMethod
+	VoucherCode     string
+	SingleUseCode   *models.VoucherCode // Set when VoucherCode is a generated code
+	Voucher         *models.Voucher     // Applied voucher, nil when none was applied
+	VoucherDiscount float64             // Part of the discount that came from Voucher
+	VoucherErr      error               // Why VoucherCode was rejected, a *VoucherError
+}
+
+// Price prices cart items at current catalog prices and applies the
+// shipping method, running promotions and then the voucher. A rejected
+// voucher does not fail the call; the quote is returned without it and
+// VoucherErr says why.
+func (s *Service) Price(ctx context.Context, req *Request) (*Result, error) {
+	result := &Result{}
+	var lines []*Line
+
+	// Variants and their products are loaded with one query each, however
+	// many lines the cart has
+	variantIDs := make([]primitive.ObjectID, 0, len(req.Items))
+	for _, cartItem := range req.Items {
+		variantIDs = append(variantIDs, cartItem.VariantID)
+	}
+	variants, err := s.repo.FindVariantsByIDs(ctx, variantIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	productIDs := make([]primitive.ObjectID, 0, len(variants))
+	for _, variant := range variants {
+		productIDs = append(productIDs, variant.ProductID)
+	}
+	products, err := s.repo.FindProductsByIDs(ctx, productIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, cartItem := range req.Items {
+		variant, ok := variants[cartItem.VariantID]
+		if !ok {
+			return nil, fmt.Errorf("variant %s not found", cartItem.VariantID.Hex())
+		}
+
+		product, ok := products[variant.ProductID]
+		if !ok {
+			return nil, fmt.Errorf("product not found for variant %s", variant.SKU)
+		}
+
+		line := &Line{
+			VariantID:  variant.ID,
+			ProductID:  product.ID,
+			BrandID:    product.BrandID,
+			CategoryID: product.CategoryID,
+			UnitPrice:  variant.Price,
+			Quantity:   cartItem.Quantity,
+		}
+		lines = append(lines, line)
+		result.Items = append(result.Items, &Item{Variant: variant, Product: product, Line: line})
+	}
+
+	var shippingFee float64
+	if req.ShippingMethodID != "" {
+		methodID, err := primitive.ObjectIDFromHex(req.ShippingMethodID)
+		if err != nil {
+			return nil, errors.New("invalid shipping method ID")
+		}
+		method, err := s.repo.FindShippingMethodByID(ctx, methodID)
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrShippingMethodNotFound
+		} else if err != nil {
+			return nil, err
+		}
+		result.ShippingMethod = method
+		shippingFee = method.Cost
+	}
+
+	result.Quote = NewQuote(lines, shippingFee)
+	now := time.Now()
+
+	if len(lines) > 0 {
+		promotions, err := s.repo.FindRunningPromotions(ctx, now)
+		if err != nil {
+			return nil, err
+		}
+		ApplyPromotions(result.Quote, promotions, now)
+	}
+
+	result.VoucherCode = strings.ToUpper(strings.TrimSpace(req.VoucherCode))
+	if result.VoucherCode != "" {
+		voucher, discount, err := s.applyVoucher(ctx, req.UserID, result, now)
+		var voucherErr *VoucherError
+		if errors.As(err, &voucherErr) {
+			result.VoucherErr = err
+		} else if err != nil {
+			return nil, err
+		}
+		result.Voucher = voucher
+		result.VoucherDiscount = discount
+	}
+
+	return result, nil
+}
+
+func (s *Service) applyVoucher(ctx context.Context, userID primitive.ObjectID, result *Result, now time.Time) (*models.Voucher, float64, error) {
+	q := result.Quote
+	voucher, singleUseCode, err := s.findVoucher(ctx, result.VoucherCode)
+	if err != nil {
+		return nil, 0, err
+	}
+	result.SingleUseCode = singleUseCode
+
+	if err := CheckVoucher(voucher, q.SubTotal, now); err != nil {
+		return nil, 0, err
+	}
+	if q.voucherBlocked {
+		return nil, 0, ErrVoucherNotCombinable
+	}
+	if err := s.CheckCustomer(ctx, voucher, userID); err != nil {
+		return nil, 0, err
+	}
+
+	discount := ApplyVoucher(q, voucher)
+	if discount <= 0 {
+		return nil, 0, ErrVoucherNotApplicable
+	}
+	return voucher, discount, nil
+}
+
+// findVoucher resolves a code typed by the customer: a voucher's own code or
+// one of its generated single-use codes
+func (s *Service) findVoucher(ctx context.Context, code string) (*models.Voucher, *models.VoucherCode, error) {
+	voucher, err := s.repo.FindVoucherByCode(ctx, code)
+	if err == nil {
+		if voucher.CodesOnly {
+			return nil, nil, ErrVoucherNotFound
+		}
+		return voucher, nil, nil
+	} else if err != mongo.ErrNoDocuments {
+		return nil, nil, err
+	}
+
+	singleUseCode, err := s.repo.FindVoucherCode(ctx, code)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil, ErrVoucherNotFound
+	} else if err != nil {
+		return nil, nil, err
+	}
+	if singleUseCode.Status != models.VoucherCodeStatusAvailable {
+		return nil, nil, ErrVoucherCodeUsed
+	}
+
+	voucher, err = s.repo.FindVoucherByID(ctx, singleUseCode.VoucherID)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil, ErrVoucherNotFound
+	} else if err != nil {
+		return nil, nil, err
+	}
+	return voucher, singleUseCode, nil
+}
+
+// CheckCustomer enforces the voucher rules that depend on the customer's
+// history. Checkout calls it again inside its transaction so the answer
+// cannot change before the redemption is recorded.
+func (s *Service) CheckCustomer(ctx context.Context, voucher *models.Voucher, userID primitive.ObjectID) error {
+	if voucher.FirstOrderOnly {
+		placed, err := s.repo.CountPlacedOrders(ctx, userID)
+		if err != nil {
+			return err
+		}
+		if placed > 0 {
+			return ErrVoucherFirstOrderOnly
+		}
+	}
+
+	if voucher.PerUserLimit > 0 {
+		used, err := s.repo.CountUserRedemptions(ctx, voucher.ID, userID)
+		if err != nil {
+			return err
+		}
+		if used >= int64(voucher.PerUserLimit) {
+			return ErrVoucherPerUserLimit
+		}
+	}
+
+	return nil
+}
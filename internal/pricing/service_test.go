@@ -0,0 +1,75 @@
+package pricing
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"phone-store-backend/internal/models"
+	"phone-store-backend/internal/testdb"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// seedCart saves lines products with one variant each, priced 1M apart, and
+// returns a cart holding one of every variant
+func seedCart(tb testing.TB, database *mongo.Database, lines int) []models.CartItem {
+	tb.Helper()
+	ctx := context.Background()
+	now := time.Now()
+
+	var products, variants []interface{}
+	items := make([]models.CartItem, 0, lines)
+	for i := 0; i < lines; i++ {
+		product := &models.Product{ID: primitive.NewObjectID(), Name: fmt.Sprintf("Phone %d", i), Slug: fmt.Sprintf("phone-%d", i), IsActive: true, CreatedAt: now, UpdatedAt: now}
+		variant := &models.ProductVariant{ID: primitive.NewObjectID(), ProductID: product.ID, SKU: fmt.Sprintf("PH-%d", i), Price: float64(i+1) * 1000000, Stock: 10, IsActive: true, CreatedAt: now, UpdatedAt: now}
+		products = append(products, product)
+		variants = append(variants, variant)
+		items = append(items, models.CartItem{VariantID: variant.ID, Quantity: 1})
+	}
+
+	if _, err := database.Collection("products").InsertMany(ctx, products); err != nil {
+		tb.Fatalf("seed products: %v", err)
+	}
+	if _, err := database.Collection("product_variants").InsertMany(ctx, variants); err != nil {
+		tb.Fatalf("seed variants: %v", err)
+	}
+	return items
+}
+
+func TestPricePricesEveryLine(t *testing.T) {
+	database := testdb.Open(t)
+	service := NewService(NewRepository(database))
+	items := seedCart(t, database, 3)
+
+	// The same variant twice is priced as two lines
+	items = append(items, items[0])
+	result, err := service.Price(context.Background(), &Request{Items: items})
+	if err != nil {
+		t.Fatalf("price: %v", err)
+	}
+	if len(result.Items) != 4 || result.SubTotal != 7000000 {
+		t.Errorf("priced %d lines for %.0f, want 4 lines for 7000000", len(result.Items), result.SubTotal)
+	}
+
+	missing := append(items, models.CartItem{VariantID: primitive.NewObjectID(), Quantity: 1})
+	if _, err := service.Price(context.Background(), &Request{Items: missing}); err == nil {
+		t.Error("priced a cart with an unknown variant")
+	}
+}
+
+func BenchmarkPrice(b *testing.B) {
+	database := testdb.Open(b)
+	service := NewService(NewRepository(database))
+	items := seedCart(b, database, 20)
+	ctx := context.Background()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := service.Price(ctx, &Request{Items: items}); err != nil {
+			b.Fatalf("price: %v", err)
+		}
+	}
+}
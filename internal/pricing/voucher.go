@@ -0,0 +1,77 @@
+package pricing
+
+import (
+	"time"
+
+	"phone-store-backend/internal/models"
+)
+
+// VoucherReason is a machine-readable reason a voucher was rejected
+type VoucherReason string
+
+const (
+	ReasonVoucherNotFound   VoucherReason = "VOUCHER_NOT_FOUND"
+	ReasonCodeAlreadyUsed   VoucherReason = "CODE_ALREADY_USED"
+	ReasonVoucherInactive   VoucherReason = "VOUCHER_INACTIVE"
+	ReasonVoucherNotStarted VoucherReason = "VOUCHER_NOT_STARTED"
+	ReasonVoucherExpired    VoucherReason = "VOUCHER_EXPIRED"
+	ReasonBelowMinOrder     VoucherReason = "BELOW_MIN_ORDER_VALUE"
+	ReasonUsageExhausted    VoucherReason = "USAGE_EXHAUSTED"
+	ReasonPerUserLimit      VoucherReason = "PER_USER_LIMIT_REACHED"
+	ReasonFirstOrderOnly    VoucherReason = "FIRST_ORDER_ONLY"
+	ReasonNotApplicable     VoucherReason = "NOT_APPLICABLE"
+	ReasonNotCombinable     VoucherReason = "NOT_COMBINABLE_WITH_PROMOTION"
+)
+
+// VoucherError explains why a voucher cannot be applied
+type VoucherError struct {
+	Reason  VoucherReason
+	Message string
+}
+
+func (e *VoucherError) Error() string {
+	return e.Message
+}
+
+// Is matches any voucher error with the same reason
+func (e *VoucherError) Is(target error) bool {
+	t, ok := target.(*VoucherError)
+	return ok && t.Reason == e.Reason
+}
+
+// Voucher rejection errors
+var (
+	ErrVoucherNotFound       = &VoucherError{ReasonVoucherNotFound, "voucher not found"}
+	ErrVoucherCodeUsed       = &VoucherError{ReasonCodeAlreadyUsed, "voucher code has already been used"}
+	ErrVoucherInactive       = &VoucherError{ReasonVoucherInactive, "voucher is not active"}
+	ErrVoucherNotStarted     = &VoucherError{ReasonVoucherNotStarted, "voucher is not valid yet"}
+	ErrVoucherExpired        = &VoucherError{ReasonVoucherExpired, "voucher has expired"}
+	ErrBelowMinOrderValue    = &VoucherError{ReasonBelowMinOrder, "order does not reach the voucher's minimum value"}
+	ErrVoucherUsageExhausted = &VoucherError{ReasonUsageExhausted, "voucher usage limit has been reached"}
+	ErrVoucherPerUserLimit   = &VoucherError{ReasonPerUserLimit, "you have already used this voucher the maximum number of times"}
+	ErrVoucherFirstOrderOnly = &VoucherError{ReasonFirstOrderOnly, "voucher is only valid for your first order"}
+	ErrVoucherNotApplicable  = &VoucherError{ReasonNotApplicable, "voucher does not apply to any item in the cart"}
+	ErrVoucherNotCombinable  = &VoucherError{ReasonNotCombinable, "voucher cannot be combined with a promotion on this order"}
+)
+
+// CheckVoucher reports why a voucher cannot be used at time now for an
+// order with the given subtotal, or nil when it can. Per-customer rules are
+// checked separately since they need the customer's history.
+func CheckVoucher(v *models.Voucher, subTotal float64, now time.Time) error {
+	if !v.IsActive {
+		return ErrVoucherInactive
+	}
+	if v.StartsAt != nil && now.Before(*v.StartsAt) {
+		return ErrVoucherNotStarted
+	}
+	if !now.Before(v.ExpiredAt) {
+		return ErrVoucherExpired
+	}
+	if subTotal < v.MinOrderValue {
+		return ErrBelowMinOrderValue
+	}
+	if v.UsageLimit > 0 && v.UsedCount >= v.UsageLimit {
+		return ErrVoucherUsageExhausted
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package search
+
+import "sort"
+
+// maxExpansions bounds how many known terms a single query word expands to
+const maxExpansions = 10
+
+// maxEdits is how many typos a query word of the given length may contain.
+// Short words must match exactly or they would match almost anything.
+func maxEdits(length int) int {
+	switch {
+	case length >= 8:
+		return 2
+	case length >= 4:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// Expand returns the words of query together with the known terms they
+// probably meant: terms within a few typos of a word, and terms a word of
+// three letters or more is the start of. Exact words come first.
+func Expand(query string, vocabulary []string) []string {
+	type candidate struct {
+		term     string
+		distance int
+	}
+
+	seen := make(map[string]bool)
+	var expanded []string
+	for _, word := range Terms(query) {
+		if !seen[word] {
+			seen[word] = true
+			expanded = append(expanded, word)
+		}
+
+		wordRunes := []rune(word)
+		limit := maxEdits(len(wordRunes))
+
+		var candidates []candidate
+		for _, term := range vocabulary {
+			if term == word {
+				continue
+			}
+			termRunes := []rune(term)
+			if len(wordRunes) >= 3 && hasPrefix(termRunes, wordRunes) {
+				candidates = append(candidates, candidate{term, 0})
+				continue
+			}
+			if limit == 0 || abs(len(termRunes)-len(wordRunes)) > limit {
+				continue
+			}
+			if d := distance(wordRunes, termRunes); d <= limit {
+				candidates = append(candidates, candidate{term, d})
+			}
+		}
+
+		sort.SliceStable(candidates, func(i, j int) bool {
+			if candidates[i].distance != candidates[j].distance {
+				return candidates[i].distance < candidates[j].distance
+			}
+			return len(candidates[i].term) < len(candidates[j].term)
+		})
+		if len(candidates) > maxExpansions {
+			candidates = candidates[:maxExpansions]
+		}
+
+		for _, c := range candidates {
+			if !seen[c.term] {
+				seen[c.term] = true
+				expanded = append(expanded, c.term)
+			}
+		}
+	}
+	return expanded
+}
+
+// distance is the Damerau-Levenshtein distance between a and b, counting a
+// swap of two neighbouring letters as one typo
+func distance(a, b []rune) int {
+	prev2 := make([]int, len(b)+1)
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				cur[j] = min(cur[j], prev2[j-2]+1)
+			}
+		}
+		prev2, prev, cur = prev, cur, prev2
+	}
+	return prev[len(b)]
+}
+
+func hasPrefix(s, prefix []rune) bool {
+	if len(s) < len(prefix) {
+		return false
+	}
+	for i := range prefix {
+		if s[i] != prefix[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,78 @@
+package search
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"", "abc", 3},
+		{"iphone", "iphone", 0},
+		{"iphone", "iphane", 1},  // Substitution
+		{"iphone", "iphon", 1},   // Deletion
+		{"iphone", "iphonee", 1}, // Insertion
+		{"iphone", "iphnoe", 1},  // Swapped neighbours count once
+		{"samsung", "smasnug", 2},
+		{"kitten", "sitting", 3},
+		{"điện", "dien", 2}, // Runes, not bytes
+	}
+	for _, tt := range tests {
+		if got := distance([]rune(tt.a), []rune(tt.b)); got != tt.want {
+			t.Errorf("distance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+		if got := distance([]rune(tt.b), []rune(tt.a)); got != tt.want {
+			t.Errorf("distance(%q, %q) = %d, want %d", tt.b, tt.a, got, tt.want)
+		}
+	}
+}
+
+func TestExpand(t *testing.T) {
+	vocabulary := []string{"iphone", "ipad", "samsung", "galaxy", "xiaomi", "dien", "thoai", "den", "pro", "promax"}
+
+	tests := []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{"exact words are kept", "iPhone", []string{"iphone"}},
+		{"one typo", "iphnoe", []string{"iphnoe", "iphone"}},
+		{"two typos in a long word", "samsnug", []string{"samsnug", "samsung"}},
+		{"start of a word", "sam", []string{"sam", "samsung"}},
+		{"start of several words", "pro", []string{"pro", "promax"}},
+		{"folded query", "Điện thoại", []string{"dien", "den", "thoai"}},
+		{"short words match exactly", "ip", []string{"ip"}},
+		{"unknown words are kept", "nokia", []string{"nokia"}},
+		{"repeated words once", "iphone iphone", []string{"iphone"}},
+		{"empty query", "", nil},
+	}
+	for _, tt := range tests {
+		if got := Expand(tt.query, vocabulary); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: Expand(%q) = %q, want %q", tt.name, tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestExpandClosestTermsFirst(t *testing.T) {
+	got := Expand("phone", []string{"phones", "phne", "iphone", "phonee"})
+	// Words starting with the query come first, then fewest typos, then shortest
+	want := []string{"phone", "phones", "phonee", "phne", "iphone"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expand = %q, want %q", got, want)
+	}
+}
+
+func TestExpandBoundsExpansions(t *testing.T) {
+	var vocabulary []string
+	for i := 0; i < 50; i++ {
+		vocabulary = append(vocabulary, fmt.Sprintf("galaxy%d", i))
+	}
+	if got := Expand("gal", vocabulary); len(got) != 1+maxExpansions {
+		t.Errorf("Expand returned %d terms, want %d", len(got), 1+maxExpansions)
+	}
+}
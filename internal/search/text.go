@@ -0,0 +1,62 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Fold lowercases s and removes its diacritics, so that "Điện Thoại" and
+// "dien thoai" compare equal. The same folding is applied to stored text
+// and to queries.
+func Fold(s string) string {
+	t := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	folded, _, err := transform.String(t, s)
+	if err != nil {
+		folded = s
+	}
+
+	// đ is a letter of its own, not d with a mark, so NFD leaves it alone
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case 'đ', 'Đ':
+			return 'd'
+		}
+		return unicode.ToLower(r)
+	}, folded)
+}
+
+// Tokens splits folded text into words of letters and digits
+func Tokens(s string) []string {
+	return strings.FieldsFunc(Fold(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// Text folds several values into one space-separated string of words,
+// ready to be stored in a text index
+func Text(values ...string) string {
+	var words []string
+	for _, value := range values {
+		words = append(words, Tokens(value)...)
+	}
+	return strings.Join(words, " ")
+}
+
+// Terms returns the distinct words of values, in first-seen order
+func Terms(values ...string) []string {
+	seen := make(map[string]bool)
+	var terms []string
+	for _, value := range values {
+		for _, token := range Tokens(value) {
+			if !seen[token] {
+				seen[token] = true
+				terms = append(terms, token)
+			}
+		}
+	}
+	return terms
+}
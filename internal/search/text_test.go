@@ -0,0 +1,35 @@
+package search
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFold(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"Điện Thoại", "dien thoai"},
+		{"đồng hồ", "dong ho"},
+		{"ĐỒNG HỒ", "dong ho"},
+		{"Ưu đãi", "uu dai"},
+		{"Sạc nhanh 25W", "sac nhanh 25w"},
+		{"iPhone 15 Pro Max", "iphone 15 pro max"},
+		{"dien thoai", "dien thoai"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := Fold(tt.in); got != tt.want {
+			t.Errorf("Fold(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestTokensAndTerms(t *testing.T) {
+	if got, want := Tokens("Galaxy S24 Ultra - 256GB, Đen"), []string{"galaxy", "s24", "ultra", "256gb", "den"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Tokens = %q, want %q", got, want)
+	}
+	if got, want := Terms("Điện thoại Xiaomi", "dien THOAI redmi"), []string{"dien", "thoai", "xiaomi", "redmi"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Terms = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,44 @@
+// Package testdb gives tests a throwaway MongoDB database. Tests that need
+// one are skipped unless MONGO_TEST_URI points at a replica set (checkout
+// and other flows use multi-document transactions), e.g.
+//
+//	MONGO_TEST_URI="mongodb://localhost:27017/?replicaSet=rs0" go test ./...
+package testdb
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"phone-store-backend/internal/db"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Open connects to a fresh database with the application's indexes and
+// drops it when the test ends
+func Open(tb testing.TB) *mongo.Database {
+	tb.Helper()
+
+	uri := os.Getenv("MONGO_TEST_URI")
+	if uri == "" {
+		tb.Skip("MONGO_TEST_URI not set")
+	}
+
+	name := fmt.Sprintf("phone_store_test_%d", time.Now().UnixNano())
+	mongodb, err := db.Connect(uri, name)
+	if err != nil {
+		tb.Fatalf("connect to test database: %v", err)
+	}
+
+	tb.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		mongodb.Database.Drop(ctx)
+		mongodb.Disconnect()
+	})
+
+	return mongodb.Database
+}